@@ -12,6 +12,8 @@ import (
 	"path"
 	"slices"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/google/uuid"
@@ -20,18 +22,37 @@ import (
 
 // App struct
 type App struct {
-	ctx             context.Context
-	IsLoading       bool          `json:"isLoading"`
-	StartupState    *StartupState `json:"startupState"`
-	AppConfig       *AppConfig    `json:"appConfig"`
-	terminalManager command_utils.XTermSessionManager
-	diffSessions    map[string]*git_operations.DiffSession
+	ctx                context.Context
+	IsLoading          bool          `json:"isLoading"`
+	StartupState       *StartupState `json:"startupState"`
+	AppConfig          *AppConfig    `json:"appConfig"`
+	terminalManager    command_utils.XTermSessionManager
+	diffSessionManager *git_operations.DiffSessionManager
+	repoWatcherManager *git_operations.RepoWatcherManager
+	jobManager         *command_utils.JobManager
+	credentialPrompts  *command_utils.CredentialPromptServer
+	instanceServer     *InstanceServer
+	mergeTools         *mergeToolSessions
+
+	// Directory diffs forwarded by other processes, which wait until they're closed
+	forwardedDirDiffs      map[string]chan bool
+	forwardedDirDiffsMutex sync.Mutex
+
+	// Set by Startup when the window was opened as git's mergetool
+	startupMerge *MergeToolRequest
+	exitCode     atomic.Int32
+
+	// Started and stopped by UpdateSettings and Shutdown, which can run at the same time
+	automationServer      *AutomationServer
+	automationServerMutex sync.Mutex
 }
 
 // NewApp creates a new App application struct
 func NewApp() *App {
 	app := App{}
 	app.IsLoading = true
+	app.mergeTools = newMergeToolSessions()
+	app.forwardedDirDiffs = make(map[string]chan bool)
 	return &app
 }
 
@@ -53,29 +74,46 @@ func (app *App) Startup(ctx context.Context, startupState *StartupState) {
 
 	app.StartupState = startupState
 	app.AppConfig = appConfig
+	app.jobManager = command_utils.NewJobManager(ctx)
+	app.credentialPrompts, err = command_utils.StartCredentialPromptServer(ctx)
+	if err != nil {
+		logger.Log.Error("Failed to start the credential prompt server, git won't be able to ask for credentials: %v", err)
+	}
+	git_operations.SetGitBackend(appConfig.Settings.Git.Backend)
 	app.terminalManager = command_utils.XTermSessionManager{
 		Ctx:              ctx,
 		Settings:         &appConfig.Settings.Terminal,
 		TerminalSessions: map[string]*command_utils.TerminalSession{},
 	}
-	app.diffSessions = make(map[string]*git_operations.DiffSession)
 
-	if startupState.DirectoryDiffArgs != nil {
-		if startupState.DirectoryDiffArgs.ShouldStartFileWatcher {
-			watcher, err := StartFileDiffWatcher(ctx)
-			if err != nil {
-				logger.Log.Error("Failed to start file diff watcher: %v", err)
-			} else {
-				startupState.fileDiffWatcher = watcher
-			}
-		}
+	if startupState.MergeToolArgs != nil {
+		app.startStartupMergeTool(*startupState.MergeToolArgs)
+	}
+
+	if !app.isMainWindow() {
+		// Diff and merge tool windows don't restore or clean up the diff sessions of the main window
+		app.diffSessionManager = git_operations.NewDiffSessionManager(&appConfig.Settings.Git.DiffSessions, "")
 	} else {
-		// Ensure git difftool is configured with helper script
-		logger.Log.Debug("Ensuring git difftool configuration...")
-		if err := git_operations.SetupGitDirDiffHelperScript(); err != nil {
-			logger.Log.Error("Failed to configure git difftool: %v", err)
+		diffSessionsFolder, err := lib.GetDiffSessionsFolderPath()
+		if err != nil {
+			logger.Log.Error("Failed to get the diff sessions folder, open diff sessions won't be saved: %v", err)
 		}
-		logger.Log.Debug("Git difftool configuration completed successfully")
+		app.diffSessionManager = git_operations.NewDiffSessionManager(&appConfig.Settings.Git.DiffSessions, diffSessionsFolder)
+		app.diffSessionManager.StartJanitor()
+
+		// Repos that were left open last time are watched right away
+		app.repoWatcherManager = git_operations.NewRepoWatcherManager(ctx)
+		for _, repoPath := range appConfig.getOpenRepos() {
+			app.watchRepo(repoPath)
+		}
+
+		app.setAutomationServerEnabled(appConfig.Settings.Automation.Enabled)
+	}
+
+	// The first window becomes the running instance, which the next GitWhale processes forward their requests to
+	app.instanceServer, err = StartInstanceServer(app)
+	if err != nil {
+		logger.Log.Info("Not listening for requests from other GitWhale processes: %v", err)
 	}
 
 	// Set up frontend log event listener
@@ -84,11 +122,20 @@ func (app *App) Startup(ctx context.Context, startupState *StartupState) {
 
 // Saves the config file
 func (app *App) Shutdown(ctx context.Context) {
-	if app.StartupState.DirectoryDiffArgs != nil {
-		if app.StartupState.DirectoryDiffArgs.ShouldStartFileWatcher {
-			CloseFileDiffWatcher(app.StartupState.fileDiffWatcher)
-		}
+	if app.instanceServer != nil {
+		app.instanceServer.Stop()
+	}
+	app.jobManager.CancelAll()
+	app.mergeTools.CancelAll()
+	app.closeForwardedDirDiffs()
+	if app.credentialPrompts != nil {
+		app.credentialPrompts.Close()
+	}
+	app.diffSessionManager.Stop()
+	if app.repoWatcherManager != nil {
+		app.repoWatcherManager.StopAll()
 	}
+	app.setAutomationServerEnabled(false)
 
 	err := app.AppConfig.SaveAppConfig()
 	if err != nil {
@@ -96,8 +143,9 @@ func (app *App) Shutdown(ctx context.Context) {
 	}
 }
 
+// Returns a copy of the state, since the open repos can change while it's sent to the frontend
 func (a *App) GetAppState() *App {
-	return a
+	return &App{IsLoading: a.IsLoading, StartupState: a.StartupState, AppConfig: a.AppConfig.snapshot()}
 }
 
 // Reads any arbitrary file and provides it to the web process
@@ -130,15 +178,58 @@ func (app *App) NormalizeFolderPath(gitRepoPath string) string {
 
 // Actually opens the repo and adds it to the app's state
 func (app *App) OpenRepoWithPath(gitRepoPath string) {
-	app.AppConfig.openNewRepo(gitRepoPath)
+	app.AppConfig.openNewRepo(app.ctx, gitRepoPath)
+	app.watchRepo(gitRepoPath)
 }
 
 func (app *App) CloseRepo(gitRepoPath string) *App {
 	app.AppConfig.closeRepo(gitRepoPath)
 	app.CleanupTerminalSession(gitRepoPath)
+	if app.repoWatcherManager != nil {
+		app.repoWatcherManager.Unwatch(gitRepoPath)
+	}
 	return app
 }
 
+// Opens the repo for a request that came from outside the window (a script or another GitWhale process), and
+// asks the frontend to show it
+func (app *App) openRepoFromOutside(gitRepoPath string) {
+	gitRepoPath = app.NormalizeFolderPath(gitRepoPath)
+	app.OpenRepoWithPath(gitRepoPath)
+	runtime.EventsEmit(app.ctx, AutomationOpenRepoTopic, gitRepoPath)
+	app.showWindow()
+}
+
+// Shows a command that another GitWhale process forwarded. The frontend routes it like the startup state
+func (app *App) runStartupCommand(state *StartupState) {
+	app.OpenRepoWithPath(app.NormalizeFolderPath(state.RepoPath))
+	runtime.EventsEmit(app.ctx, StartupCommandTopic, state)
+	app.showWindow()
+}
+
+// Diff and merge tool windows only live as long as their diff or merge, so they leave the repo watchers, saved diff
+// sessions and the automation server to the main window
+func (app *App) isMainWindow() bool {
+	return app.StartupState.DirectoryDiffArgs == nil && app.StartupState.MergeToolArgs == nil
+}
+
+// Brings the window to the front, e.g. when another app asked to show something
+func (app *App) showWindow() {
+	runtime.WindowUnminimise(app.ctx)
+	runtime.WindowShow(app.ctx)
+}
+
+// Starts pushing RepoWatchEvents for the repo. The repo still works without them, so errors are only logged
+func (app *App) watchRepo(gitRepoPath string) {
+	if app.repoWatcherManager == nil {
+		return
+	}
+
+	if err := app.repoWatcherManager.Watch(gitRepoPath); err != nil {
+		logger.Log.Error("Failed to watch the repo %s for changes: %v", gitRepoPath, err)
+	}
+}
+
 func (app *App) InitNewTerminalSession(repoPath string) {
 	app.terminalManager.SetupXTermForNewRepo(repoPath)
 }
@@ -161,28 +252,31 @@ func (app *App) RunGitLog(gitRepoPath string, options *git_operations.GitLogOpti
 		options.CommitsToLoad = &app.AppConfig.Settings.Git.CommitsToLoad
 	}
 
-	return git_operations.ReadGitLog(gitRepoPath, *options)
+	return git_operations.ReadGitLog(app.ctx, gitRepoPath, *options)
 }
 
 func (app *App) GetDetailedCommitInfo(repoPath string, commitHash string) (*git_operations.DetailedCommitInfo, error) {
-	return git_operations.GetDetailedCommitInfo(repoPath, commitHash)
+	return git_operations.GetDetailedCommitInfo(app.ctx, repoPath, commitHash, &app.AppConfig.Settings.Git.DiffSettings)
 }
 
 func (app *App) GetAllRefs(gitRepoPath string) []git_operations.GitRef {
-	return git_operations.GetAllRefs(gitRepoPath)
+	return git_operations.GetAllRefs(app.ctx, gitRepoPath)
 }
 
 func (app *App) GetWorktrees(gitRepoPath string) []git_operations.WorktreeInfo {
-	return git_operations.GetWorktrees(gitRepoPath)
+	return git_operations.GetWorktrees(app.ctx, gitRepoPath)
 }
 
+// GitFetch fetches origin as a background job, and waits for it
 func (app *App) GitFetch(gitRepoPath string) error {
-	return git_operations.GitFetch(gitRepoPath)
+	return app.runJob(command_utils.JobOptions{Title: "Fetch origin", RepoPath: gitRepoPath, WritesToRepo: true}, func(ctx context.Context) error {
+		return git_operations.GitFetch(ctx, gitRepoPath)
+	})
 }
 
 // ValidateRef checks if a Git reference is valid in the given repository
 func (app *App) ValidateRef(gitRepoPath string, ref string) bool {
-	return git_operations.ValidateGitRef(gitRepoPath, ref)
+	return git_operations.ValidateGitRef(app.ctx, gitRepoPath, ref)
 }
 
 func (app *App) ToggleStarRepo(gitRepoPath string) bool {
@@ -194,10 +288,39 @@ func (app *App) UpdateSettings(newSettings AppSettings) error {
 	if err != nil {
 		app.terminalManager.Settings = &newSettings.Terminal
 	}
+	git_operations.SetGitBackend(app.AppConfig.Settings.Git.Backend)
+	if app.isMainWindow() {
+		app.setAutomationServerEnabled(app.AppConfig.Settings.Automation.Enabled)
+	}
 
 	return err
 }
 
+// Starts or stops the automation server. Only the main window runs it, diff tool windows are short-lived
+func (app *App) setAutomationServerEnabled(enabled bool) {
+	app.automationServerMutex.Lock()
+	defer app.automationServerMutex.Unlock()
+
+	if !enabled {
+		if app.automationServer != nil {
+			app.automationServer.Stop()
+			app.automationServer = nil
+		}
+		return
+	}
+
+	if app.automationServer != nil {
+		return
+	}
+
+	server, err := StartAutomationServer(app)
+	if err != nil {
+		logger.Log.Error("Failed to start the automation server: %v", err)
+		return
+	}
+	app.automationServer = server
+}
+
 type TerminalDefaults struct {
 	DefaultInteractiveTerminalCommand string `json:"defaultInteractiveTerminalCommand"`
 	DefaultShellForBackgroundCommands string `json:"defaultShellForBackgroundCommands"`
@@ -223,66 +346,118 @@ func (app *App) GetStartupDirDiffDirectory() *git_operations.Directory {
 		return nil
 	}
 
-	return git_operations.ReadDiffs(diffArgs.LeftPath, diffArgs.RightPath)
+	return app.GetDirDiffDirectory(diffArgs.LeftPath, diffArgs.RightPath)
+}
+
+// GetDirDiffDirectory compares two folders, e.g. for a directory diff another GitWhale process forwarded
+func (app *App) GetDirDiffDirectory(leftPath, rightPath string) *git_operations.Directory {
+	return git_operations.ReadDiffs(leftPath, rightPath, app.AppConfig.Settings.UI.HideUnchangedDirDiffFiles)
 }
 
 func (app *App) StartDiffSession(options git_operations.DiffOptions) (*git_operations.DiffSession, error) {
 	logger.Log.Info("Starting diff session for repo: %s", options.RepoPath)
 
-	session, err := git_operations.CreateDiffSession(options)
+	var session *git_operations.DiffSession
+	err := app.runJob(command_utils.JobOptions{Title: "Create diff session", RepoPath: options.RepoPath}, func(ctx context.Context) (err error) {
+		session, err = git_operations.CreateDiffSession(ctx, app.withDefaultDiffSettings(options))
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	app.trackDiffSession(session)
+	return session, nil
+}
+
+// StartMergeCommitDiffSessions diffs a merge commit against each of its parents
+func (app *App) StartMergeCommitDiffSessions(options git_operations.DiffOptions) ([]*git_operations.DiffSession, error) {
+	var sessions []*git_operations.DiffSession
+	err := app.runJob(command_utils.JobOptions{Title: "Create merge commit diff sessions", RepoPath: options.RepoPath}, func(ctx context.Context) (err error) {
+		sessions, err = git_operations.CreateMergeCommitDiffSessions(ctx, app.withDefaultDiffSettings(options))
+		return err
+	})
 	if err != nil {
 		return nil, err
 	}
 
-	if !session.HasDiffData {
-		return session, nil
+	for _, session := range sessions {
+		app.trackDiffSession(session)
 	}
+	return sessions, nil
+}
 
-	// Store session in app
-	app.diffSessions[session.SessionId] = session
+// GetUnifiedDiff returns the parsed `git diff` (files, hunks and lines) without creating a diff session
+func (app *App) GetUnifiedDiff(options git_operations.DiffOptions) (*git_operations.UnifiedDiff, error) {
+	return git_operations.GetUnifiedDiff(app.ctx, app.withDefaultDiffSettings(options))
+}
 
-	// Cleanup old sessions periodically
-	go git_operations.CleanupOldDiffSessions()
+// CompareRefs loads the merge-base, ahead/behind commits and the three-dot diff session between two refs
+func (app *App) CompareRefs(repoPath, refA, refB string) (*git_operations.RefComparison, error) {
+	var comparison *git_operations.RefComparison
+	err := app.runJob(command_utils.JobOptions{Title: "Compare refs", RepoPath: repoPath}, func(ctx context.Context) (err error) {
+		comparison, err = git_operations.CompareRefs(ctx, repoPath, refA, refB, app.AppConfig.Settings.Git.CommitsToLoad, &app.AppConfig.Settings.Git.DiffSettings)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
 
-	return session, nil
+	app.trackDiffSession(comparison.DiffSession)
+	return comparison, nil
 }
 
-func (app *App) GetDiffSession(sessionId string) *git_operations.DiffSession {
-	session, exists := app.diffSessions[sessionId]
-	if !exists {
-		return nil
+// GetRangeDiff pairs up the commits of two versions of a patch series
+func (app *App) GetRangeDiff(options git_operations.RangeDiffOptions) (*git_operations.RangeDiffResult, error) {
+	if options.DiffSettings == nil {
+		options.DiffSettings = &app.AppConfig.Settings.Git.DiffSettings
 	}
+	return git_operations.GetRangeDiff(app.ctx, options)
+}
 
-	// Update last accessed time
-	session.LastAccessed = time.Now()
-	return session
+// Falls back to the user's default diff settings when the request doesn't specify any
+func (app *App) withDefaultDiffSettings(options git_operations.DiffOptions) git_operations.DiffOptions {
+	if options.DiffSettings == nil {
+		options.DiffSettings = &app.AppConfig.Settings.Git.DiffSettings
+	}
+	return options
 }
 
-func (app *App) EndDiffSession(sessionId string) error {
-	_, exists := app.diffSessions[sessionId]
-	if !exists {
-		return fmt.Errorf("diff session not found: %s", sessionId)
+// Stores a diff session that has data, so that it can be looked up and cleaned up later
+func (app *App) trackDiffSession(session *git_operations.DiffSession) {
+	if session == nil || !session.HasDiffData {
+		return
 	}
 
-	// Cleanup temp directories
-	err := git_operations.CleanupDiffSession(sessionId)
+	app.diffSessionManager.Add(session)
+}
+
+func (app *App) GetDiffSession(sessionId string) *git_operations.DiffSession {
+	session, err := app.diffSessionManager.Get(sessionId)
 	if err != nil {
-		logger.Log.Error("Failed to cleanup diff session %s: %v", sessionId, err)
+		logger.Log.Warning("Failed to get diff session: %v", err)
+		return nil
 	}
+	return session
+}
 
-	// Remove from app sessions
-	delete(app.diffSessions, sessionId)
+func (app *App) EndDiffSession(sessionId string) error {
+	if err := app.diffSessionManager.Remove(sessionId); err != nil {
+		return err
+	}
 
 	logger.Log.Info("Ended diff session: %s", sessionId)
 	return nil
 }
 
+// GetDiffSessionFileContent loads one side of a changed file in a lazy diff session
+func (app *App) GetDiffSessionFileContent(sessionId, path string, side git_operations.DiffSide) (*git_operations.DiffFileContent, error) {
+	return app.diffSessionManager.GetFileContent(app.ctx, sessionId, path, side)
+}
+
+// ListDiffSessions returns the open diff sessions (including the ones restored after a restart), most recently used first
 func (app *App) ListDiffSessions() []*git_operations.DiffSession {
-	sessions := make([]*git_operations.DiffSession, 0, len(app.diffSessions))
-	for _, session := range app.diffSessions {
-		sessions = append(sessions, session)
-	}
-	return sessions
+	return app.diffSessionManager.List()
 }
 
 func (app *App) GetApplicationLogHistory() []logger.LogEntry {
@@ -313,6 +488,215 @@ func (app *App) ExecuteShellCommand(command string, workingDir, broadcastToTopic
 	command_utils.StartRunningAndStreamCommand(app.ctx, shellPathCommand, command, workingDir, broadcastToTopic)
 }
 
+// Repository search operations
+
+// StartGrepSearch streams `git grep` results (grouped by file) to the given topic
+func (app *App) StartGrepSearch(options git_operations.GrepOptions, broadcastToTopic string) error {
+	return git_operations.StartGrepSearch(app.ctx, options, broadcastToTopic)
+}
+
+// GetGrepFileContent loads a file in the version a search result came from (GrepFileResult's target and ref)
+func (app *App) GetGrepFileContent(repoPath string, target git_operations.GrepTarget, ref, filePath string) (string, error) {
+	return git_operations.GetGrepFileContent(app.ctx, repoPath, target, ref, filePath)
+}
+
+// GetFileContentFromRef loads a file from a ref, the index ("index") or the working tree ("")
+func (app *App) GetFileContentFromRef(repoPath, filePath, ref string) (string, error) {
+	return git_operations.GetFileContentFromRef(app.ctx, repoPath, filePath, ref)
+}
+
+// GetBlame blames the working tree version of a file, relative to the repo's root
+func (app *App) GetBlame(repoPath, filePath string) ([]git_operations.BlameLine, error) {
+	return git_operations.GetBlame(app.ctx, repoPath, filePath)
+}
+
+// Background jobs
+
+// runJob runs the operation as a job and waits for it, so it shows up in ListJobs and can be cancelled
+func (app *App) runJob(options command_utils.JobOptions, run command_utils.JobFunc) error {
+	return app.jobManager.Start(options, run).Wait()
+}
+
+// ListJobs returns the running and queued jobs, and the most recently finished ones
+func (app *App) ListJobs() []command_utils.JobInfo {
+	return app.jobManager.ListJobs()
+}
+
+func (app *App) CancelJob(jobId string) error {
+	return app.jobManager.Cancel(jobId)
+}
+
+// Credential prompts
+
+func (app *App) AnswerCredentialPrompt(promptId, answer string, remember bool) error {
+	if app.credentialPrompts == nil {
+		return fmt.Errorf("the credential prompt server isn't running")
+	}
+	return app.credentialPrompts.Answer(promptId, answer, remember)
+}
+
+func (app *App) CancelCredentialPrompt(promptId string) error {
+	if app.credentialPrompts == nil {
+		return fmt.Errorf("the credential prompt server isn't running")
+	}
+	return app.credentialPrompts.Cancel(promptId)
+}
+
+// ClearCredentialCache forgets the answers that were remembered for this session
+func (app *App) ClearCredentialCache() {
+	if app.credentialPrompts != nil {
+		app.credentialPrompts.ClearCache()
+	}
+}
+
+// Bisect operations
+
+func (app *App) StartBisect(repoPath, badRef string, goodRefs []string) (*git_operations.BisectState, error) {
+	var state *git_operations.BisectState
+	err := app.runJob(command_utils.JobOptions{Title: "Start bisect", RepoPath: repoPath, WritesToRepo: true}, func(ctx context.Context) (err error) {
+		state, err = git_operations.StartBisect(ctx, repoPath, badRef, goodRefs)
+		return err
+	})
+	return state, err
+}
+
+// MarkBisectCommit marks a ref (or the current candidate when empty) as "good", "bad" or "skip"
+func (app *App) MarkBisectCommit(repoPath string, mark git_operations.BisectMark, ref string) (*git_operations.BisectState, error) {
+	var state *git_operations.BisectState
+	err := app.runJob(command_utils.JobOptions{Title: "Mark bisect commit", RepoPath: repoPath, WritesToRepo: true}, func(ctx context.Context) (err error) {
+		state, err = git_operations.MarkBisectCommit(ctx, repoPath, mark, ref)
+		return err
+	})
+	return state, err
+}
+
+func (app *App) GetBisectState(repoPath string) (*git_operations.BisectState, error) {
+	return git_operations.GetBisectState(app.ctx, repoPath)
+}
+
+func (app *App) ResetBisect(repoPath string) error {
+	return app.runJob(command_utils.JobOptions{Title: "Reset bisect", RepoPath: repoPath, WritesToRepo: true}, func(ctx context.Context) error {
+		return git_operations.ResetBisect(ctx, repoPath)
+	})
+}
+
+func (app *App) GetBisectLog(repoPath string) (string, error) {
+	return git_operations.GetBisectLog(app.ctx, repoPath)
+}
+
+// StartBisectRun starts `git bisect run` as a background job, which streams its output to the given topic
+func (app *App) StartBisectRun(repoPath, scriptPath string, scriptArgs []string, broadcastToTopic string) error {
+	if err := git_operations.ValidateBisectRun(app.ctx, repoPath, scriptPath); err != nil {
+		return err
+	}
+
+	app.jobManager.Start(command_utils.JobOptions{Title: "Bisect run", RepoPath: repoPath, WritesToRepo: true}, func(ctx context.Context) error {
+		return git_operations.RunBisectScript(ctx, repoPath, scriptPath, scriptArgs, broadcastToTopic)
+	})
+	return nil
+}
+
+// Patch operations
+
+// ExportPatches writes a commit range as mbox patch files or a single unified patch. When no output path is
+// given, the user picks a folder (mbox) or a file (unified) with a dialog. Returns nil if they cancel it
+func (app *App) ExportPatches(options git_operations.PatchExportOptions) (*git_operations.PatchExportResult, error) {
+	if options.OutputPath == "" {
+		outputPath, err := app.selectPatchExportPath(options)
+		if err != nil || outputPath == "" {
+			return nil, err
+		}
+		options.OutputPath = outputPath
+	}
+
+	return git_operations.ExportPatches(app.ctx, options)
+}
+
+func (app *App) selectPatchExportPath(options git_operations.PatchExportOptions) (string, error) {
+	if options.Format == git_operations.PatchFormatMbox {
+		dirPath, err := runtime.OpenDirectoryDialog(app.ctx, runtime.OpenDialogOptions{
+			Title:                "Select Patch Export Folder",
+			DefaultDirectory:     options.RepoPath,
+			CanCreateDirectories: true,
+		})
+		if err != nil {
+			return "", fmt.Errorf("failed to open folder dialog: %w", err)
+		}
+		return dirPath, nil
+	}
+
+	filePath, err := runtime.SaveFileDialog(app.ctx, runtime.SaveDialogOptions{
+		Title:           "Export Patch",
+		DefaultFilename: fmt.Sprintf("gitwhale-%s.patch", time.Now().Format("2006-01-02")),
+		Filters: []runtime.FileFilter{
+			{
+				DisplayName: "Patch Files",
+				Pattern:     "*.patch;*.diff",
+			},
+		},
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to open save dialog: %w", err)
+	}
+	return filePath, nil
+}
+
+// SelectPatchFileForImport opens a file dialog for selecting a patch or mbox file to apply
+func (app *App) SelectPatchFileForImport() (string, error) {
+	filePath, err := runtime.OpenFileDialog(app.ctx, runtime.OpenDialogOptions{
+		Title: "Select Patch File",
+		Filters: []runtime.FileFilter{
+			{
+				DisplayName: "Patch Files",
+				Pattern:     "*.patch;*.diff;*.mbox;*.eml",
+			},
+			{
+				DisplayName: "All Files",
+				Pattern:     "*",
+			},
+		},
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to open file dialog: %w", err)
+	}
+	return filePath, nil
+}
+
+// ApplyPatch applies a patch or mbox (or only checks it on a dry run), with a 3-way merge fallback
+func (app *App) ApplyPatch(options git_operations.PatchApplyOptions) (*git_operations.PatchApplyResult, error) {
+	return app.runPatchJob("Apply patch", options.RepoPath, func(ctx context.Context) (*git_operations.PatchApplyResult, error) {
+		return git_operations.ApplyPatch(ctx, options)
+	})
+}
+
+// ContinuePatchApply continues `git am` once the conflicts were resolved and staged
+func (app *App) ContinuePatchApply(repoPath string) (*git_operations.PatchApplyResult, error) {
+	return app.runPatchJob("Continue applying patches", repoPath, func(ctx context.Context) (*git_operations.PatchApplyResult, error) {
+		return git_operations.ContinuePatchApply(ctx, repoPath)
+	})
+}
+
+func (app *App) SkipPatch(repoPath string) (*git_operations.PatchApplyResult, error) {
+	return app.runPatchJob("Skip patch", repoPath, func(ctx context.Context) (*git_operations.PatchApplyResult, error) {
+		return git_operations.SkipPatch(ctx, repoPath)
+	})
+}
+
+func (app *App) AbortPatchApply(repoPath string) error {
+	return app.runJob(command_utils.JobOptions{Title: "Abort applying patches", RepoPath: repoPath, WritesToRepo: true}, func(ctx context.Context) error {
+		return git_operations.AbortPatchApply(ctx, repoPath)
+	})
+}
+
+func (app *App) runPatchJob(title, repoPath string, run func(ctx context.Context) (*git_operations.PatchApplyResult, error)) (*git_operations.PatchApplyResult, error) {
+	var result *git_operations.PatchApplyResult
+	err := app.runJob(command_utils.JobOptions{Title: title, RepoPath: repoPath, WritesToRepo: true}, func(ctx context.Context) (err error) {
+		result, err = run(ctx)
+		return err
+	})
+	return result, err
+}
+
 // UserScript CRUD operations
 
 func (app *App) SaveUserScriptCommand(command UserDefinedCommandDefinition) error {
@@ -496,30 +880,41 @@ func (app *App) ImportCustomUserScripts(filePath string, selectedUserScriptIds [
 
 // GetGitStatus retrieves the current Git status for a repository
 func (app *App) GetGitStatus(repoPath string) (*git_operations.GitStatus, error) {
-	return git_operations.GetGitStatus(repoPath)
+	return git_operations.GetGitStatus(app.ctx, repoPath)
 }
 
 // StageFile stages a specific file
 func (app *App) StageFile(repoPath string, filePath []string) error {
-	return git_operations.StageFile(repoPath, filePath)
+	return app.runJob(command_utils.JobOptions{Title: "Stage files", RepoPath: repoPath, WritesToRepo: true}, func(ctx context.Context) error {
+		return git_operations.StageFile(ctx, repoPath, filePath)
+	})
 }
 
 // UnstageFile un-stages a specific file
 func (app *App) UnstageFile(repoPath string, filePath []string) error {
-	return git_operations.UnstageFile(repoPath, filePath)
+	return app.runJob(command_utils.JobOptions{Title: "Unstage files", RepoPath: repoPath, WritesToRepo: true}, func(ctx context.Context) error {
+		return git_operations.UnstageFile(ctx, repoPath, filePath)
+	})
 }
 
 // CommitChanges commits the staged changes with the provided message
 func (app *App) CommitChanges(repoPath, message string) error {
-	return git_operations.CommitChanges(repoPath, message)
+	return app.runJob(command_utils.JobOptions{Title: "Commit", RepoPath: repoPath, WritesToRepo: true}, func(ctx context.Context) error {
+		return git_operations.CommitChanges(ctx, repoPath, message)
+	})
+}
+
+// CreateStagingDiffSession loads both versions of a staging area file for viewing its diff
+func (app *App) CreateStagingDiffSession(repoPath string, file git_operations.GitStatusFile, fileType git_operations.StagingFileType) (*git_operations.StagingDiffInfo, error) {
+	return git_operations.CreateStagingDiffSession(app.ctx, repoPath, file, fileType)
 }
 
-// CreateStagingDiffSession creates a staging diff session for viewing file diffs
-func (app *App) CreateStagingDiffSession(repoPath, filePath, fileType string) (*git_operations.StagingDiffInfo, error) {
-	return git_operations.CreateStagingDiffSession(repoPath, filePath, fileType)
+// GetBinaryDiffInfo describes the two versions of a binary file, with image previews or hex dumps
+func (app *App) GetBinaryDiffInfo(options git_operations.BinaryDiffOptions) (*git_operations.BinaryDiffInfo, error) {
+	return git_operations.GetBinaryDiffInfo(app.ctx, options)
 }
 
-// CleanupStagingDiffSession cleans up a staging diff session
-func (app *App) CleanupStagingDiffSession(sessionId string) error {
-	return git_operations.CleanupStagingDiffSession(sessionId)
+// GetCommitBinaryDiffInfo describes a binary file changed by a commit
+func (app *App) GetCommitBinaryDiffInfo(repoPath, commitHash string, fileChange git_operations.FileChange, hexDumpOffset int64, hexDumpLength int) (*git_operations.BinaryDiffInfo, error) {
+	return git_operations.GetCommitBinaryDiffInfo(app.ctx, repoPath, commitHash, fileChange, hexDumpOffset, hexDumpLength)
 }
@@ -0,0 +1,382 @@
+package backend
+
+import (
+	"bufio"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"gitwhale/backend/git_operations"
+	"gitwhale/backend/lib"
+	"gitwhale/backend/logger"
+	"net"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/google/uuid"
+	"github.com/wailsapp/wails/v2/pkg/runtime"
+)
+
+// The automation server asks the frontend to show what a request opened through these topics
+const (
+	AutomationOpenRepoTopic  = "onAutomationOpenRepo"
+	AutomationOpenDiffTopic  = "onAutomationOpenDiff"
+	AutomationFocusFileTopic = "onAutomationFocusFile"
+)
+
+// JSON-RPC 2.0 error codes. The -32000s are ours
+const (
+	rpcParseError       = -32700
+	rpcInvalidRequest   = -32600
+	rpcMethodNotFound   = -32601
+	rpcInvalidParams    = -32602
+	rpcInternalError    = -32603
+	rpcNotAuthenticated = -32001
+)
+
+type rpcRequest struct {
+	JsonRpc string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id,omitempty"` // Missing for notifications, which don't get a response
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params,omitempty"`
+}
+
+type rpcResponse struct {
+	JsonRpc string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Result  any             `json:"result,omitempty"`
+	Error   *rpcError       `json:"error,omitempty"`
+}
+
+type rpcError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+func (err *rpcError) Error() string {
+	return err.Message
+}
+
+// rpcHandler runs one method. Returning an *rpcError picks the error code, any other error is an internal error
+type rpcHandler func(params json.RawMessage) (any, error)
+
+// AutomationConnectionInfo is written to the automation file while the server is running, so editor plugins and
+// scripts can find the socket. Only the current user can read it
+type AutomationConnectionInfo struct {
+	SocketPath string `json:"socketPath"`
+	Token      string `json:"token"`
+	Pid        int    `json:"pid"`
+}
+
+// AutomationServer lets editor plugins and scripts drive GitWhale, with line-delimited JSON-RPC 2.0 over a Unix
+// domain socket. Windows supports Unix domain sockets too (since Windows 10 1803), so there's no named pipe
+// version. The first request of a connection has to be "authenticate", with the token from the automation file
+type AutomationServer struct {
+	app      *App
+	listener net.Listener
+	info     AutomationConnectionInfo
+	methods  map[string]rpcHandler
+
+	connections      map[net.Conn]struct{}
+	connectionsMutex sync.Mutex
+}
+
+func StartAutomationServer(app *App) (*AutomationServer, error) {
+	// Only the current user can open the folder, so nobody else can connect or put something in the socket's place
+	socketFolderPath, err := lib.GetSocketFolderPath()
+	if err != nil {
+		return nil, err
+	}
+	socketPath := filepath.Join(socketFolderPath, fmt.Sprintf("automation-%d.sock", os.Getpid()))
+	_ = os.Remove(socketPath) // Left over by a crashed process that had the same pid
+
+	listener, err := net.Listen("unix", socketPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to listen on %s: %w", socketPath, err)
+	}
+
+	server := &AutomationServer{
+		app:      app,
+		listener: listener,
+		info: AutomationConnectionInfo{
+			SocketPath: socketPath,
+			Token:      uuid.New().String(),
+			Pid:        os.Getpid(),
+		},
+		connections: make(map[net.Conn]struct{}),
+	}
+	server.methods = map[string]rpcHandler{
+		"ping":          server.ping,
+		"listOpenRepos": server.listOpenRepos,
+		"openRepo":      server.openRepo,
+		"openDiff":      server.openDiff,
+		"focusFile":     server.focusFile,
+		"listJobs":      server.listJobs,
+	}
+
+	if err := server.writeConnectionInfo(); err != nil {
+		listener.Close()
+		return nil, err
+	}
+
+	go server.acceptConnections()
+	logger.Log.Info("Automation server listening on %s", socketPath)
+	return server, nil
+}
+
+func (server *AutomationServer) writeConnectionInfo() error {
+	infoPath, err := lib.GetAutomationInfoFilePath()
+	if err != nil {
+		return fmt.Errorf("failed to get the automation file path: %w", err)
+	}
+
+	infoJson, err := json.MarshalIndent(server.info, "", "\t")
+	if err != nil {
+		return fmt.Errorf("failed to marshal the automation connection info: %w", err)
+	}
+
+	// Recreated, so the token is never readable by others, even if an old file had looser permissions
+	_ = os.Remove(infoPath)
+	if err := os.WriteFile(infoPath, infoJson, 0600); err != nil {
+		return fmt.Errorf("failed to write the automation file: %w", err)
+	}
+	return nil
+}
+
+// Stop closes the socket and every open connection
+func (server *AutomationServer) Stop() {
+	server.listener.Close()
+	_ = os.Remove(server.info.SocketPath)
+
+	if infoPath, err := lib.GetAutomationInfoFilePath(); err == nil {
+		_ = lib.DeleteFile(infoPath)
+	}
+
+	server.connectionsMutex.Lock()
+	defer server.connectionsMutex.Unlock()
+	for conn := range server.connections {
+		conn.Close()
+	}
+
+	logger.Log.Info("Automation server stopped")
+}
+
+func (server *AutomationServer) acceptConnections() {
+	for {
+		conn, err := server.listener.Accept()
+		if err != nil {
+			if !errors.Is(err, net.ErrClosed) {
+				logger.Log.Error("Automation server stopped accepting connections: %v", err)
+			}
+			return
+		}
+
+		server.connectionsMutex.Lock()
+		server.connections[conn] = struct{}{}
+		server.connectionsMutex.Unlock()
+
+		go server.handleConnection(conn)
+	}
+}
+
+// Serves one request per line, in order, until the client disconnects
+func (server *AutomationServer) handleConnection(conn net.Conn) {
+	defer func() {
+		server.connectionsMutex.Lock()
+		delete(server.connections, conn)
+		server.connectionsMutex.Unlock()
+		conn.Close()
+	}()
+
+	isAuthenticated := false
+	scanner := bufio.NewScanner(conn)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	encoder := json.NewEncoder(conn)
+
+	for scanner.Scan() {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+
+		var request rpcRequest
+		if err := json.Unmarshal(scanner.Bytes(), &request); err != nil {
+			encoder.Encode(rpcResponse{JsonRpc: "2.0", ID: json.RawMessage("null"), Error: &rpcError{Code: rpcParseError, Message: err.Error()}})
+			continue
+		}
+
+		result, err := server.handleRequest(request, &isAuthenticated)
+		if request.ID == nil {
+			continue
+		}
+
+		response := rpcResponse{JsonRpc: "2.0", ID: request.ID, Result: result}
+		if err != nil {
+			var rpcErr *rpcError
+			if !errors.As(err, &rpcErr) {
+				rpcErr = &rpcError{Code: rpcInternalError, Message: err.Error()}
+			}
+			response.Result = nil
+			response.Error = rpcErr
+		}
+
+		if err := encoder.Encode(response); err != nil {
+			logger.Log.Warning("Failed to send an automation response: %v", err)
+			return
+		}
+	}
+}
+
+func (server *AutomationServer) handleRequest(request rpcRequest, isAuthenticated *bool) (any, error) {
+	if request.JsonRpc != "2.0" || request.Method == "" {
+		return nil, &rpcError{Code: rpcInvalidRequest, Message: "expected a JSON-RPC 2.0 request"}
+	}
+
+	if request.Method == "authenticate" {
+		var params struct {
+			Token string `json:"token"`
+		}
+		if err := decodeRpcParams(request.Params, &params); err != nil {
+			return nil, err
+		}
+		if subtle.ConstantTimeCompare([]byte(params.Token), []byte(server.info.Token)) != 1 {
+			return nil, &rpcError{Code: rpcNotAuthenticated, Message: "wrong token"}
+		}
+
+		*isAuthenticated = true
+		return true, nil
+	}
+
+	if !*isAuthenticated {
+		return nil, &rpcError{Code: rpcNotAuthenticated, Message: "call authenticate first"}
+	}
+
+	handler, exists := server.methods[request.Method]
+	if !exists {
+		return nil, &rpcError{Code: rpcMethodNotFound, Message: fmt.Sprintf("unknown method: %s", request.Method)}
+	}
+
+	logger.Log.Info("Automation request: %s", request.Method)
+	return handler(request.Params)
+}
+
+func decodeRpcParams(params json.RawMessage, target any) error {
+	if len(params) == 0 {
+		return &rpcError{Code: rpcInvalidParams, Message: "missing params"}
+	}
+	if err := json.Unmarshal(params, target); err != nil {
+		return &rpcError{Code: rpcInvalidParams, Message: err.Error()}
+	}
+	return nil
+}
+
+// Methods
+
+func (server *AutomationServer) ping(params json.RawMessage) (any, error) {
+	return map[string]int{"pid": server.info.Pid}, nil
+}
+
+func (server *AutomationServer) listOpenRepos(params json.RawMessage) (any, error) {
+	return server.app.AppConfig.getOpenRepos(), nil
+}
+
+// Resolves and checks a repo path from a request. The frontend keys repos by their normalized path
+func (server *AutomationServer) resolveRepoPath(repoPath string) (string, error) {
+	absolutePath, err := filepath.Abs(repoPath)
+	if err != nil || repoPath == "" {
+		return "", &rpcError{Code: rpcInvalidParams, Message: fmt.Sprintf("invalid repo path: %q", repoPath)}
+	}
+	if !lib.DirExists(absolutePath) {
+		return "", &rpcError{Code: rpcInvalidParams, Message: fmt.Sprintf("the repo doesn't exist: %s", absolutePath)}
+	}
+	return server.app.NormalizeFolderPath(absolutePath), nil
+}
+
+// openRepo opens the repo in a tab. Params: {"repoPath"}
+func (server *AutomationServer) openRepo(params json.RawMessage) (any, error) {
+	var request struct {
+		RepoPath string `json:"repoPath"`
+	}
+	if err := decodeRpcParams(params, &request); err != nil {
+		return nil, err
+	}
+
+	repoPath, err := server.resolveRepoPath(request.RepoPath)
+	if err != nil {
+		return nil, err
+	}
+
+	server.app.openRepoFromOutside(repoPath)
+
+	return map[string]string{"repoPath": repoPath}, nil
+}
+
+// AutomationOpenDiffEvent asks the frontend to show a diff session that was started by the automation server
+type AutomationOpenDiffEvent struct {
+	RepoPath string                      `json:"repoPath"`
+	Options  git_operations.DiffOptions  `json:"options"`
+	Session  *git_operations.DiffSession `json:"session"`
+}
+
+// openDiff diffs a ref range and opens it in the repo's tab. Params are DiffOptions, e.g.
+// {"repoPath", "fromRef", "toRef", "pathspecs"}. An empty toRef diffs against the working tree
+func (server *AutomationServer) openDiff(params json.RawMessage) (any, error) {
+	var options git_operations.DiffOptions
+	if err := decodeRpcParams(params, &options); err != nil {
+		return nil, err
+	}
+	if options.FromRef == "" {
+		return nil, &rpcError{Code: rpcInvalidParams, Message: "fromRef is required"}
+	}
+
+	repoPath, err := server.resolveRepoPath(options.RepoPath)
+	if err != nil {
+		return nil, err
+	}
+	options.RepoPath = repoPath
+
+	session, err := server.app.StartDiffSession(options)
+	if err != nil {
+		return nil, err
+	}
+
+	if session.HasDiffData {
+		server.app.OpenRepoWithPath(repoPath)
+		runtime.EventsEmit(server.app.ctx, AutomationOpenDiffTopic, AutomationOpenDiffEvent{RepoPath: repoPath, Options: options, Session: session})
+		server.app.showWindow()
+	}
+
+	return map[string]any{"sessionId": session.SessionId, "hasDiffData": session.HasDiffData}, nil
+}
+
+// AutomationFocusFileEvent asks the frontend to open a file of a diff session
+type AutomationFocusFileEvent struct {
+	SessionId string `json:"sessionId"`
+	Path      string `json:"path"`
+}
+
+// focusFile opens a file of a diff session started with openDiff. Params: {"sessionId", "path"}, with the path
+// relative to the repo
+func (server *AutomationServer) focusFile(params json.RawMessage) (any, error) {
+	var request AutomationFocusFileEvent
+	if err := decodeRpcParams(params, &request); err != nil {
+		return nil, err
+	}
+	if request.Path == "" {
+		return nil, &rpcError{Code: rpcInvalidParams, Message: "path is required"}
+	}
+
+	if _, err := server.app.diffSessionManager.Get(request.SessionId); err != nil {
+		return nil, &rpcError{Code: rpcInvalidParams, Message: err.Error()}
+	}
+
+	request.Path = filepath.ToSlash(filepath.Clean(request.Path))
+	runtime.EventsEmit(server.app.ctx, AutomationFocusFileTopic, request)
+	server.app.showWindow()
+
+	return true, nil
+}
+
+func (server *AutomationServer) listJobs(params json.RawMessage) (any, error) {
+	return server.app.ListJobs(), nil
+}
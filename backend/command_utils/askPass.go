@@ -0,0 +1,366 @@
+package command_utils
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"gitwhale/backend/lib"
+	"gitwhale/backend/logger"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/wailsapp/wails/v2/pkg/runtime"
+)
+
+// AskPassFlag is the first argument of the GitWhale executable when the askpass script runs it
+const AskPassFlag = "--askpass"
+
+const (
+	// A prompt is published to CredentialPromptTopic, and its ID to CredentialPromptClosedTopic once it's answered,
+	// cancelled or abandoned by git
+	CredentialPromptTopic       = "onCredentialPrompt"
+	CredentialPromptClosedTopic = "onCredentialPromptClosed"
+
+	askPassSocketEnv = "GITWHALE_ASKPASS_SOCKET"
+	askPassTokenEnv  = "GITWHALE_ASKPASS_TOKEN"
+
+	// Prompts nobody answers are cancelled, so git fails instead of waiting until its own timeout
+	credentialPromptTimeout = 5 * time.Minute
+)
+
+type CredentialPromptKind string
+
+const (
+	CredentialUsername     CredentialPromptKind = "username"
+	CredentialPassword     CredentialPromptKind = "password"
+	CredentialPassphrase   CredentialPromptKind = "passphrase"   // For an SSH key
+	CredentialConfirmation CredentialPromptKind = "confirmation" // e.g. SSH asking whether to trust a new host key
+)
+
+// CredentialPrompt is sent to the frontend when git or ssh asks for something
+type CredentialPrompt struct {
+	ID     string               `json:"id"`
+	Prompt string               `json:"prompt"` // As printed by git, e.g. "Password for 'https://user@github.com': "
+	Kind   CredentialPromptKind `json:"kind"`
+	Secret bool                 `json:"secret"` // The answer shouldn't be shown while typing it
+}
+
+type askPassRequest struct {
+	Token  string `json:"token"`
+	Prompt string `json:"prompt"`
+}
+
+type askPassResponse struct {
+	Answer    string `json:"answer"`
+	Cancelled bool   `json:"cancelled"`
+}
+
+// Environment variables that make git and ssh ask GitWhale for credentials. Set by StartCredentialPromptServer
+var (
+	askPassEnv      []string
+	askPassEnvMutex sync.RWMutex
+)
+
+// commandEnvironment returns the environment for a child process: the current one, the askpass variables (only for
+// git, so other programs never see the socket and its token) and the extra ones. It returns nil (meaning the current
+// environment) when there is nothing to add
+func commandEnvironment(commandName string, extraEnv []string) []string {
+	askPassEnvMutex.RLock()
+	defer askPassEnvMutex.RUnlock()
+
+	var commandAskPassEnv []string
+	if commandName == "git" {
+		commandAskPassEnv = askPassEnv
+	}
+
+	if len(commandAskPassEnv) == 0 && len(extraEnv) == 0 {
+		return nil
+	}
+
+	env := append(os.Environ(), commandAskPassEnv...)
+	return append(env, extraEnv...)
+}
+
+// CredentialPromptServer answers the askpass script over a local socket, by asking the user through the frontend.
+// Answers can be remembered for the rest of the session
+type CredentialPromptServer struct {
+	ctx        context.Context
+	listener   net.Listener
+	socketPath string
+	token      string // Only processes started by GitWhale know it
+
+	mutex   sync.Mutex
+	pending map[string]*pendingCredentialPrompt
+	cache   map[string]string // Remembered answers, by prompt
+}
+
+type pendingCredentialPrompt struct {
+	prompt string
+	answer chan askPassResponse
+}
+
+// StartCredentialPromptServer installs the askpass script, listens for it and makes the commands started from now
+// on use it
+func StartCredentialPromptServer(ctx context.Context) (*CredentialPromptServer, error) {
+	scriptPath, err := installAskPassScript()
+	if err != nil {
+		return nil, err
+	}
+
+	// The folder keeps other users out, even in the moment between creating the socket and restricting it
+	socketFolderPath, err := lib.GetSocketFolderPath()
+	if err != nil {
+		return nil, err
+	}
+	socketPath := filepath.Join(socketFolderPath, fmt.Sprintf("askpass-%d.sock", os.Getpid()))
+	_ = os.Remove(socketPath) // Left over by a crashed process that had the same pid
+
+	listener, err := net.Listen("unix", socketPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to listen on %s: %w", socketPath, err)
+	}
+	if err := os.Chmod(socketPath, 0600); err != nil {
+		logger.Log.Warning("Failed to restrict the permissions of %s: %v", socketPath, err)
+	}
+
+	server := &CredentialPromptServer{
+		ctx:        ctx,
+		listener:   listener,
+		socketPath: socketPath,
+		token:      uuid.New().String(),
+		pending:    make(map[string]*pendingCredentialPrompt),
+		cache:      make(map[string]string),
+	}
+	go server.acceptConnections()
+
+	askPassEnvMutex.Lock()
+	askPassEnv = []string{
+		"GIT_ASKPASS=" + scriptPath,
+		"SSH_ASKPASS=" + scriptPath,
+		"SSH_ASKPASS_REQUIRE=force", // ssh only uses SSH_ASKPASS without a terminal and with a DISPLAY otherwise
+		"GIT_TERMINAL_PROMPT=0",     // Fail instead of hanging if git still wants a terminal
+		askPassSocketEnv + "=" + socketPath,
+		askPassTokenEnv + "=" + server.token,
+	}
+	askPassEnvMutex.Unlock()
+
+	logger.Log.Info("Listening for credential prompts on %s", socketPath)
+	return server, nil
+}
+
+// Writes a script that runs this executable with AskPassFlag. git runs askpass helpers through a shell,
+// including Git for Windows, so the same script works everywhere
+func installAskPassScript() (string, error) {
+	executablePath, err := os.Executable()
+	if err != nil {
+		return "", fmt.Errorf("failed to get the path of the GitWhale executable: %w", err)
+	}
+
+	scriptPath, err := lib.GetAskPassScriptPath()
+	if err != nil {
+		return "", fmt.Errorf("failed to get the askpass script path: %w", err)
+	}
+
+	quotedExecutablePath := "'" + strings.ReplaceAll(filepath.ToSlash(executablePath), "'", `'\''`) + "'"
+	script := fmt.Sprintf("#!/bin/sh\nexec %s %s \"$@\"\n", quotedExecutablePath, AskPassFlag)
+	if err := lib.WriteToFileAndReplaceOld(scriptPath, script); err != nil {
+		return "", fmt.Errorf("failed to write the askpass script: %w", err)
+	}
+	if err := os.Chmod(scriptPath, 0700); err != nil {
+		return "", fmt.Errorf("failed to make the askpass script executable: %w", err)
+	}
+
+	return scriptPath, nil
+}
+
+// Close stops listening and cancels the open prompts. Commands started afterwards won't use the askpass script
+func (server *CredentialPromptServer) Close() {
+	askPassEnvMutex.Lock()
+	askPassEnv = nil
+	askPassEnvMutex.Unlock()
+
+	server.listener.Close()
+	_ = os.Remove(server.socketPath)
+
+	server.mutex.Lock()
+	defer server.mutex.Unlock()
+	for _, pending := range server.pending {
+		pending.answer <- askPassResponse{Cancelled: true}
+	}
+	server.pending = make(map[string]*pendingCredentialPrompt)
+}
+
+// Answer replies to a prompt. With remember, the same prompt is answered automatically for the rest of the session
+func (server *CredentialPromptServer) Answer(promptID, answer string, remember bool) error {
+	return server.respond(promptID, askPassResponse{Answer: answer}, remember)
+}
+
+// Cancel makes the prompt fail, which makes git fail too
+func (server *CredentialPromptServer) Cancel(promptID string) error {
+	return server.respond(promptID, askPassResponse{Cancelled: true}, false)
+}
+
+func (server *CredentialPromptServer) respond(promptID string, response askPassResponse, remember bool) error {
+	server.mutex.Lock()
+	defer server.mutex.Unlock()
+
+	pending, exists := server.pending[promptID]
+	if !exists {
+		return fmt.Errorf("credential prompt %s doesn't exist or was already answered", promptID)
+	}
+	delete(server.pending, promptID)
+
+	if remember {
+		server.cache[pending.prompt] = response.Answer
+	}
+	pending.answer <- response
+	return nil
+}
+
+// ClearCache forgets the remembered answers, e.g. after a wrong password was remembered
+func (server *CredentialPromptServer) ClearCache() {
+	server.mutex.Lock()
+	defer server.mutex.Unlock()
+
+	server.cache = make(map[string]string)
+}
+
+func (server *CredentialPromptServer) acceptConnections() {
+	for {
+		conn, err := server.listener.Accept()
+		if err != nil {
+			if !errors.Is(err, net.ErrClosed) {
+				logger.Log.Error("Credential prompt server stopped accepting connections: %v", err)
+			}
+			return
+		}
+		go server.handleConnection(conn)
+	}
+}
+
+func (server *CredentialPromptServer) handleConnection(conn net.Conn) {
+	defer conn.Close()
+
+	var request askPassRequest
+	if err := json.NewDecoder(conn).Decode(&request); err != nil {
+		logger.Log.Error("Received a malformed askpass request: %v", err)
+		return
+	}
+	if subtle.ConstantTimeCompare([]byte(request.Token), []byte(server.token)) != 1 {
+		logger.Log.Warning("Rejected an askpass request with the wrong token")
+		return
+	}
+
+	response := server.prompt(conn, request.Prompt)
+	if err := json.NewEncoder(conn).Encode(response); err != nil {
+		logger.Log.Error("Failed to answer the askpass request: %v", err)
+	}
+}
+
+// Asks the frontend, and waits for the answer. The prompt is cancelled if the askpass process goes away first,
+// e.g. because its git command was cancelled
+func (server *CredentialPromptServer) prompt(conn net.Conn, promptText string) askPassResponse {
+	prompt := CredentialPrompt{
+		ID:     uuid.New().String(),
+		Prompt: promptText,
+		Kind:   classifyCredentialPrompt(promptText),
+	}
+	prompt.Secret = prompt.Kind == CredentialPassword || prompt.Kind == CredentialPassphrase
+
+	answer := make(chan askPassResponse, 1)
+
+	server.mutex.Lock()
+	if cachedAnswer, exists := server.cache[promptText]; exists {
+		server.mutex.Unlock()
+		logger.Log.Debug("Answering the credential prompt %q from the session cache", promptText)
+		return askPassResponse{Answer: cachedAnswer}
+	}
+	server.pending[prompt.ID] = &pendingCredentialPrompt{prompt: promptText, answer: answer}
+	server.mutex.Unlock()
+
+	logger.Log.Info("Asking for credentials: %q", promptText)
+	runtime.EventsEmit(server.ctx, CredentialPromptTopic, prompt)
+
+	// The askpass process doesn't send anything else, so reading only returns once it closes the connection
+	connClosed := make(chan struct{})
+	go func() {
+		_, _ = conn.Read(make([]byte, 1))
+		close(connClosed)
+	}()
+
+	var response askPassResponse
+	select {
+	case response = <-answer:
+	case <-connClosed:
+		logger.Log.Info("The credential prompt %q was abandoned", promptText)
+		response = askPassResponse{Cancelled: true}
+	case <-time.After(credentialPromptTimeout):
+		logger.Log.Warning("The credential prompt %q timed out", promptText)
+		response = askPassResponse{Cancelled: true}
+	}
+
+	server.mutex.Lock()
+	delete(server.pending, prompt.ID)
+	server.mutex.Unlock()
+
+	runtime.EventsEmit(server.ctx, CredentialPromptClosedTopic, prompt.ID)
+	return response
+}
+
+// Guesses what the prompt asks for from its text, since git and ssh only pass the text
+func classifyCredentialPrompt(promptText string) CredentialPromptKind {
+	lowerPrompt := strings.ToLower(promptText)
+
+	switch {
+	case strings.Contains(lowerPrompt, "username"):
+		return CredentialUsername
+	case strings.Contains(lowerPrompt, "passphrase"):
+		return CredentialPassphrase
+	case strings.Contains(lowerPrompt, "(yes/no"):
+		return CredentialConfirmation
+	default:
+		return CredentialPassword
+	}
+}
+
+// RunAskPassHelper is what the askpass script runs: it forwards the prompt to the running GitWhale and prints the
+// answer for git. It returns the process's exit code, which is non-zero when the prompt was cancelled
+func RunAskPassHelper(args []string) int {
+	socketPath := os.Getenv(askPassSocketEnv)
+	if socketPath == "" {
+		fmt.Fprintln(os.Stderr, "GitWhale askpass: not started by GitWhale")
+		return 1
+	}
+
+	conn, err := net.DialTimeout("unix", socketPath, 5*time.Second)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "GitWhale askpass: failed to connect to GitWhale: %v\n", err)
+		return 1
+	}
+	defer conn.Close()
+
+	request := askPassRequest{Token: os.Getenv(askPassTokenEnv), Prompt: strings.Join(args, " ")}
+	if err := json.NewEncoder(conn).Encode(request); err != nil {
+		fmt.Fprintf(os.Stderr, "GitWhale askpass: failed to send the prompt: %v\n", err)
+		return 1
+	}
+
+	var response askPassResponse
+	if err := json.NewDecoder(conn).Decode(&response); err != nil {
+		fmt.Fprintf(os.Stderr, "GitWhale askpass: no answer from GitWhale: %v\n", err)
+		return 1
+	}
+	if response.Cancelled {
+		return 1
+	}
+
+	fmt.Println(response.Answer)
+	return 0
+}
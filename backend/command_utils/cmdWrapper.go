@@ -1,65 +1,227 @@
 package command_utils
 
 import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
 	"gitwhale/backend/logger"
+	"io"
 	"os/exec"
 	"path/filepath"
 	"strings"
 	"time"
 )
 
-func RunCommandAndLogErr(command *exec.Cmd) (string, int, error) {
+// Commands that don't set their own timeout are stopped after this long
+const DefaultCommandTimeout = 2 * time.Minute
+
+var (
+	ErrGitNotFound    = errors.New("git executable not found")
+	ErrNotARepository = errors.New("not a git repository")
+	ErrRefNotFound    = errors.New("ref not found")
+	ErrTimeout        = errors.New("command timed out")
+	ErrCancelled      = errors.New("command was cancelled")
+	ErrCommandFailed  = errors.New("command failed")
+)
+
+// Runner runs a command to completion. The app uses ExecRunner, and tests can swap DefaultRunner for a fake
+type Runner interface {
+	Run(ctx context.Context, command Command) (*CommandResult, error)
+}
+
+type Command struct {
+	Name  string // The executable to run. Defaults to git
+	Args  []string
+	Dir   string
+	Env   []string // Added on top of the current process's environment
+	Stdin io.Reader
+
+	// Defaults to DefaultCommandTimeout. Negative values disable the timeout
+	Timeout time.Duration
+
+	// When set, git's --progress lines are passed here as they come, instead of ending up in Stderr
+	Progress func(ProgressEvent)
+}
+
+// CommandResult is returned even when the command fails, so callers can check the exit code and output
+type CommandResult struct {
+	Stdout   string
+	Stderr   string
+	ExitCode int // -1 when the command couldn't be started or was killed
+	Duration time.Duration
+}
+
+// CommandError describes a failed command. Kind is one of the Err* values, so it can be checked with errors.Is
+type CommandError struct {
+	Kind     error
+	Args     []string
+	ExitCode int
+	Stderr   string
+	Cause    error
+}
+
+func (err *CommandError) Error() string {
+	message := fmt.Sprintf("%v: %s", err.Kind, strings.Join(err.Args, " "))
+	if err.ExitCode > 0 {
+		message += fmt.Sprintf(" (exit code %d)", err.ExitCode)
+	}
+	if stderr := strings.TrimSpace(err.Stderr); stderr != "" {
+		message += ": " + stderr
+	} else if err.Cause != nil {
+		message += ": " + err.Cause.Error()
+	}
+	return message
+}
+
+func (err *CommandError) Unwrap() error {
+	return err.Kind
+}
+
+// The runner used by RunGit and RunCommand
+var DefaultRunner Runner = &ExecRunner{}
+
+// RunGit runs a git command in the given directory with DefaultRunner
+func RunGit(ctx context.Context, dir string, args ...string) (*CommandResult, error) {
+	return DefaultRunner.Run(ctx, Command{Args: args, Dir: dir})
+}
+
+// RunCommand runs any command with DefaultRunner
+func RunCommand(ctx context.Context, command Command) (*CommandResult, error) {
+	return DefaultRunner.Run(ctx, command)
+}
+
+// ExecRunner runs commands as child processes, and records them in the command log
+type ExecRunner struct{}
+
+func (runner *ExecRunner) Run(ctx context.Context, command Command) (*CommandResult, error) {
+	if command.Name == "" {
+		command.Name = "git"
+	}
+
+	timeout := command.Timeout
+	if timeout == 0 {
+		timeout = DefaultCommandTimeout
+	}
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	env := command.Env
+	if command.Name == "git" {
+		// Otherwise reading the status refreshes the index, which the repo watcher then reports as a change
+		env = append([]string{"GIT_OPTIONAL_LOCKS=0"}, env...)
+	}
+
+	cmd := exec.CommandContext(ctx, command.Name, command.Args...)
+	cmd.Dir = command.Dir
+	cmd.Stdin = command.Stdin
+	cmd.Env = commandEnvironment(command.Name, env)
+
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	var stderrProgress *progressWriter
+	if command.Progress != nil {
+		stderrProgress = &progressWriter{onProgress: command.Progress, output: &stderr}
+		cmd.Stderr = stderrProgress
+	}
+
 	// Log the command being executed with full details
-	logger.Log.Debug("Executing git command: %s", strings.Join(command.Args, " "))
+	logger.Log.Debug("Executing git command: %s", strings.Join(cmd.Args, " "))
 	logger.Log.Trace("\t- Command working directory: %s", command.Dir)
 	logger.Log.Trace("\t- Command environment variables: %v", command.Env)
 
-	// Record start time and log to command buffer
-	startTime := time.Now()
-
 	// Get working directory (fallback to current dir if not set)
 	workingDir := command.Dir
 	if workingDir == "" {
 		workingDir, _ = filepath.Abs(".")
 	}
+	commandID := LogCommandStart(cmd.Args, workingDir)
 
-	// Log command start to buffer
-	commandID := LogCommandStart(command.Args, workingDir)
-
-	HideWindowsConsole(command)
-	result, err := command.CombinedOutput()
+	startTime := time.Now()
+	HideWindowsConsole(cmd)
+	runErr := cmd.Run()
+	if stderrProgress != nil {
+		stderrProgress.Flush()
+	}
 
-	// Log execution time
-	duration := time.Since(startTime)
-	logger.Log.Trace("\t- Command execution time: %v", duration)
+	result := &CommandResult{
+		Stdout:   stdout.String(),
+		Stderr:   stderr.String(),
+		ExitCode: 0,
+		Duration: time.Since(startTime),
+	}
+	logger.Log.Trace("\t- Command execution time: %v", result.Duration)
 
-	// Determine exit code
-	exitCode := 0
+	err := classifyCommandError(ctx, cmd.Args, runErr, result)
 	if err != nil {
-		if exitError, ok := err.(*exec.ExitError); ok {
-			exitCode = exitError.ExitCode()
-			logger.Log.Error("\t- Git command failed with exit code %d: %s", exitCode, strings.Join(command.Args, " "))
-			logger.Log.Error("\t- Command stderr: %s", string(exitError.Stderr))
-		} else {
-			exitCode = 420 // Generic error code
-			logger.Log.Error("\t- Error running git command: [%v] -> %v", command.Args, err)
-		}
-		logger.Log.Debug("\t- Failed command output: %s", string(result))
+		logger.Log.Error("\t- Command failed: %v", err)
+		logger.Log.Debug("\t- Failed command output: %s", result.Stdout)
 	} else {
-		logger.Log.Debug("\t- Git command completed successfully: %s", strings.Join(command.Args, " "))
-		logger.Log.Debug("\t- Command output length: %d bytes", len(result))
-		if len(result) > 0 && len(result) < 1000 {
-			logger.Log.Trace("\t- Command output: %s", strings.TrimSpace(string(result)))
+		logger.Log.Debug("\t- Git command completed successfully: %s", strings.Join(cmd.Args, " "))
+		logger.Log.Debug("\t- Command output length: %d bytes", len(result.Stdout))
+		if len(result.Stdout) > 0 && len(result.Stdout) < 1000 {
+			logger.Log.Trace("\t- Command output: %s", strings.TrimSpace(result.Stdout))
 		}
 	}
 
-	// Log command completion to buffer
-	output := string(result)
-	errorOutput := ""
-	if err != nil {
+	errorOutput := result.Stderr
+	if err != nil && errorOutput == "" {
 		errorOutput = err.Error()
 	}
-	LogCommandEnd(commandID, output, errorOutput, exitCode)
+	LogCommandEnd(commandID, result.Stdout, errorOutput, result.ExitCode)
+
+	return result, err
+}
+
+// Turns the error from exec into a CommandError, and sets the exit code on the result
+func classifyCommandError(ctx context.Context, args []string, runErr error, result *CommandResult) error {
+	if runErr == nil {
+		return nil
+	}
+
+	commandErr := &CommandError{Args: args, ExitCode: -1, Stderr: result.Stderr, Cause: runErr}
 
-	return output, exitCode, err
+	var exitErr *exec.ExitError
+	switch {
+	case errors.Is(ctx.Err(), context.DeadlineExceeded):
+		commandErr.Kind = ErrTimeout
+	case errors.Is(ctx.Err(), context.Canceled):
+		commandErr.Kind = ErrCancelled
+	case errors.Is(runErr, exec.ErrNotFound):
+		commandErr.Kind = ErrGitNotFound
+	case errors.As(runErr, &exitErr):
+		commandErr.ExitCode = exitErr.ExitCode()
+		commandErr.Kind = classifyGitStderr(result.Stderr)
+	default:
+		commandErr.Kind = ErrCommandFailed
+	}
+
+	result.ExitCode = commandErr.ExitCode
+	return commandErr
+}
+
+// git doesn't have distinct exit codes for most failures, so the kind comes from its error message
+func classifyGitStderr(stderr string) error {
+	stderr = strings.ToLower(stderr)
+
+	switch {
+	case strings.Contains(stderr, "not a git repository"):
+		return ErrNotARepository
+	case strings.Contains(stderr, "unknown revision"),
+		strings.Contains(stderr, "bad revision"),
+		strings.Contains(stderr, "bad object"),
+		strings.Contains(stderr, "invalid object name"),
+		strings.Contains(stderr, "needed a single revision"),
+		strings.Contains(stderr, "not a valid object name"),
+		strings.Contains(stderr, "does not exist in"),
+		strings.Contains(stderr, "exists on disk, but not in"):
+		return ErrRefNotFound
+	default:
+		return ErrCommandFailed
+	}
 }
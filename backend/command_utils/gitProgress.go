@@ -0,0 +1,150 @@
+package command_utils
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/wailsapp/wails/v2/pkg/runtime"
+)
+
+// ProgressEvent is one progress update printed by git with --progress, e.g.
+// "Receiving objects:  42% (42/100), 1.20 MiB | 2.40 MiB/s"
+type ProgressEvent struct {
+	Phase       string `json:"phase"`                 // e.g. "Receiving objects"
+	Remote      bool   `json:"remote"`                // Reported by the server, with a "remote: " prefix
+	Percent     int    `json:"percent"`               // -1 for phases that only count, like "Enumerating objects"
+	Current     int64  `json:"current"`               // Objects, deltas or files processed so far
+	Total       int64  `json:"total"`                 // 0 when the phase doesn't know its total
+	Transferred string `json:"transferred,omitempty"` // e.g. "1.20 MiB", only while transferring objects
+	Throughput  string `json:"throughput,omitempty"`  // e.g. "2.40 MiB/s"
+	Done        bool   `json:"done"`
+}
+
+// Matches "<phase>: <percent>% (<current>/<total>)" or "<phase>: <count>", optionally followed by
+// ", <size> | <speed>" and ", done."
+var gitProgressRegex = regexp.MustCompile(
+	`^(remote: )?([A-Z][A-Za-z ]*?):\s+(?:(\d+)%\s+\((\d+)/(\d+)\)|(\d+))` +
+		`(?:,\s+([\d.]+ (?:bytes|[KMGT]iB))\s+\|\s+([\d.]+ (?:bytes|[KMGT]iB)/s))?` +
+		`(,\s+done)?\.?$`)
+
+// ParseGitProgressLine parses one line of git's --progress output. The bool is false for anything that isn't a
+// progress update, like "remote: Total 12 (delta 3)" or error messages
+func ParseGitProgressLine(line string) (ProgressEvent, bool) {
+	match := gitProgressRegex.FindStringSubmatch(strings.TrimSpace(line))
+	if match == nil {
+		return ProgressEvent{}, false
+	}
+
+	event := ProgressEvent{
+		Phase:       match[2],
+		Remote:      match[1] != "",
+		Percent:     -1,
+		Transferred: match[7],
+		Throughput:  match[8],
+		Done:        match[9] != "",
+	}
+
+	if match[3] != "" {
+		event.Percent, _ = strconv.Atoi(match[3])
+		event.Current, _ = strconv.ParseInt(match[4], 10, 64)
+		event.Total, _ = strconv.ParseInt(match[5], 10, 64)
+	} else {
+		event.Current, _ = strconv.ParseInt(match[6], 10, 64)
+	}
+
+	return event, true
+}
+
+// Message describes the event in a single line, e.g. for a job's progress
+func (event ProgressEvent) Message() string {
+	var message string
+	if event.Total > 0 {
+		message = fmt.Sprintf("%s (%d/%d)", event.Phase, event.Current, event.Total)
+	} else {
+		message = fmt.Sprintf("%s (%d)", event.Phase, event.Current)
+	}
+
+	if event.Transferred != "" {
+		message += fmt.Sprintf(", %s | %s", event.Transferred, event.Throughput)
+	}
+	return message
+}
+
+// JobProgressTopic is the topic that a job's ProgressEvents are published to
+func JobProgressTopic(jobID string) string {
+	return "onJobProgress://" + jobID
+}
+
+// ReportGitProgress updates the progress of the job that ctx belongs to, and publishes the event on the job's
+// progress topic. It does nothing outside of a job
+func ReportGitProgress(ctx context.Context, event ProgressEvent) {
+	job, ok := ctx.Value(jobContextKey{}).(*Job)
+	if !ok {
+		return
+	}
+
+	ReportJobProgress(ctx, event.Message(), event.Percent)
+	if job.manager.ctx != nil {
+		runtime.EventsEmit(job.manager.ctx, JobProgressTopic(job.ID()), event)
+	}
+}
+
+// scanLinesAndCarriageReturns is a bufio.SplitFunc like bufio.ScanLines, which also ends a line at "\r".
+// git rewrites its progress line in place with "\r", so ScanLines would only see the final update.
+// "\r\n" produces an extra empty line
+func scanLinesAndCarriageReturns(data []byte, atEOF bool) (advance int, token []byte, err error) {
+	if atEOF && len(data) == 0 {
+		return 0, nil, nil
+	}
+	if i := bytes.IndexAny(data, "\r\n"); i >= 0 {
+		return i + 1, data[:i], nil
+	}
+	if atEOF {
+		return len(data), data, nil
+	}
+	return 0, nil, nil
+}
+
+// progressWriter is used as a command's stderr. It hands the progress updates to onProgress as they are
+// written, and keeps everything else (warnings and errors) in output
+type progressWriter struct {
+	onProgress func(ProgressEvent)
+	output     *bytes.Buffer
+	pending    []byte
+}
+
+func (writer *progressWriter) Write(data []byte) (int, error) {
+	writer.pending = append(writer.pending, data...)
+	for {
+		advance, line, _ := scanLinesAndCarriageReturns(writer.pending, false)
+		if advance == 0 {
+			break
+		}
+		writer.writeLine(string(line))
+		writer.pending = writer.pending[advance:]
+	}
+	return len(data), nil
+}
+
+// Flush handles the last line, when the command didn't end it with a newline
+func (writer *progressWriter) Flush() {
+	if len(writer.pending) > 0 {
+		writer.writeLine(string(writer.pending))
+		writer.pending = nil
+	}
+}
+
+func (writer *progressWriter) writeLine(line string) {
+	if line == "" {
+		return
+	}
+	if event, ok := ParseGitProgressLine(line); ok {
+		writer.onProgress(event)
+		return
+	}
+	writer.output.WriteString(line + "\n")
+}
@@ -0,0 +1,316 @@
+package command_utils
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"gitwhale/backend/logger"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/wailsapp/wails/v2/pkg/runtime"
+)
+
+// Every change to a job is published to this topic, with the job's JobInfo
+const JobsTopic = "onJobChanged"
+
+const (
+	// How many finished jobs are kept around for ListJobs
+	maxFinishedJobs = 50
+
+	// Progress events are throttled, since some jobs report progress for every file
+	jobProgressEventInterval = 100 * time.Millisecond
+)
+
+type JobState string
+
+const (
+	JobQueued    JobState = "queued" // Waiting for another job that writes to the same repo
+	JobRunning   JobState = "running"
+	JobCompleted JobState = "completed"
+	JobFailed    JobState = "failed"
+	JobCancelled JobState = "cancelled"
+)
+
+type JobProgress struct {
+	Message string `json:"message"`
+	Percent int    `json:"percent"` // -1 when the job can't tell how far along it is
+}
+
+type JobOptions struct {
+	Title    string `json:"title"`
+	RepoPath string `json:"repoPath"`
+
+	// Jobs that write to the repo wait for the previous one in the same repo to finish, so they never race on the
+	// index lock. Read-only jobs start right away
+	WritesToRepo bool `json:"writesToRepo"`
+}
+
+// JobInfo is a snapshot of a job, as sent to the frontend
+type JobInfo struct {
+	ID           string      `json:"id"`
+	Title        string      `json:"title"`
+	RepoPath     string      `json:"repoPath"`
+	WritesToRepo bool        `json:"writesToRepo"`
+	State        JobState    `json:"state"`
+	Progress     JobProgress `json:"progress"`
+	Error        string      `json:"error,omitempty"`
+	CreatedAt    time.Time   `json:"createdAt"`
+	StartedAt    *time.Time  `json:"startedAt,omitempty"`
+	FinishedAt   *time.Time  `json:"finishedAt,omitempty"`
+}
+
+// JobFunc does the work of a job. It should stop once ctx is cancelled, and can report its progress with ReportJobProgress
+type JobFunc func(ctx context.Context) error
+
+type Job struct {
+	manager *JobManager
+	cancel  context.CancelFunc
+	done    chan struct{}
+	err     error
+
+	// Guarded by the manager's mutex
+	info              JobInfo
+	lastProgressEvent time.Time
+}
+
+// Wait blocks until the job finishes, and returns its error
+func (job *Job) Wait() error {
+	<-job.done
+	return job.err
+}
+
+func (job *Job) ID() string {
+	return job.info.ID
+}
+
+// JobManager runs long git operations in the background, so they can be listed, followed and cancelled
+type JobManager struct {
+	ctx   context.Context // The Wails context, which jobs inherit. Without one, job events aren't published
+	mutex sync.Mutex
+	jobs  map[string]*Job
+
+	// One slot per repo, held by the job that is currently writing to it
+	repoLocks map[string]chan struct{}
+}
+
+func NewJobManager(ctx context.Context) *JobManager {
+	return &JobManager{
+		ctx:       ctx,
+		jobs:      make(map[string]*Job),
+		repoLocks: make(map[string]chan struct{}),
+	}
+}
+
+type jobContextKey struct{}
+
+// ReportJobProgress updates the progress of the job that ctx belongs to. It does nothing outside of a job
+func ReportJobProgress(ctx context.Context, message string, percent int) {
+	job, ok := ctx.Value(jobContextKey{}).(*Job)
+	if !ok {
+		return
+	}
+
+	job.manager.updateJob(job, false, func(info *JobInfo) {
+		info.Progress = JobProgress{Message: message, Percent: percent}
+	})
+}
+
+// Start runs the job in the background, and returns right away
+func (manager *JobManager) Start(options JobOptions, run JobFunc) *Job {
+	parentCtx := manager.ctx
+	if parentCtx == nil {
+		parentCtx = context.Background()
+	}
+	ctx, cancel := context.WithCancel(parentCtx)
+
+	if options.RepoPath != "" {
+		options.RepoPath = filepath.Clean(options.RepoPath)
+	}
+
+	job := &Job{
+		manager: manager,
+		cancel:  cancel,
+		done:    make(chan struct{}),
+		info: JobInfo{
+			ID:           uuid.New().String(),
+			Title:        options.Title,
+			RepoPath:     options.RepoPath,
+			WritesToRepo: options.WritesToRepo,
+			State:        JobQueued,
+			Progress:     JobProgress{Percent: -1},
+			CreatedAt:    time.Now(),
+		},
+	}
+	ctx = context.WithValue(ctx, jobContextKey{}, job)
+
+	manager.mutex.Lock()
+	manager.jobs[job.info.ID] = job
+	manager.mutex.Unlock()
+
+	logger.Log.Info("Starting job %s (%s) in %s", job.info.ID, job.info.Title, job.info.RepoPath)
+	manager.emitJobChanged(job)
+
+	go manager.runJob(ctx, job, options, run)
+	return job
+}
+
+func (manager *JobManager) runJob(ctx context.Context, job *Job, options JobOptions, run JobFunc) {
+	defer close(job.done)
+	defer job.cancel()
+
+	var err error
+	if options.WritesToRepo && options.RepoPath != "" {
+		repoLock := manager.getRepoLock(options.RepoPath)
+		select {
+		case repoLock <- struct{}{}:
+			defer func() { <-repoLock }()
+		case <-ctx.Done():
+			err = fmt.Errorf("%w before it started", ErrCancelled)
+		}
+	}
+
+	if err == nil {
+		manager.updateJob(job, true, func(info *JobInfo) {
+			now := time.Now()
+			info.State = JobRunning
+			info.StartedAt = &now
+		})
+		err = run(ctx)
+	}
+
+	job.err = err
+	manager.updateJob(job, true, func(info *JobInfo) {
+		now := time.Now()
+		info.FinishedAt = &now
+
+		switch {
+		case errors.Is(err, ErrCancelled) || (err != nil && ctx.Err() != nil):
+			info.State = JobCancelled
+		case err != nil:
+			info.State = JobFailed
+			info.Error = err.Error()
+		default:
+			info.State = JobCompleted
+		}
+	})
+
+	if err != nil {
+		logger.Log.Warning("Job %s (%s) ended with: %v", job.info.ID, job.info.Title, err)
+	} else {
+		logger.Log.Info("Job %s (%s) completed", job.info.ID, job.info.Title)
+	}
+
+	manager.pruneFinishedJobs()
+}
+
+func (manager *JobManager) getRepoLock(repoPath string) chan struct{} {
+	manager.mutex.Lock()
+	defer manager.mutex.Unlock()
+
+	repoLock, exists := manager.repoLocks[repoPath]
+	if !exists {
+		repoLock = make(chan struct{}, 1)
+		manager.repoLocks[repoPath] = repoLock
+	}
+	return repoLock
+}
+
+// Applies the update to the job and publishes it. Progress updates (force is false) are throttled
+func (manager *JobManager) updateJob(job *Job, force bool, update func(info *JobInfo)) {
+	manager.mutex.Lock()
+	update(&job.info)
+	shouldEmit := force || time.Since(job.lastProgressEvent) >= jobProgressEventInterval
+	if shouldEmit {
+		job.lastProgressEvent = time.Now()
+	}
+	manager.mutex.Unlock()
+
+	if shouldEmit {
+		manager.emitJobChanged(job)
+	}
+}
+
+func (manager *JobManager) emitJobChanged(job *Job) {
+	if manager.ctx == nil {
+		return
+	}
+
+	manager.mutex.Lock()
+	info := job.info
+	manager.mutex.Unlock()
+
+	runtime.EventsEmit(manager.ctx, JobsTopic, info)
+}
+
+// Cancel stops a queued or running job
+func (manager *JobManager) Cancel(jobID string) error {
+	manager.mutex.Lock()
+	job, exists := manager.jobs[jobID]
+	var title string
+	if exists {
+		title = job.info.Title
+	}
+	manager.mutex.Unlock()
+
+	if !exists {
+		return fmt.Errorf("job %s doesn't exist", jobID)
+	}
+
+	logger.Log.Info("Cancelling job %s (%s)", jobID, title)
+	job.cancel()
+	return nil
+}
+
+// CancelAll stops every job, e.g. when the app is closing
+func (manager *JobManager) CancelAll() {
+	manager.mutex.Lock()
+	defer manager.mutex.Unlock()
+
+	for _, job := range manager.jobs {
+		job.cancel()
+	}
+}
+
+// ListJobs returns every running and queued job, and the most recently finished ones, oldest first
+func (manager *JobManager) ListJobs() []JobInfo {
+	manager.mutex.Lock()
+	defer manager.mutex.Unlock()
+
+	jobs := make([]JobInfo, 0, len(manager.jobs))
+	for _, job := range manager.jobs {
+		jobs = append(jobs, job.info)
+	}
+
+	sort.Slice(jobs, func(i, j int) bool {
+		return jobs[i].CreatedAt.Before(jobs[j].CreatedAt)
+	})
+	return jobs
+}
+
+// Drops the oldest finished jobs once there are more than maxFinishedJobs
+func (manager *JobManager) pruneFinishedJobs() {
+	manager.mutex.Lock()
+	defer manager.mutex.Unlock()
+
+	finishedJobs := []*Job{}
+	for _, job := range manager.jobs {
+		if job.info.FinishedAt != nil {
+			finishedJobs = append(finishedJobs, job)
+		}
+	}
+
+	if len(finishedJobs) <= maxFinishedJobs {
+		return
+	}
+
+	sort.Slice(finishedJobs, func(i, j int) bool {
+		return finishedJobs[i].info.FinishedAt.Before(*finishedJobs[j].info.FinishedAt)
+	})
+	for _, job := range finishedJobs[:len(finishedJobs)-maxFinishedJobs] {
+		delete(manager.jobs, job.info.ID)
+	}
+}
@@ -32,9 +32,22 @@ type StreamedCommandEvent struct {
 	Duration  string                `json:"duration,omitempty"`
 	ExitCode  int                   `json:"exitCode,omitempty"`
 	Error     string                `json:"error,omitempty"`
+	Data      any                   `json:"data,omitempty"`
 	Timestamp time.Time             `json:"timestamp"`
 }
 
+// StreamedOutputParser turns the stdout of a streamed command into the events sent to the frontend
+type StreamedOutputParser interface {
+	// ParseLine receives every stdout line and returns the event to stream (or nil to skip the line)
+	ParseLine(line string) *StreamedCommandEvent
+
+	// Flush is called once the command exits, and returns an event for anything that is still buffered (or nil)
+	Flush() *StreamedCommandEvent
+
+	// IsSuccessExitCode reports whether the exit code should be treated as a successful run
+	IsSuccessExitCode(exitCode int) bool
+}
+
 // activeCommands tracks running commands for cancellation
 var activeCommands = make(map[string]*exec.Cmd)
 var activeCommandsMutex sync.RWMutex
@@ -48,14 +61,20 @@ func StartRunningAndStreamCommand(ctx context.Context, shellPath []string, comma
 	logger.Log.Debug("StartRunningAndStreamCommand called - command: %s, topic: %s", commandString, broadcastToTopic)
 
 	go func() {
-		err := streamOutput(ctx, shellPath, commandString, workingDir, broadcastToTopic)
+		if len(shellPath) < 1 {
+			emitStreamingError(ctx, broadcastToTopic, fmt.Errorf("misconfigured shell path for running commands"))
+			return
+		}
+
+		if len(commandString) == 0 {
+			emitStreamingError(ctx, broadcastToTopic, fmt.Errorf("empty command string"))
+			return
+		}
+
+		allCommand := append(shellPath, commandString)
+		_, err := streamOutput(ctx, allCommand, workingDir, broadcastToTopic, nil, false)
 		if err != nil {
-			emitEvent(ctx, broadcastToTopic, StreamedCommandEvent{
-				State:     StateError,
-				Error:     err.Error(),
-				Timestamp: time.Now(),
-			})
-			logger.Log.Error("Command streaming failed: %v", err)
+			emitStreamingError(ctx, broadcastToTopic, err)
 		}
 	}()
 
@@ -63,6 +82,59 @@ func StartRunningAndStreamCommand(ctx context.Context, shellPath []string, comma
 	go listenForCancellation(ctx, broadcastToTopic)
 }
 
+// StartRunningAndStreamParsedCommand asynchronously executes a command (without going through a shell) and
+// streams the events produced by the parser for its stdout. Cancellation works like StartRunningAndStreamCommand.
+// parseGitProgress is for git commands started with --progress, and streams their stderr as ProgressEvents
+func StartRunningAndStreamParsedCommand(ctx context.Context, commandArgs []string, workingDir, broadcastToTopic string, parser StreamedOutputParser, parseGitProgress bool) {
+	logger.Log.Debug("StartRunningAndStreamParsedCommand called - command: %v, topic: %s", commandArgs, broadcastToTopic)
+
+	go func() {
+		if len(commandArgs) < 1 {
+			emitStreamingError(ctx, broadcastToTopic, fmt.Errorf("empty command"))
+			return
+		}
+
+		_, err := streamOutput(ctx, commandArgs, workingDir, broadcastToTopic, parser, parseGitProgress)
+		if err != nil {
+			emitStreamingError(ctx, broadcastToTopic, err)
+		}
+	}()
+
+	// Set up cancellation listener
+	go listenForCancellation(ctx, broadcastToTopic)
+}
+
+// RunAndStreamParsedCommand is the blocking version of StartRunningAndStreamParsedCommand, for background jobs.
+// Cancelling ctx stops the command the same way a cancel event from the frontend does
+func RunAndStreamParsedCommand(ctx context.Context, commandArgs []string, workingDir, broadcastToTopic string, parser StreamedOutputParser, parseGitProgress bool) error {
+	logger.Log.Debug("RunAndStreamParsedCommand called - command: %v, topic: %s", commandArgs, broadcastToTopic)
+
+	if len(commandArgs) < 1 {
+		err := fmt.Errorf("empty command")
+		emitStreamingError(ctx, broadcastToTopic, err)
+		return err
+	}
+
+	go listenForCancellation(ctx, broadcastToTopic)
+
+	wait, err := streamOutput(ctx, commandArgs, workingDir, broadcastToTopic, parser, parseGitProgress)
+	if err != nil {
+		emitStreamingError(ctx, broadcastToTopic, err)
+		return err
+	}
+	return wait()
+}
+
+// emitStreamingError reports a command that could not be started
+func emitStreamingError(ctx context.Context, broadcastToTopic string, err error) {
+	emitEvent(ctx, broadcastToTopic, StreamedCommandEvent{
+		State:     StateError,
+		Error:     err.Error(),
+		Timestamp: time.Now(),
+	})
+	logger.Log.Error("Command streaming failed: %v", err)
+}
+
 // listenForCancellation listens for cancellation events from the frontend
 func listenForCancellation(ctx context.Context, broadcastToTopic string) {
 	runtime.EventsOn(ctx, broadcastToTopic, func(optionalData ...interface{}) {
@@ -99,21 +171,16 @@ func cancelCommand(broadcastToTopic string) {
 	}
 }
 
-// streamOutput executes the command and streams output in real-time
-func streamOutput(ctx context.Context, shellPath []string, commandString, workingDir, broadcastToTopic string) error {
-	if len(shellPath) < 1 {
-		return fmt.Errorf("misconfigured shell path for running commands")
-	}
-
-	if len(commandString) == 0 {
-		return fmt.Errorf("empty command string")
-	}
-
+// streamOutput starts the command and streams output in real-time. When a parser is provided,
+// stdout is streamed as the parser's events instead of raw lines. The returned function waits for the command to end
+func streamOutput(ctx context.Context, allCommand []string, workingDir, broadcastToTopic string, parser StreamedOutputParser, parseGitProgress bool) (func() error, error) {
 	// Create command
-	allCommand := append(shellPath, commandString)
-	command := exec.CommandContext(ctx, allCommand[0], allCommand[1:]...)
+	command := exec.Command(allCommand[0], allCommand[1:]...)
 	command.Dir = workingDir
 
+	// The environment is inherited as is. Streamed commands run user scripts (directly, or through bisect run),
+	// which shouldn't get the askpass socket and its token
+
 	// Log the command being executed
 	logger.Log.Debug("Executing command: %s", strings.Join(command.Args, " "))
 	logger.Log.Trace("\t- Command working directory: %s", command.Dir)
@@ -131,6 +198,11 @@ func streamOutput(ctx context.Context, shellPath []string, commandString, workin
 	activeCommands[broadcastToTopic] = command
 	activeCommandsMutex.Unlock()
 
+	// Cancelling the context (e.g. a job being cancelled) goes through the same path as a cancel event
+	stopCancellingOnDone := context.AfterFunc(ctx, func() {
+		cancelCommand(broadcastToTopic)
+	})
+
 	// Emit started event
 	emitEvent(ctx, broadcastToTopic, StreamedCommandEvent{
 		State:     StateStarted,
@@ -140,33 +212,45 @@ func streamOutput(ctx context.Context, shellPath []string, commandString, workin
 	// Set up pipes for stdout and stderr
 	stdout, err := command.StdoutPipe()
 	if err != nil {
-		return fmt.Errorf("failed to create stdout pipe: %v", err)
+		stopCancellingOnDone()
+		return nil, fmt.Errorf("failed to create stdout pipe: %v", err)
 	}
 
 	stderr, err := command.StderrPipe()
 	if err != nil {
-		return fmt.Errorf("failed to create stderr pipe: %v", err)
+		stopCancellingOnDone()
+		return nil, fmt.Errorf("failed to create stderr pipe: %v", err)
 	}
 
 	// Start the command
 	err = command.Start()
 	if err != nil {
-		return fmt.Errorf("failed to start command: %v", err)
+		stopCancellingOnDone()
+		return nil, fmt.Errorf("failed to start command: %v", err)
 	}
 
 	// Stream output from both stdout and stderr
 	var wg sync.WaitGroup
 	wg.Add(2)
 
-	go streamPipe(ctx, stdout, "stdout", broadcastToTopic, commandID, &wg)
-	go streamPipe(ctx, stderr, "stderr", broadcastToTopic, commandID, &wg)
+	go streamPipe(ctx, stdout, "stdout", broadcastToTopic, commandID, parser, false, &wg)
+	go streamPipe(ctx, stderr, "stderr", broadcastToTopic, commandID, nil, parseGitProgress, &wg)
 
 	// Wait for command to complete
+	done := make(chan error, 1)
 	go func() {
 		wg.Wait()
 
 		// Wait for the command to finish
 		cmdErr := command.Wait()
+		stopCancellingOnDone()
+
+		// Stream anything the parser was still holding on to
+		if parser != nil {
+			if event := parser.Flush(); event != nil {
+				emitParsedEvent(ctx, broadcastToTopic, event)
+			}
+		}
 
 		// Calculate duration
 		duration := time.Since(startTime)
@@ -186,20 +270,28 @@ func streamOutput(ctx context.Context, shellPath []string, commandString, workin
 		var finalState CommandExecutionState
 		var exitCode int
 		var errorMsg string
+		var resultErr error
 
 		if wasCancelled {
 			finalState = StateCancelled
 			errorMsg = "Command was cancelled"
+			resultErr = &CommandError{Kind: ErrCancelled, Args: command.Args, ExitCode: -1, Cause: cmdErr}
 			logger.Log.Debug("Command was cancelled: %s", strings.Join(command.Args, " "))
 		} else if cmdErr != nil {
-			if exitError, ok := cmdErr.(*exec.ExitError); ok {
+			if exitError, ok := cmdErr.(*exec.ExitError); ok && parser != nil && parser.IsSuccessExitCode(exitError.ExitCode()) {
+				exitCode = exitError.ExitCode()
+				finalState = StateCompleted
+				logger.Log.Debug("Command completed with accepted exit code %d: %s", exitCode, strings.Join(command.Args, " "))
+			} else if exitError, ok := cmdErr.(*exec.ExitError); ok {
 				exitCode = exitError.ExitCode()
 				finalState = StateError
 				errorMsg = fmt.Sprintf("Command failed with exit code %d", exitCode)
+				resultErr = &CommandError{Kind: ErrCommandFailed, Args: command.Args, ExitCode: exitCode, Cause: cmdErr}
 				logger.Log.Error("Command failed with exit code %d: %s", exitCode, strings.Join(command.Args, " "))
 			} else {
 				finalState = StateError
 				errorMsg = cmdErr.Error()
+				resultErr = &CommandError{Kind: ErrCommandFailed, Args: command.Args, ExitCode: -1, Cause: cmdErr}
 				logger.Log.Error("Error running command: %v", cmdErr)
 			}
 		} else {
@@ -220,26 +312,48 @@ func streamOutput(ctx context.Context, shellPath []string, commandString, workin
 		})
 
 		logger.Log.Trace("Command execution time: %v", duration)
+		done <- resultErr
 	}()
 
-	return nil
+	return func() error { return <-done }, nil
 }
 
-// streamPipe reads from a pipe and emits output events
-func streamPipe(ctx context.Context, pipe io.ReadCloser, pipeType, broadcastToTopic, commandID string, wg *sync.WaitGroup) {
+// streamPipe reads from a pipe and emits output events. With parseGitProgress, the stderr lines can end with "\r" as
+// well, so git's progress updates are streamed as they happen, as a ProgressEvent in the event's Data. Any other
+// output (stdout, user scripts, bisect run) is split on newlines only and streamed as is, since a "\r" can be part of
+// a line there (e.g. in a file that git grep matched)
+func streamPipe(ctx context.Context, pipe io.ReadCloser, pipeType, broadcastToTopic, commandID string, parser StreamedOutputParser, parseGitProgress bool, wg *sync.WaitGroup) {
 	defer wg.Done()
 	defer pipe.Close()
 
 	isErrorOutput := pipeType == "stderr"
+	parsesProgress := isErrorOutput && parseGitProgress
 	scanner := bufio.NewScanner(pipe)
+	if parsesProgress {
+		scanner.Split(scanLinesAndCarriageReturns)
+	}
 	for scanner.Scan() {
 		line := scanner.Text()
+		if parsesProgress {
+			if progress, ok := ParseGitProgressLine(line); ok {
+				emitProgressEvent(ctx, broadcastToTopic, commandID, line, progress)
+				continue
+			}
+		}
+
 		if line != "" {
 			output := line + "\n" // Add newline to preserve line breaks in the logged output
 
 			// Append output to command log
 			LogCommandAppendMoreOutput(commandID, output, isErrorOutput)
 
+			if parser != nil {
+				if event := parser.ParseLine(line); event != nil {
+					emitParsedEvent(ctx, broadcastToTopic, event)
+				}
+				continue
+			}
+
 			// Emit event for real-time streaming (without the added newline for display)
 			emitEvent(ctx, broadcastToTopic, StreamedCommandEvent{
 				State:     StateOutput,
@@ -254,6 +368,29 @@ func streamPipe(ctx context.Context, pipe io.ReadCloser, pipeType, broadcastToTo
 	}
 }
 
+// emitProgressEvent streams a progress update. Only the line that finishes a phase is kept in the command log and
+// the output, the intermediate ones are only sent as data
+func emitProgressEvent(ctx context.Context, broadcastToTopic, commandID, line string, progress ProgressEvent) {
+	event := StreamedCommandEvent{
+		State:     StateOutput,
+		Data:      progress,
+		Timestamp: time.Now(),
+	}
+
+	if progress.Done {
+		LogCommandAppendMoreOutput(commandID, line+"\n", true)
+		event.Output = line
+	}
+	emitEvent(ctx, broadcastToTopic, event)
+}
+
+// emitParsedEvent emits an output event produced by a StreamedOutputParser
+func emitParsedEvent(ctx context.Context, broadcastToTopic string, event *StreamedCommandEvent) {
+	event.State = StateOutput
+	event.Timestamp = time.Now()
+	emitEvent(ctx, broadcastToTopic, *event)
+}
+
 // emitEvent emits a StreamedCommandEvent to the frontend
 func emitEvent(ctx context.Context, broadcastToTopic string, event StreamedCommandEvent) {
 	runtime.EventsEmit(ctx, broadcastToTopic, event)
@@ -1,10 +1,15 @@
 package backend
 
 import (
+	"context"
 	"gitwhale/backend/command_utils"
+	"gitwhale/backend/git_operations"
 	"gitwhale/backend/lib"
 	"gitwhale/backend/logger"
+	"maps"
 	"path/filepath"
+	"slices"
+	"sync"
 )
 
 type AppConfig struct {
@@ -23,23 +28,45 @@ type AppConfig struct {
 
 	// A list of starred repos that persist at the top
 	StarredGitRepos []string `json:"starredGitRepos"`
+
+	// Guards the repo lists and the settings, which the automation and instance servers change from their own
+	// goroutines
+	mutex sync.RWMutex
 }
 
 type AppSettings struct {
 	Git                GitSettings                    `json:"git"`
 	Terminal           command_utils.TerminalSettings `json:"terminal"`
 	UI                 UISettings                     `json:"ui"`
+	Automation         AutomationSettings             `json:"automation"`
 	UserScriptCommands []UserDefinedCommandDefinition `json:"userScriptCommands"`
 }
 
+type AutomationSettings struct {
+	// Lets editor plugins and scripts drive GitWhale through the local automation server. Off by default
+	Enabled bool `json:"enabled"`
+}
+
 type UISettings struct {
 	AutoShowCommitDetails bool `json:"autoShowCommitDetails"`
+
+	// Leave out files that are identical on both sides when diffing two directories
+	HideUnchangedDirDiffFiles bool `json:"hideUnchangedDirDiffFiles"`
 }
 
 type GitSettings struct {
 	CommitsToLoad             int `json:"commitsToLoad"`
 	CommitMessageTabWidth     int `json:"commitMessageTabWidth"`
 	CommitMessageWrapLimitCol int `json:"commitMessageWrapLimitCol"`
+
+	// The default options used for every diff, unless a request overrides them
+	DiffSettings git_operations.DiffSettings `json:"diffSettings"`
+
+	// Limits for the diff sessions that are kept open
+	DiffSessions git_operations.DiffSessionSettings `json:"diffSessions"`
+
+	// How the log, refs and file contents are read. Defaults to the git CLI
+	Backend git_operations.GitBackendType `json:"backend"`
 }
 
 type UserDefinedCommandDefinition struct {
@@ -83,6 +110,10 @@ func LoadAppConfig() (*AppConfig, error) {
 					CommitsToLoad:             25,
 					CommitMessageTabWidth:     4,
 					CommitMessageWrapLimitCol: 72,
+					DiffSessions: git_operations.DiffSessionSettings{
+						MaxDiskUsageMB: 1024,
+						MaxIdleHours:   24,
+					},
 				},
 				Terminal: command_utils.TerminalSettings{
 					DefaultInteractiveTerminalCommand: "",
@@ -111,6 +142,12 @@ func LoadAppConfig() (*AppConfig, error) {
 	if config.Settings.Git.CommitMessageWrapLimitCol == 0 {
 		config.Settings.Git.CommitMessageWrapLimitCol = 70
 	}
+	if config.Settings.Git.DiffSessions.MaxDiskUsageMB == 0 {
+		config.Settings.Git.DiffSessions.MaxDiskUsageMB = 1024
+	}
+	if config.Settings.Git.DiffSessions.MaxIdleHours == 0 {
+		config.Settings.Git.DiffSessions.MaxIdleHours = 24
+	}
 	if config.Settings.Terminal.FontSize == 0 {
 		config.Settings.Terminal.FontSize = 14
 	}
@@ -129,11 +166,33 @@ func LoadAppConfig() (*AppConfig, error) {
 }
 
 func (config *AppConfig) SaveAppConfig() error {
-	return lib.SaveAsJSON(config.FilePath, config)
+	return lib.SaveAsJSON(config.FilePath, config.snapshot())
+}
+
+// Returns a copy of the config that can be serialized while the repo lists keep changing
+func (config *AppConfig) snapshot() *AppConfig {
+	config.mutex.RLock()
+	defer config.mutex.RUnlock()
+
+	return &AppConfig{
+		FilePath:            config.FilePath,
+		Settings:            config.Settings,
+		GitReposMap:         maps.Clone(config.GitReposMap),
+		OrderedOpenGitRepos: slices.Clone(config.OrderedOpenGitRepos),
+		RecentGitRepos:      slices.Clone(config.RecentGitRepos),
+		StarredGitRepos:     slices.Clone(config.StarredGitRepos),
+	}
+}
+
+// Returns the open repos, in the order of their tabs
+func (config *AppConfig) getOpenRepos() []string {
+	config.mutex.RLock()
+	defer config.mutex.RUnlock()
+	return slices.Clone(config.OrderedOpenGitRepos)
 }
 
 // Returns the absolute path that should be used to key into the repo
-func (config *AppConfig) openNewRepo(gitRepoPath string) {
+func (config *AppConfig) openNewRepo(ctx context.Context, gitRepoPath string) {
 	gitRepoPath, err := filepath.Abs(gitRepoPath)
 	if err != nil {
 		logger.Log.Error("Failed to get the absolute path for the repo: %v", gitRepoPath)
@@ -142,13 +201,16 @@ func (config *AppConfig) openNewRepo(gitRepoPath string) {
 	}
 
 	// Add to the list of open git repos if it's not already open for some reason
-	logger.Log.Info("Current config: %v", config)
+	logger.Log.Info("Opening repo: %s", gitRepoPath)
+	config.mutex.Lock()
 	if _, exists := config.GitReposMap[gitRepoPath]; !exists {
-		config.GitReposMap[gitRepoPath] = *CreateContext(gitRepoPath)
+		config.GitReposMap[gitRepoPath] = *CreateContext(ctx, gitRepoPath)
 		config.OrderedOpenGitRepos = append(config.OrderedOpenGitRepos, gitRepoPath)
 	}
 
 	config.addRepoToRecentList(gitRepoPath)
+	config.mutex.Unlock()
+
 	config.SaveAppConfig()
 }
 
@@ -165,6 +227,9 @@ func (config *AppConfig) addRepoToRecentList(gitRepoPath string) {
 }
 
 func (config *AppConfig) closeRepo(gitRepoPath string) {
+	config.mutex.Lock()
+	defer config.mutex.Unlock()
+
 	// Remove the from map
 	delete(config.GitReposMap, gitRepoPath)
 
@@ -181,6 +246,9 @@ func (config *AppConfig) toggleStarRepo(gitRepoPath string) bool {
 		return false
 	}
 
+	config.mutex.Lock()
+	defer config.mutex.Unlock()
+
 	starIndex := lib.FindIndex(config.StarredGitRepos, gitRepoPath)
 	if starIndex >= 0 {
 		// Repo is starred, so unstar it
@@ -194,7 +262,17 @@ func (config *AppConfig) toggleStarRepo(gitRepoPath string) bool {
 }
 
 func (config *AppConfig) updateSettings(newSettings AppSettings) error {
+	if err := newSettings.Git.DiffSettings.Validate(); err != nil {
+		return err
+	}
+	if err := newSettings.Git.Backend.Validate(); err != nil {
+		return err
+	}
+
+	config.mutex.Lock()
 	config.Settings = newSettings
+	config.mutex.Unlock()
+
 	return config.SaveAppConfig()
 }
 
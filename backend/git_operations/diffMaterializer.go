@@ -0,0 +1,416 @@
+package git_operations
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"gitwhale/backend/command_utils"
+	"gitwhale/backend/logger"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	nullBlobHash       = "0000000000000000000000000000000000000000"
+	nullBlobHashSha256 = "0000000000000000000000000000000000000000000000000000000000000000"
+
+	gitModeSubmodule  = "160000"
+	gitModeExecutable = "100755"
+
+	// Every worker runs its own `git cat-file --batch` process
+	maxMaterializeWorkers = 8
+)
+
+// diffEntry is one file that differs between the two sides of a diff, as reported by `git diff --raw`
+// (the same information as --name-status, plus the modes and blob ids of both sides)
+type diffEntry struct {
+	Status  string
+	OldPath string
+	NewPath string
+	OldMode string
+	NewMode string
+	OldBlob string
+	NewBlob string
+}
+
+// A single file to write into one of the session folders
+type materializeJob struct {
+	path     string
+	mode     string
+	blob     string
+	destRoot string
+
+	// Working tree files aren't stored in git yet, so they're read from disk instead
+	fromWorkingTree bool
+}
+
+// materializeDiff writes the two versions of every changed file into the left and right folders.
+// Boolean is for whether there were any changes found. When it runs as a job, it reports how many files were written
+func materializeDiff(ctx context.Context, options DiffOptions, leftDest, rightDest string) (bool, error) {
+	logger.Log.Info("Starting diff operation for repo: %s,  %s -> %s", options.RepoPath, options.FromRef, options.ToRef)
+	logger.Log.Debug("Diff destinations - Left: %s, Right: %s", leftDest, rightDest)
+
+	startTime := time.Now()
+
+	entries, err := listDiffEntries(ctx, options)
+	if err != nil {
+		return false, err
+	}
+
+	if len(entries) == 0 {
+		logger.Log.Debug("No changes found between %s and %s", options.FromRef, options.ToRef)
+		return false, nil
+	}
+
+	repoRoot, err := FindRepoRoot(ctx, options.RepoPath)
+	if err != nil {
+		return false, err
+	}
+
+	jobs := buildMaterializeJobs(entries, options.ToRef == "", leftDest, rightDest)
+	if err := runMaterializeJobs(ctx, repoRoot, jobs); err != nil {
+		return false, err
+	}
+
+	logger.Log.Info("Wrote %d files for %d changes in %v", len(jobs), len(entries), time.Since(startTime))
+	return true, nil
+}
+
+// listDiffEntries returns the changed files, using the same settings and revisions as every other diff
+func listDiffEntries(ctx context.Context, options DiffOptions) ([]diffEntry, error) {
+	args := []string{"-c", "core.quotePath=false", "diff", "--raw", "-z", "--no-abbrev", "--no-ext-diff", "--no-textconv"}
+	args = append(args, options.DiffSettings.gitArgs()...)
+	args = append(args, diffRevisionArgs(options)...)
+	args = append(args, "--")
+	args = append(args, options.Pathspecs...)
+
+	result, err := runGit(ctx, options.RepoPath, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list the changed files: %w", err)
+	}
+
+	return parseRawDiffOutput(result.Stdout)
+}
+
+// parseRawDiffOutput parses `git diff --raw -z` records: ":oldMode newMode oldBlob newBlob status\0path\0[newPath\0]"
+func parseRawDiffOutput(output string) ([]diffEntry, error) {
+	entries := []diffEntry{}
+	fields := strings.Split(output, "\x00")
+
+	for i := 0; i < len(fields); i++ {
+		header := fields[i]
+		if header == "" {
+			continue
+		}
+
+		parts := strings.Fields(strings.TrimPrefix(header, ":"))
+		if !strings.HasPrefix(header, ":") || len(parts) != 5 {
+			return nil, fmt.Errorf("unexpected git diff --raw record: %s", header)
+		}
+
+		entry := diffEntry{
+			OldMode: parts[0],
+			NewMode: parts[1],
+			OldBlob: parts[2],
+			NewBlob: parts[3],
+			Status:  parts[4][:1], // Renames and copies carry their similarity score, e.g. "R087"
+		}
+
+		if i+1 >= len(fields) {
+			return nil, fmt.Errorf("missing path for git diff --raw record: %s", header)
+		}
+		i++
+		entry.OldPath = fields[i]
+		entry.NewPath = fields[i]
+
+		if entry.Status == "R" || entry.Status == "C" {
+			if i+1 >= len(fields) {
+				return nil, fmt.Errorf("missing new path for git diff --raw record: %s", header)
+			}
+			i++
+			entry.NewPath = fields[i]
+		}
+
+		entries = append(entries, entry)
+	}
+
+	return entries, nil
+}
+
+func buildMaterializeJobs(entries []diffEntry, rightIsWorkingTree bool, leftDest, rightDest string) []materializeJob {
+	jobs := []materializeJob{}
+	for _, entry := range entries {
+		if entry.Status != "A" && !isNullBlob(entry.OldBlob) {
+			jobs = append(jobs, materializeJob{
+				path:     entry.OldPath,
+				mode:     entry.OldMode,
+				blob:     entry.OldBlob,
+				destRoot: leftDest,
+			})
+		}
+
+		if entry.Status == "D" {
+			continue
+		}
+
+		// git only knows the blob id of working tree files that match the index
+		fromWorkingTree := rightIsWorkingTree && isNullBlob(entry.NewBlob)
+		if isNullBlob(entry.NewBlob) && !fromWorkingTree {
+			continue
+		}
+
+		jobs = append(jobs, materializeJob{
+			path:            entry.NewPath,
+			mode:            entry.NewMode,
+			blob:            entry.NewBlob,
+			destRoot:        rightDest,
+			fromWorkingTree: fromWorkingTree,
+		})
+	}
+	return jobs
+}
+
+// runMaterializeJobs writes the files in parallel, and returns the first error that any worker hit.
+// Cancelling ctx stops the workers after the file they're writing
+func runMaterializeJobs(ctx context.Context, repoRoot string, jobs []materializeJob) error {
+	workerCount := min(runtime.NumCPU(), maxMaterializeWorkers, len(jobs))
+	jobQueue := make(chan materializeJob)
+
+	var firstErr error
+	var errMutex sync.Mutex
+	recordErr := func(err error) {
+		errMutex.Lock()
+		defer errMutex.Unlock()
+		if firstErr == nil {
+			firstErr = err
+		}
+	}
+	hasFailed := func() bool {
+		errMutex.Lock()
+		defer errMutex.Unlock()
+		return firstErr != nil
+	}
+
+	var writtenCount atomic.Int64
+
+	var wg sync.WaitGroup
+	for range workerCount {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			reader := &blobReader{repoPath: repoRoot}
+			defer reader.Close()
+
+			for job := range jobQueue {
+				if hasFailed() || ctx.Err() != nil {
+					continue // Drain the queue
+				}
+				if err := materializeFile(ctx, repoRoot, reader, job); err != nil {
+					recordErr(err)
+				}
+
+				written := writtenCount.Add(1)
+				command_utils.ReportJobProgress(ctx, fmt.Sprintf("Writing files (%d/%d)", written, len(jobs)), int(written*100)/len(jobs))
+			}
+		}()
+	}
+
+	for _, job := range jobs {
+		jobQueue <- job
+	}
+	close(jobQueue)
+	wg.Wait()
+
+	if firstErr == nil && ctx.Err() != nil {
+		return fmt.Errorf("%w while writing the diff files", command_utils.ErrCancelled)
+	}
+	return firstErr
+}
+
+func materializeFile(ctx context.Context, repoRoot string, reader *blobReader, job materializeJob) error {
+	var content []byte
+	var err error
+
+	switch {
+	case job.mode == gitModeSubmodule:
+		content, err = readSubmoduleContent(ctx, repoRoot, job)
+	case job.fromWorkingTree:
+		content, err = readWorkingTreeFile(filepath.Join(repoRoot, filepath.FromSlash(job.path)))
+	default:
+		content, err = reader.Read(job.blob)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %v", job.path, err)
+	}
+
+	destPath := filepath.Join(job.destRoot, filepath.FromSlash(job.path))
+	if err := os.MkdirAll(filepath.Dir(destPath), 0755); err != nil {
+		return fmt.Errorf("failed to create the folder for %s: %v", job.path, err)
+	}
+
+	permissions := os.FileMode(0644)
+	if job.mode == gitModeExecutable {
+		permissions = 0755
+	}
+
+	if err := os.WriteFile(destPath, content, permissions); err != nil {
+		return fmt.Errorf("failed to write %s: %v", destPath, err)
+	}
+	return nil
+}
+
+// Symlinks are shown as their target path, the same way git stores them
+func readWorkingTreeFile(path string) ([]byte, error) {
+	info, err := os.Lstat(path)
+	if err != nil {
+		return nil, err
+	}
+
+	if info.Mode()&os.ModeSymlink != 0 {
+		target, err := os.Readlink(path)
+		if err != nil {
+			return nil, err
+		}
+		return []byte(target), nil
+	}
+
+	return os.ReadFile(path)
+}
+
+// Submodules are shown the same way `git diff` shows them, as the commit they point to
+func readSubmoduleContent(ctx context.Context, repoRoot string, job materializeJob) ([]byte, error) {
+	commitHash := job.blob
+	if job.fromWorkingTree {
+		result, err := runGit(ctx, filepath.Join(repoRoot, filepath.FromSlash(job.path)), "rev-parse", "HEAD")
+		if err != nil {
+			return nil, fmt.Errorf("failed to read the checked out commit of the submodule: %w", err)
+		}
+		commitHash = strings.TrimSpace(result.Stdout)
+	}
+
+	return []byte(fmt.Sprintf("Subproject commit %s\n", commitHash)), nil
+}
+
+var errObjectMissing = errors.New("object is missing from the repository")
+
+// blobReader reads blobs through a long-running `git cat-file --batch` process, started on first use
+type blobReader struct {
+	repoPath  string
+	cmd       *exec.Cmd
+	stdin     io.WriteCloser
+	stdout    *bufio.Reader
+	stderr    bytes.Buffer
+	commandID string
+	blobsRead int
+}
+
+func (reader *blobReader) start() error {
+	cmd := exec.Command("git", "cat-file", "--batch")
+	cmd.Dir = reader.repoPath
+	cmd.Env = append(os.Environ(), "GIT_OPTIONAL_LOCKS=0")
+	reader.stderr.Reset()
+	cmd.Stderr = &reader.stderr
+
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return fmt.Errorf("failed to open the stdin of git cat-file: %v", err)
+	}
+
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return fmt.Errorf("failed to open the stdout of git cat-file: %v", err)
+	}
+
+	// This keeps running while blobs are read, so it can't go through a command_utils.Runner.
+	// It's still logged like one, and ends up in the command log once it exits
+	logger.Log.Debug("Starting git cat-file --batch in %s", reader.repoPath)
+	reader.commandID = command_utils.LogCommandStart(cmd.Args, reader.repoPath)
+	reader.blobsRead = 0
+
+	command_utils.HideWindowsConsole(cmd)
+	if err := cmd.Start(); err != nil {
+		command_utils.LogCommandEnd(reader.commandID, "", err.Error(), -1)
+		return fmt.Errorf("failed to start git cat-file: %v", err)
+	}
+
+	reader.cmd = cmd
+	reader.stdin = stdin
+	reader.stdout = bufio.NewReader(stdout)
+	return nil
+}
+
+// Read returns the contents of a blob, given its hash or any other object name (e.g. "HEAD:path").
+// The response format is "<hash> <type> <size>\n<contents>\n"
+func (reader *blobReader) Read(blobHash string) ([]byte, error) {
+	if reader.cmd == nil {
+		if err := reader.start(); err != nil {
+			return nil, err
+		}
+	}
+
+	if _, err := io.WriteString(reader.stdin, blobHash+"\n"); err != nil {
+		return nil, fmt.Errorf("failed to request blob %s: %v", blobHash, err)
+	}
+
+	header, err := reader.stdout.ReadString('\n')
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the header of blob %s: %v", blobHash, err)
+	}
+
+	// The object name is echoed back as is, so it can have spaces in it (e.g. "HEAD:some file.txt missing")
+	if strings.HasSuffix(strings.TrimSpace(header), " missing") {
+		return nil, fmt.Errorf("%w: %s", errObjectMissing, blobHash)
+	}
+
+	headerParts := strings.Fields(header)
+	if len(headerParts) != 3 {
+		return nil, fmt.Errorf("unexpected git cat-file header: %s", strings.TrimSpace(header))
+	}
+
+	size, err := strconv.ParseInt(headerParts[2], 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid size in git cat-file header '%s': %v", strings.TrimSpace(header), err)
+	}
+
+	// The contents are followed by a newline
+	content := make([]byte, size+1)
+	if _, err := io.ReadFull(reader.stdout, content); err != nil {
+		return nil, fmt.Errorf("failed to read blob %s: %v", blobHash, err)
+	}
+
+	reader.blobsRead++
+	return content[:size], nil
+}
+
+func (reader *blobReader) Close() {
+	if reader.cmd == nil {
+		return
+	}
+
+	reader.stdin.Close()
+	err := reader.cmd.Wait()
+	exitCode := reader.cmd.ProcessState.ExitCode()
+	if err != nil {
+		logger.Log.Warning("git cat-file exited with an error: %v", err)
+	} else {
+		logger.Log.Debug("git cat-file finished after reading %d blobs", reader.blobsRead)
+	}
+
+	command_utils.LogCommandEnd(reader.commandID, fmt.Sprintf("Read %d blobs", reader.blobsRead), reader.stderr.String(), exitCode)
+	reader.cmd = nil
+}
+
+func isNullBlob(blobHash string) bool {
+	return blobHash == nullBlobHash || blobHash == nullBlobHashSha256
+}
@@ -0,0 +1,461 @@
+package git_operations
+
+import (
+	"bytes"
+	"container/list"
+	"context"
+	"errors"
+	"fmt"
+	"gitwhale/backend/lib"
+	"gitwhale/backend/logger"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+type DiffSide string
+
+const (
+	DiffSideLeft  DiffSide = "left"
+	DiffSideRight DiffSide = "right"
+)
+
+// The most blob bytes that are kept in memory across all lazy diff sessions
+const maxCachedBlobBytes = 64 * 1024 * 1024
+
+// git treats a file as binary when there's a NUL byte in its first 8000 bytes
+const binaryDetectionBytes = 8000
+
+// DiffManifestEntry describes a changed file in a lazy diff session, without its contents
+type DiffManifestEntry struct {
+	Status  string `json:"status"` // A, C, D, M, R, T or U, like git diff --name-status
+	Path    string `json:"path"`
+	OldPath string `json:"oldPath"` // Same as Path unless the file was renamed or copied
+
+	OldMode string `json:"oldMode"`
+	NewMode string `json:"newMode"`
+	OldBlob string `json:"oldBlob"`
+	NewBlob string `json:"newBlob"`
+
+	// -1 when the file doesn't exist on that side
+	OldSize int64 `json:"oldSize"`
+	NewSize int64 `json:"newSize"`
+
+	// The right side has to be read from the working tree, since git doesn't have a blob for it yet
+	NewFromWorkingTree bool `json:"newFromWorkingTree"`
+}
+
+// DiffFileContent is one side of a file in a lazy diff session
+type DiffFileContent struct {
+	Path     string   `json:"path"`
+	Side     DiffSide `json:"side"`
+	Exists   bool     `json:"exists"`
+	Size     int64    `json:"size"`
+	IsBinary bool     `json:"isBinary"`
+	Content  string   `json:"content"` // Empty for binary files
+
+	// The encoding the file was stored in. Content is always converted to UTF-8
+	Encoding FileEncoding `json:"encoding"`
+}
+
+var lazyDiffBlobCache = newBlobContentCache(maxCachedBlobBytes)
+
+// Creates a diff session that only holds the manifest of changed files. Their contents are loaded
+// on demand with DiffSessionManager.GetFileContent
+func createLazyDiffSession(ctx context.Context, options DiffOptions) (*DiffSession, error) {
+	sessionId := generateSessionId(options)
+	logger.Log.Debug("Created lazy diff session ID: %s", sessionId)
+
+	entries, err := listDiffEntries(ctx, options)
+	if err != nil {
+		return nil, err
+	}
+
+	session := &DiffSession{
+		SessionId:    sessionId,
+		RepoPath:     options.RepoPath,
+		FromRef:      options.FromRef,
+		ToRef:        options.ToRef,
+		CreatedAt:    time.Now(),
+		LastAccessed: time.Now(),
+		Title:        generateDiffTitle(options),
+		HasDiffData:  len(entries) > 0,
+		IsLazy:       true,
+		Manifest:     []DiffManifestEntry{},
+		Options:      options,
+	}
+
+	if !session.HasDiffData {
+		return session, nil
+	}
+
+	session.Manifest, err = buildDiffManifest(ctx, options.RepoPath, entries, options.ToRef == "")
+	if err != nil {
+		return nil, err
+	}
+
+	if options.IsSingleCommitDiff {
+		session.CommitInformation, err = GetGitLogCommitInfo(ctx, options.RepoPath, options.FromRef)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load information about the commit %s", options.FromRef)
+		}
+	}
+
+	session.DirectoryData = buildManifestDirectory(session.Manifest)
+
+	logger.Log.Info("Created lazy diff session: %s with %d changed files", sessionId, len(session.Manifest))
+	return session, nil
+}
+
+// buildDiffManifest turns the raw diff entries into manifest entries, with the size of both sides
+func buildDiffManifest(ctx context.Context, repoPath string, entries []diffEntry, rightIsWorkingTree bool) ([]DiffManifestEntry, error) {
+	repoRoot, err := FindRepoRoot(ctx, repoPath)
+	if err != nil {
+		return nil, err
+	}
+
+	blobHashes := []string{}
+	for _, entry := range entries {
+		if !isNullBlob(entry.OldBlob) && entry.OldMode != gitModeSubmodule {
+			blobHashes = append(blobHashes, entry.OldBlob)
+		}
+		if !isNullBlob(entry.NewBlob) && entry.NewMode != gitModeSubmodule {
+			blobHashes = append(blobHashes, entry.NewBlob)
+		}
+	}
+
+	blobSizes, err := readBlobSizes(ctx, repoRoot, blobHashes)
+	if err != nil {
+		return nil, err
+	}
+
+	manifest := make([]DiffManifestEntry, 0, len(entries))
+	for _, entry := range entries {
+		manifestEntry := DiffManifestEntry{
+			Status:             entry.Status,
+			Path:               entry.NewPath,
+			OldPath:            entry.OldPath,
+			OldMode:            entry.OldMode,
+			NewMode:            entry.NewMode,
+			OldBlob:            entry.OldBlob,
+			NewBlob:            entry.NewBlob,
+			OldSize:            -1,
+			NewSize:            -1,
+			NewFromWorkingTree: rightIsWorkingTree && isNullBlob(entry.NewBlob) && entry.Status != "D",
+		}
+
+		if size, exists := blobSizes[entry.OldBlob]; exists && entry.Status != "A" {
+			manifestEntry.OldSize = size
+		}
+
+		if manifestEntry.NewFromWorkingTree {
+			if info, err := os.Lstat(filepath.Join(repoRoot, filepath.FromSlash(entry.NewPath))); err == nil {
+				manifestEntry.NewSize = info.Size()
+			}
+		} else if size, exists := blobSizes[entry.NewBlob]; exists && entry.Status != "D" {
+			manifestEntry.NewSize = size
+		}
+
+		manifest = append(manifest, manifestEntry)
+	}
+
+	return manifest, nil
+}
+
+// Uses `git cat-file --batch-check` to look up the size of every blob in one go
+func readBlobSizes(ctx context.Context, repoPath string, blobHashes []string) (map[string]int64, error) {
+	sizes := make(map[string]int64, len(blobHashes))
+	if len(blobHashes) == 0 {
+		return sizes, nil
+	}
+
+	result, err := runGitWithStdin(ctx, repoPath, strings.NewReader(strings.Join(blobHashes, "\n")+"\n"), "cat-file", "--batch-check")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the size of the changed files: %w", err)
+	}
+
+	// Each line is "<hash> <type> <size>", or "<hash> missing"
+	for _, line := range strings.Split(strings.TrimSpace(result.Stdout), "\n") {
+		parts := strings.Fields(line)
+		if len(parts) != 3 {
+			continue
+		}
+
+		size, err := strconv.ParseInt(parts[2], 10, 64)
+		if err != nil {
+			continue
+		}
+		sizes[parts[0]] = size
+	}
+
+	return sizes, nil
+}
+
+// Builds the same folder structure as readDirDiffStructure, but from the manifest instead of the disk
+func buildManifestDirectory(manifest []DiffManifestEntry) *Directory {
+	rootDir := &Directory{
+		Path:    "./",
+		Name:    "./",
+		Files:   make([]*FileInfo, 0),
+		SubDirs: make([]*Directory, 0),
+	}
+
+	dirMap := make(map[string]*Directory)
+	dirMap["."] = rootDir
+
+	var getDirectory func(relativeDir string) *Directory
+	getDirectory = func(relativeDir string) *Directory {
+		if directory, exists := dirMap[relativeDir]; exists {
+			return directory
+		}
+
+		directory := &Directory{
+			Path:    relativeDir,
+			Name:    filepath.Base(relativeDir),
+			Files:   make([]*FileInfo, 0),
+			SubDirs: make([]*Directory, 0),
+		}
+		dirMap[relativeDir] = directory
+
+		parent := getDirectory(filepath.Dir(relativeDir))
+		parent.SubDirs = append(parent.SubDirs, directory)
+		return directory
+	}
+
+	for _, entry := range manifest {
+		relativePath := filepath.FromSlash(entry.Path)
+		directory := getDirectory(filepath.Dir(relativePath))
+
+		file := &FileInfo{
+			Path:      relativePath,
+			Name:      filepath.Base(relativePath),
+			Extension: lib.RemoveLeadingPeriod(filepath.Ext(relativePath)),
+			Status:    manifestEntryChangeStatus(entry),
+			LeftSize:  entry.OldSize,
+			RightSize: entry.NewSize,
+		}
+		if file.Status == FileRenamed {
+			file.OldPath = filepath.FromSlash(entry.OldPath)
+		}
+
+		directory.Files = append(directory.Files, file)
+	}
+
+	// Line stats need the file contents, so lazy sessions only roll up the statuses
+	rollUpDirectoryStats(rootDir)
+
+	return rootDir
+}
+
+func manifestEntryChangeStatus(entry DiffManifestEntry) FileChangeStatus {
+	switch entry.Status {
+	case "A", "C":
+		return FileAdded
+	case "D":
+		return FileDeleted
+	case "R":
+		return FileRenamed
+	default:
+		return FileModified
+	}
+}
+
+// Loads one side of a changed file in a lazy diff session. The path can be either the old or new path of the file
+func readDiffSessionFileContent(ctx context.Context, session *DiffSession, reader *diffContentReader, path string, side DiffSide) (*DiffFileContent, error) {
+	if !session.IsLazy {
+		return nil, fmt.Errorf("diff session %s already has its files on disk", session.SessionId)
+	}
+
+	if side != DiffSideLeft && side != DiffSideRight {
+		return nil, fmt.Errorf("unsupported diff side: %s", side)
+	}
+
+	path = filepath.ToSlash(path)
+	fileContent := &DiffFileContent{Path: path, Side: side, Size: -1}
+
+	entry := findManifestEntry(session.Manifest, path, side)
+	if entry == nil {
+		return fileContent, nil
+	}
+
+	var content []byte
+	var err error
+	switch {
+	case side == DiffSideLeft:
+		content, err = reader.readBlob(entry.OldMode, entry.OldBlob)
+	case entry.NewFromWorkingTree:
+		content, err = reader.readWorkingTreeFile(ctx, entry)
+	default:
+		content, err = reader.readBlob(entry.NewMode, entry.NewBlob)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load the %s side of %s: %v", side, path, err)
+	}
+
+	fileContent.Exists = true
+	fileContent.Size = int64(len(content))
+	fileContent.Content, fileContent.Encoding = decodeFileContent(content)
+	fileContent.IsBinary = fileContent.Encoding == EncodingBinary
+
+	return fileContent, nil
+}
+
+// Finds the entry that has the path on the requested side. Returns nil if the file doesn't exist on that side
+func findManifestEntry(manifest []DiffManifestEntry, path string, side DiffSide) *DiffManifestEntry {
+	for i := range manifest {
+		entry := &manifest[i]
+		if side == DiffSideLeft && entry.OldPath == path && entry.Status != "A" && !isNullBlob(entry.OldBlob) {
+			return entry
+		}
+
+		if side == DiffSideRight && entry.Path == path && entry.Status != "D" && (entry.NewFromWorkingTree || !isNullBlob(entry.NewBlob)) {
+			return entry
+		}
+	}
+	return nil
+}
+
+// diffContentReader reads the files of one lazy diff session. It keeps a single `git cat-file --batch` running
+// for the session, instead of starting one for every file that's opened
+type diffContentReader struct {
+	mutex    sync.Mutex
+	repoPath string
+	repoRoot string // Looked up the first time a working tree file is read
+	blobs    *blobReader
+	closed   bool
+}
+
+func newDiffContentReader(repoPath string) *diffContentReader {
+	return &diffContentReader{
+		repoPath: repoPath,
+		blobs:    &blobReader{repoPath: repoPath},
+	}
+}
+
+func (reader *diffContentReader) readBlob(mode, blobHash string) ([]byte, error) {
+	if mode == gitModeSubmodule {
+		return []byte(fmt.Sprintf("Subproject commit %s\n", blobHash)), nil
+	}
+
+	if content, exists := lazyDiffBlobCache.Get(blobHash); exists {
+		return content, nil
+	}
+
+	reader.mutex.Lock()
+	defer reader.mutex.Unlock()
+
+	if reader.closed {
+		return nil, fmt.Errorf("the diff session was closed")
+	}
+
+	content, err := reader.blobs.Read(blobHash)
+	if err != nil {
+		// The output of cat-file can't be trusted after a failed read, so the next read starts it again
+		if !errors.Is(err, errObjectMissing) {
+			reader.blobs.Close()
+		}
+		return nil, err
+	}
+
+	lazyDiffBlobCache.Add(blobHash, content)
+	return content, nil
+}
+
+// Working tree files can change at any time, so they're never cached
+func (reader *diffContentReader) readWorkingTreeFile(ctx context.Context, entry *DiffManifestEntry) ([]byte, error) {
+	repoRoot, err := reader.findRepoRoot(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	job := materializeJob{path: entry.Path, mode: entry.NewMode, fromWorkingTree: true}
+	if entry.NewMode == gitModeSubmodule {
+		return readSubmoduleContent(ctx, repoRoot, job)
+	}
+	return readWorkingTreeFile(filepath.Join(repoRoot, filepath.FromSlash(entry.Path)))
+}
+
+func (reader *diffContentReader) findRepoRoot(ctx context.Context) (string, error) {
+	reader.mutex.Lock()
+	defer reader.mutex.Unlock()
+
+	if reader.repoRoot == "" {
+		repoRoot, err := FindRepoRoot(ctx, reader.repoPath)
+		if err != nil {
+			return "", err
+		}
+		reader.repoRoot = repoRoot
+	}
+	return reader.repoRoot, nil
+}
+
+// Close stops the session's git cat-file. Reads after this fail
+func (reader *diffContentReader) Close() {
+	reader.mutex.Lock()
+	defer reader.mutex.Unlock()
+
+	reader.closed = true
+	reader.blobs.Close()
+}
+
+func isBinaryContent(content []byte) bool {
+	return bytes.IndexByte(content[:min(len(content), binaryDetectionBytes)], 0) != -1
+}
+
+// blobContentCache is a least recently used cache of blob contents, limited by their total size.
+// Blobs never change for a given hash, so entries never go stale
+type blobContentCache struct {
+	mutex     sync.Mutex
+	maxBytes  int
+	usedBytes int
+	order     *list.List // Most recently used at the front
+	entries   map[string]*list.Element
+}
+
+type cachedBlob struct {
+	hash    string
+	content []byte
+}
+
+func newBlobContentCache(maxBytes int) *blobContentCache {
+	return &blobContentCache{
+		maxBytes: maxBytes,
+		order:    list.New(),
+		entries:  make(map[string]*list.Element),
+	}
+}
+
+func (cache *blobContentCache) Get(hash string) ([]byte, bool) {
+	cache.mutex.Lock()
+	defer cache.mutex.Unlock()
+
+	element, exists := cache.entries[hash]
+	if !exists {
+		return nil, false
+	}
+
+	cache.order.MoveToFront(element)
+	return element.Value.(*cachedBlob).content, true
+}
+
+func (cache *blobContentCache) Add(hash string, content []byte) {
+	cache.mutex.Lock()
+	defer cache.mutex.Unlock()
+
+	if _, exists := cache.entries[hash]; exists || len(content) > cache.maxBytes {
+		return
+	}
+
+	cache.entries[hash] = cache.order.PushFront(&cachedBlob{hash: hash, content: content})
+	cache.usedBytes += len(content)
+
+	for cache.usedBytes > cache.maxBytes {
+		oldest := cache.order.Back()
+		evicted := cache.order.Remove(oldest).(*cachedBlob)
+		delete(cache.entries, evicted.hash)
+		cache.usedBytes -= len(evicted.content)
+	}
+}
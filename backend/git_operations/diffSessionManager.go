@@ -0,0 +1,468 @@
+package git_operations
+
+import (
+	"context"
+	"fmt"
+	"gitwhale/backend/lib"
+	"gitwhale/backend/logger"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+const diffSessionJanitorInterval = 10 * time.Minute
+
+// DiffSessionSettings bounds how much the diff sessions can keep around
+type DiffSessionSettings struct {
+	// The total size of the session folders on disk, before the least recently used sessions are evicted
+	MaxDiskUsageMB int `json:"maxDiskUsageMB"`
+
+	// Sessions that weren't accessed for this long are cleaned up
+	MaxIdleHours int `json:"maxIdleHours"`
+}
+
+// DiffSessionManager owns every open diff session. It persists their manifests under the app folder so
+// they survive a restart, and evicts sessions once they go over the disk or idle limits.
+// The tracked sessions are only changed while holding the mutex, callers get copies of them
+type DiffSessionManager struct {
+	Settings *DiffSessionSettings
+
+	mutex          sync.Mutex
+	sessions       map[string]*DiffSession
+	contentReaders map[string]*diffContentReader // The file readers of the lazy sessions, started on first use
+	restoring      map[string]chan struct{}      // Closed once the session's files have been written again
+	manifestFolder string
+	stopJanitor    chan struct{}
+}
+
+// NewDiffSessionManager creates the manager, and restores the sessions that were saved in the manifest folder
+func NewDiffSessionManager(settings *DiffSessionSettings, manifestFolder string) *DiffSessionManager {
+	manager := &DiffSessionManager{
+		Settings:       settings,
+		sessions:       make(map[string]*DiffSession),
+		contentReaders: make(map[string]*diffContentReader),
+		restoring:      make(map[string]chan struct{}),
+		manifestFolder: manifestFolder,
+	}
+
+	manager.loadSavedSessions()
+	return manager
+}
+
+// Add tracks a new session, and evicts older sessions if it pushes the disk usage over the limit
+func (manager *DiffSessionManager) Add(session *DiffSession) {
+	if session == nil || !session.HasDiffData {
+		return
+	}
+
+	// The caller keeps its own copy, since it's still being sent to the frontend
+	trackedSession := *session
+	trackedSession.DiskSize = getFolderSize(getDiffSessionFolder(session.SessionId))
+
+	manager.mutex.Lock()
+	manager.sessions[session.SessionId] = &trackedSession
+	manager.saveSession(&trackedSession)
+	evictedSessionIds := manager.enforceDiskLimit(session.SessionId)
+	manager.mutex.Unlock()
+
+	manager.deleteSessionFiles(evictedSessionIds...)
+}
+
+// Get returns the session and marks it as used. Sessions restored after a restart get their files
+// written out again the first time they're used
+func (manager *DiffSessionManager) Get(sessionId string) (*DiffSession, error) {
+	manager.mutex.Lock()
+	session, exists := manager.sessions[sessionId]
+	if !exists {
+		manager.mutex.Unlock()
+		return nil, fmt.Errorf("diff session not found: %s", sessionId)
+	}
+
+	session.LastAccessed = time.Now()
+
+	if session.DirectoryData != nil {
+		sessionCopy := *session
+		manager.mutex.Unlock()
+		return &sessionCopy, nil
+	}
+
+	// Another call is already writing its files, so wait for it instead of writing them twice
+	if restoring, inProgress := manager.restoring[sessionId]; inProgress {
+		manager.mutex.Unlock()
+		<-restoring
+		return manager.Get(sessionId)
+	}
+
+	restoring := make(chan struct{})
+	manager.restoring[sessionId] = restoring
+	restoredSession := *session
+	manager.mutex.Unlock()
+
+	// Writing the files can take a while, so the lock isn't held in the meantime
+	restoreErr := restoreDiffSession(&restoredSession)
+	if restoreErr == nil {
+		restoredSession.DiskSize = getFolderSize(getDiffSessionFolder(sessionId))
+	}
+
+	manager.mutex.Lock()
+	delete(manager.restoring, sessionId)
+	close(restoring)
+
+	if manager.sessions[sessionId] != session {
+		// It was ended or evicted while its files were being written
+		manager.mutex.Unlock()
+		CleanupDiffSession(sessionId)
+		return nil, fmt.Errorf("diff session not found: %s", sessionId)
+	}
+
+	if restoreErr != nil {
+		manager.remove(sessionId)
+		manager.mutex.Unlock()
+		manager.deleteSessionFiles(sessionId)
+		return nil, fmt.Errorf("failed to restore diff session %s: %v", sessionId, restoreErr)
+	}
+
+	restoredSession.LastAccessed = session.LastAccessed
+	manager.sessions[sessionId] = &restoredSession
+	evictedSessionIds := manager.enforceDiskLimit(sessionId)
+	manager.mutex.Unlock()
+
+	manager.deleteSessionFiles(evictedSessionIds...)
+
+	sessionCopy := restoredSession
+	return &sessionCopy, nil
+}
+
+// GetFileContent loads one side of a changed file in a lazy diff session. The path can be either the old or new
+// path of the file
+func (manager *DiffSessionManager) GetFileContent(ctx context.Context, sessionId, path string, side DiffSide) (*DiffFileContent, error) {
+	session, err := manager.Get(sessionId)
+	if err != nil {
+		return nil, err
+	}
+
+	manager.mutex.Lock()
+	if _, exists := manager.sessions[sessionId]; !exists {
+		manager.mutex.Unlock()
+		return nil, fmt.Errorf("diff session not found: %s", sessionId)
+	}
+	reader, exists := manager.contentReaders[sessionId]
+	if !exists {
+		reader = newDiffContentReader(session.RepoPath)
+		manager.contentReaders[sessionId] = reader
+	}
+	manager.mutex.Unlock()
+
+	return readDiffSessionFileContent(ctx, session, reader, path, side)
+}
+
+// Remove deletes the session's files and its saved manifest
+func (manager *DiffSessionManager) Remove(sessionId string) error {
+	manager.mutex.Lock()
+	if _, exists := manager.sessions[sessionId]; !exists {
+		manager.mutex.Unlock()
+		return fmt.Errorf("diff session not found: %s", sessionId)
+	}
+	manager.remove(sessionId)
+	manager.mutex.Unlock()
+
+	manager.deleteSessionFiles(sessionId)
+	return nil
+}
+
+// List returns every session, most recently used first
+func (manager *DiffSessionManager) List() []*DiffSession {
+	manager.mutex.Lock()
+	defer manager.mutex.Unlock()
+
+	sessions := manager.sessionsByLastAccessed()
+	for index, session := range sessions {
+		sessionCopy := *session
+		sessions[index] = &sessionCopy
+	}
+	return sessions
+}
+
+// StartJanitor periodically cleans up idle sessions and leftover session folders, until Stop is called
+func (manager *DiffSessionManager) StartJanitor() {
+	manager.mutex.Lock()
+	if manager.stopJanitor != nil {
+		manager.mutex.Unlock()
+		return
+	}
+	stopJanitor := make(chan struct{})
+	manager.stopJanitor = stopJanitor
+	manager.mutex.Unlock()
+
+	go func() {
+		ticker := time.NewTicker(diffSessionJanitorInterval)
+		defer ticker.Stop()
+
+		manager.Cleanup()
+		for {
+			select {
+			case <-ticker.C:
+				manager.Cleanup()
+			case <-stopJanitor:
+				return
+			}
+		}
+	}()
+}
+
+// Stop ends the janitor and saves the latest state of every session
+func (manager *DiffSessionManager) Stop() {
+	manager.mutex.Lock()
+	defer manager.mutex.Unlock()
+
+	if manager.stopJanitor != nil {
+		close(manager.stopJanitor)
+		manager.stopJanitor = nil
+	}
+
+	for _, session := range manager.sessions {
+		manager.saveSession(session)
+	}
+
+	for sessionId, reader := range manager.contentReaders {
+		reader.Close()
+		delete(manager.contentReaders, sessionId)
+	}
+}
+
+// Cleanup evicts the idle sessions, enforces the disk limit, and deletes session folders nobody tracks anymore
+func (manager *DiffSessionManager) Cleanup() {
+	manager.mutex.Lock()
+	removedSessionIds := []string{}
+	idleCutoff := time.Now().Add(-manager.maxIdle())
+	for sessionId, session := range manager.sessions {
+		if session.LastAccessed.Before(idleCutoff) {
+			logger.Log.Info("Cleaning up idle diff session: %s", sessionId)
+			manager.remove(sessionId)
+			removedSessionIds = append(removedSessionIds, sessionId)
+		}
+	}
+
+	removedSessionIds = append(removedSessionIds, manager.enforceDiskLimit("")...)
+	orphanedFolders := manager.findOrphanedFolders(idleCutoff)
+
+	for _, session := range manager.sessions {
+		manager.saveSession(session)
+	}
+	manager.mutex.Unlock()
+
+	// Deleting the folders can take a while, so the other sessions aren't blocked in the meantime
+	manager.deleteSessionFiles(removedSessionIds...)
+	for _, folderPath := range orphanedFolders {
+		logger.Log.Info("Cleaning up orphaned diff session folder: %s", filepath.Base(folderPath))
+		os.RemoveAll(folderPath)
+	}
+}
+
+// Evicts the least recently used sessions (except the one being kept) until the disk usage is under the limit.
+// Expects the mutex to be held, and returns the evicted sessions, whose files still have to be deleted
+func (manager *DiffSessionManager) enforceDiskLimit(keepSessionId string) []string {
+	maxDiskBytes := int64(manager.Settings.MaxDiskUsageMB) * 1024 * 1024
+	if maxDiskBytes <= 0 {
+		return nil
+	}
+
+	var totalSize int64
+	for _, session := range manager.sessions {
+		totalSize += session.DiskSize
+	}
+
+	evictedSessionIds := []string{}
+	sessions := manager.sessionsByLastAccessed()
+	for index := len(sessions) - 1; index >= 0 && totalSize > maxDiskBytes; index-- {
+		session := sessions[index]
+		if session.SessionId == keepSessionId || session.DiskSize == 0 {
+			continue
+		}
+
+		logger.Log.Info("Evicting diff session %s to stay under the %d MB disk limit", session.SessionId, manager.Settings.MaxDiskUsageMB)
+		totalSize -= session.DiskSize
+		manager.remove(session.SessionId)
+		evictedSessionIds = append(evictedSessionIds, session.SessionId)
+	}
+	return evictedSessionIds
+}
+
+// Session folders are only deleted once they're older than the idle limit, since another
+// GitWhale window could still be using them. Expects the mutex to be held
+func (manager *DiffSessionManager) findOrphanedFolders(cutoff time.Time) []string {
+	diffDir := filepath.Join(os.TempDir(), "gitwhale-diff")
+	entries, err := os.ReadDir(diffDir)
+	if err != nil {
+		return nil
+	}
+
+	orphanedFolders := []string{}
+	for _, entry := range entries {
+		if _, exists := manager.sessions[entry.Name()]; exists || !entry.IsDir() {
+			continue
+		}
+
+		info, err := entry.Info()
+		if err != nil || !info.ModTime().Before(cutoff) {
+			continue
+		}
+		orphanedFolders = append(orphanedFolders, filepath.Join(diffDir, entry.Name()))
+	}
+	return orphanedFolders
+}
+
+// Stops tracking the session. Expects the mutex to be held, its files are deleted by deleteSessionFiles afterwards
+func (manager *DiffSessionManager) remove(sessionId string) {
+	delete(manager.sessions, sessionId)
+
+	// Closing waits for a read that's in progress, so it doesn't hold up the other sessions
+	if reader, exists := manager.contentReaders[sessionId]; exists {
+		delete(manager.contentReaders, sessionId)
+		go reader.Close()
+	}
+}
+
+// Deletes the folders and saved manifests of sessions that aren't tracked anymore, without holding the mutex
+func (manager *DiffSessionManager) deleteSessionFiles(sessionIds ...string) {
+	for _, sessionId := range sessionIds {
+		CleanupDiffSession(sessionId)
+
+		if manager.manifestFolder != "" {
+			if err := os.Remove(manager.manifestPath(sessionId)); err != nil && !os.IsNotExist(err) {
+				logger.Log.Warning("Failed to delete the saved manifest of diff session %s: %v", sessionId, err)
+			}
+		}
+	}
+}
+
+func (manager *DiffSessionManager) sessionsByLastAccessed() []*DiffSession {
+	sessions := make([]*DiffSession, 0, len(manager.sessions))
+	for _, session := range manager.sessions {
+		sessions = append(sessions, session)
+	}
+
+	slices.SortFunc(sessions, func(a, b *DiffSession) int {
+		return b.LastAccessed.Compare(a.LastAccessed)
+	})
+	return sessions
+}
+
+func (manager *DiffSessionManager) maxIdle() time.Duration {
+	if manager.Settings.MaxIdleHours <= 0 {
+		return 24 * time.Hour
+	}
+	return time.Duration(manager.Settings.MaxIdleHours) * time.Hour
+}
+
+func (manager *DiffSessionManager) manifestPath(sessionId string) string {
+	return filepath.Join(manager.manifestFolder, sessionId+".json")
+}
+
+// The directory tree isn't saved, since it can be rebuilt from the session's files or manifest. Sessions of the
+// working tree aren't saved at all, since restoring them would show whatever the working tree has by then
+func (manager *DiffSessionManager) saveSession(session *DiffSession) {
+	if manager.manifestFolder == "" || session.Options.comparesWorkingTree() {
+		return
+	}
+
+	savedSession := *session
+	savedSession.DirectoryData = nil
+	if err := lib.SaveAsJSON(manager.manifestPath(session.SessionId), savedSession); err != nil {
+		logger.Log.Warning("Failed to save the manifest of diff session %s: %v", session.SessionId, err)
+	}
+}
+
+func (manager *DiffSessionManager) loadSavedSessions() {
+	if manager.manifestFolder == "" {
+		return
+	}
+
+	entries, err := os.ReadDir(manager.manifestFolder)
+	if err != nil {
+		logger.Log.Warning("Failed to read the saved diff sessions: %v", err)
+		return
+	}
+
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".json") {
+			continue
+		}
+
+		manifestPath := filepath.Join(manager.manifestFolder, entry.Name())
+		session, err := lib.LoadJSON[*DiffSession](manifestPath)
+		if err != nil || session == nil || session.SessionId == "" {
+			logger.Log.Warning("Deleting unreadable diff session manifest %s: %v", entry.Name(), err)
+			os.Remove(manifestPath)
+			continue
+		}
+
+		// Saved before working tree sessions stopped being saved
+		if session.Options.comparesWorkingTree() {
+			logger.Log.Info("Dropping saved diff session %s, since it compared the working tree", session.SessionId)
+			os.Remove(manifestPath)
+			CleanupDiffSession(session.SessionId)
+			continue
+		}
+
+		manager.sessions[session.SessionId] = session
+	}
+
+	logger.Log.Info("Restored %d saved diff sessions", len(manager.sessions))
+}
+
+// Rebuilds the directory tree of a restored session, rewriting its files if they were deleted in the meantime
+func restoreDiffSession(session *DiffSession) error {
+	if session.IsLazy {
+		session.DirectoryData = buildManifestDirectory(session.Manifest)
+		return nil
+	}
+
+	if !lib.DirExists(session.LeftPath) || !lib.DirExists(session.RightPath) {
+		logger.Log.Info("Rewriting the files of restored diff session: %s", session.SessionId)
+
+		leftPath, rightPath, err := createDiffDestinations(session.SessionId)
+		if err != nil {
+			return err
+		}
+
+		changesFound, err := materializeDiff(context.Background(), session.Options, leftPath, rightPath)
+		if err != nil {
+			return err
+		}
+		if !changesFound {
+			return fmt.Errorf("the diff no longer has any changes")
+		}
+
+		session.LeftPath = leftPath
+		session.RightPath = rightPath
+	}
+
+	session.DirectoryData = GetDiffSessionDirectory(session)
+	if session.DirectoryData == nil {
+		return fmt.Errorf("failed to load directory structure for diff session")
+	}
+	return nil
+}
+
+func getDiffSessionFolder(sessionId string) string {
+	return filepath.Join(os.TempDir(), "gitwhale-diff", sessionId)
+}
+
+func getFolderSize(folderPath string) int64 {
+	var size int64
+	filepath.WalkDir(folderPath, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil || entry.IsDir() {
+			return nil
+		}
+		if info, err := entry.Info(); err == nil {
+			size += info.Size()
+		}
+		return nil
+	})
+	return size
+}
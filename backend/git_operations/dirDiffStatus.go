@@ -0,0 +1,407 @@
+package git_operations
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"io"
+	"os"
+	"runtime"
+	"slices"
+	"strings"
+	"sync"
+)
+
+type FileChangeStatus string
+
+const (
+	FileAdded     FileChangeStatus = "added"
+	FileDeleted   FileChangeStatus = "deleted"
+	FileModified  FileChangeStatus = "modified"
+	FileRenamed   FileChangeStatus = "renamed"
+	FileUnchanged FileChangeStatus = "unchanged"
+)
+
+// DirectoryStats rolls up the changes of every file inside a directory, including its subdirectories
+type DirectoryStats struct {
+	Added     int
+	Deleted   int
+	Modified  int
+	Renamed   int
+	Unchanged int
+	Binary    int
+
+	LinesAdded   int
+	LinesDeleted int
+}
+
+const (
+	// Files bigger than this are still compared, but their lines aren't counted
+	maxLineStatsFileBytes = 8 * 1024 * 1024
+
+	// Past this many line edits, the remaining lines are counted as fully replaced
+	maxLineDiffEdits = 5000
+)
+
+// annotateDirDiff fills in the change status, sizes and line stats of every file, pairs up renamed files,
+// and rolls the counts up into each directory
+func annotateDirDiff(rootDir *Directory, hideUnchanged bool) {
+	parentDirs := make(map[*FileInfo]*Directory)
+	collectDirDiffFiles(rootDir, parentDirs)
+
+	files := make(chan *FileInfo)
+	var wg sync.WaitGroup
+	for range min(runtime.NumCPU(), len(parentDirs)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for file := range files {
+				annotateDirDiffFile(file)
+			}
+		}()
+	}
+	for file := range parentDirs {
+		files <- file
+	}
+	close(files)
+	wg.Wait()
+
+	detectRenamedFiles(rootDir, parentDirs)
+
+	if hideUnchanged {
+		removeUnchangedFiles(rootDir)
+	}
+
+	rollUpDirectoryStats(rootDir)
+}
+
+func collectDirDiffFiles(directory *Directory, parentDirs map[*FileInfo]*Directory) {
+	for _, file := range directory.Files {
+		parentDirs[file] = directory
+	}
+	for _, subDir := range directory.SubDirs {
+		collectDirDiffFiles(subDir, parentDirs)
+	}
+}
+
+func annotateDirDiffFile(file *FileInfo) {
+	file.LeftSize = getFileSize(file.LeftDirAbsPath)
+	file.RightSize = getFileSize(file.RightDirAbsPath)
+
+	switch {
+	case file.LeftSize < 0 && file.RightSize < 0:
+		file.Status = FileUnchanged
+		return
+	case file.LeftSize < 0:
+		file.Status = FileAdded
+	case file.RightSize < 0:
+		file.Status = FileDeleted
+	case file.LeftSize != file.RightSize:
+		file.Status = FileModified
+	default:
+		// Same size, so the contents decide it
+		leftHash, leftErr := hashFile(file.LeftDirAbsPath)
+		rightHash, rightErr := hashFile(file.RightDirAbsPath)
+		if leftErr == nil && rightErr == nil && leftHash == rightHash {
+			file.Status = FileUnchanged
+		} else {
+			file.Status = FileModified
+		}
+	}
+
+	countLines := file.Status != FileUnchanged
+	leftContent := readLineStatsContent(file.LeftDirAbsPath, file.LeftSize, countLines)
+	rightContent := readLineStatsContent(file.RightDirAbsPath, file.RightSize, countLines)
+	file.IsBinary = isBinaryContent(leftContent) || isBinaryContent(rightContent)
+
+	if file.IsBinary || file.Status == FileUnchanged {
+		return
+	}
+	if file.LeftSize > maxLineStatsFileBytes || file.RightSize > maxLineStatsFileBytes {
+		return
+	}
+
+	file.LinesAdded, file.LinesDeleted = countLineChanges(leftContent, rightContent)
+}
+
+// Pairs up deleted and added files with identical contents as renames. The added file takes over the
+// left side of the deleted one, which is then removed from the tree, along with the directories it leaves empty
+func detectRenamedFiles(rootDir *Directory, parentDirs map[*FileInfo]*Directory) {
+	deletedByHash := make(map[[sha256.Size]byte][]*FileInfo)
+	for _, file := range sortedFilesWithStatus(parentDirs, FileDeleted) {
+		if file.LeftSize == 0 {
+			continue
+		}
+		if hash, err := hashFile(file.LeftDirAbsPath); err == nil {
+			deletedByHash[hash] = append(deletedByHash[hash], file)
+		}
+	}
+
+	if len(deletedByHash) == 0 {
+		return
+	}
+
+	emptiedDirs := make(map[*Directory]bool)
+	for _, file := range sortedFilesWithStatus(parentDirs, FileAdded) {
+		if file.RightSize == 0 {
+			continue
+		}
+
+		hash, err := hashFile(file.RightDirAbsPath)
+		if err != nil || len(deletedByHash[hash]) == 0 {
+			continue
+		}
+
+		// Files that kept their name are the most likely to have been moved
+		candidates := deletedByHash[hash]
+		matchIndex := max(0, slices.IndexFunc(candidates, func(candidate *FileInfo) bool {
+			return candidate.Name == file.Name
+		}))
+		deletedFile := candidates[matchIndex]
+		deletedByHash[hash] = slices.Delete(candidates, matchIndex, matchIndex+1)
+
+		file.Status = FileRenamed
+		file.OldPath = deletedFile.Path
+		file.LeftDirAbsPath = deletedFile.LeftDirAbsPath
+		file.LeftSize = deletedFile.LeftSize
+		file.LinesAdded = 0
+		file.LinesDeleted = 0
+
+		parentDir := parentDirs[deletedFile]
+		parentDir.Files = removeFileInfo(parentDir.Files, deletedFile)
+		if len(parentDir.Files) == 0 {
+			emptiedDirs[parentDir] = true
+		}
+	}
+
+	removeEmptiedDirectories(rootDir, emptiedDirs)
+}
+
+// Map iteration order is random, so the files are sorted by path to always pair them up the same way
+func sortedFilesWithStatus(parentDirs map[*FileInfo]*Directory, status FileChangeStatus) []*FileInfo {
+	files := []*FileInfo{}
+	for file := range parentDirs {
+		if file.Status == status {
+			files = append(files, file)
+		}
+	}
+
+	slices.SortFunc(files, func(a, b *FileInfo) int {
+		return strings.Compare(a.LeftDirAbsPath+a.RightDirAbsPath, b.LeftDirAbsPath+b.RightDirAbsPath)
+	})
+	return files
+}
+
+// Removes the directories that renames left without anything in them, and returns whether the directory itself
+// should be removed. Directories that were already empty on disk are kept
+func removeEmptiedDirectories(directory *Directory, emptiedDirs map[*Directory]bool) bool {
+	remainingSubDirs := make([]*Directory, 0, len(directory.SubDirs))
+	removedSubDir := false
+	for _, subDir := range directory.SubDirs {
+		if removeEmptiedDirectories(subDir, emptiedDirs) {
+			removedSubDir = true
+			continue
+		}
+		remainingSubDirs = append(remainingSubDirs, subDir)
+	}
+	directory.SubDirs = remainingSubDirs
+
+	isEmpty := len(directory.Files) == 0 && len(directory.SubDirs) == 0
+	return isEmpty && (emptiedDirs[directory] || removedSubDir)
+}
+
+// Removes the unchanged files, and any directories that end up empty
+func removeUnchangedFiles(directory *Directory) {
+	changedFiles := make([]*FileInfo, 0, len(directory.Files))
+	for _, file := range directory.Files {
+		if file.Status != FileUnchanged {
+			changedFiles = append(changedFiles, file)
+		}
+	}
+	directory.Files = changedFiles
+
+	nonEmptySubDirs := make([]*Directory, 0, len(directory.SubDirs))
+	for _, subDir := range directory.SubDirs {
+		removeUnchangedFiles(subDir)
+		if len(subDir.Files) > 0 || len(subDir.SubDirs) > 0 {
+			nonEmptySubDirs = append(nonEmptySubDirs, subDir)
+		}
+	}
+	directory.SubDirs = nonEmptySubDirs
+}
+
+func rollUpDirectoryStats(directory *Directory) DirectoryStats {
+	stats := DirectoryStats{}
+	for _, file := range directory.Files {
+		switch file.Status {
+		case FileAdded:
+			stats.Added++
+		case FileDeleted:
+			stats.Deleted++
+		case FileModified:
+			stats.Modified++
+		case FileRenamed:
+			stats.Renamed++
+		case FileUnchanged:
+			stats.Unchanged++
+		}
+
+		if file.IsBinary {
+			stats.Binary++
+		}
+		stats.LinesAdded += file.LinesAdded
+		stats.LinesDeleted += file.LinesDeleted
+	}
+
+	for _, subDir := range directory.SubDirs {
+		subStats := rollUpDirectoryStats(subDir)
+		stats.Added += subStats.Added
+		stats.Deleted += subStats.Deleted
+		stats.Modified += subStats.Modified
+		stats.Renamed += subStats.Renamed
+		stats.Unchanged += subStats.Unchanged
+		stats.Binary += subStats.Binary
+		stats.LinesAdded += subStats.LinesAdded
+		stats.LinesDeleted += subStats.LinesDeleted
+	}
+
+	directory.Stats = stats
+	return stats
+}
+
+func removeFileInfo(files []*FileInfo, fileToRemove *FileInfo) []*FileInfo {
+	for index, file := range files {
+		if file == fileToRemove {
+			return append(files[:index], files[index+1:]...)
+		}
+	}
+	return files
+}
+
+// Returns -1 when there's no file at the path
+func getFileSize(path string) int64 {
+	if path == "" {
+		return -1
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return -1
+	}
+	return info.Size()
+}
+
+func hashFile(path string) ([sha256.Size]byte, error) {
+	var hash [sha256.Size]byte
+
+	file, err := os.Open(path)
+	if err != nil {
+		return hash, err
+	}
+	defer file.Close()
+
+	hasher := sha256.New()
+	if _, err := io.Copy(hasher, file); err != nil {
+		return hash, err
+	}
+
+	copy(hash[:], hasher.Sum(nil))
+	return hash, nil
+}
+
+// Reads the whole file when its lines will be counted, or just enough of it to detect binary files otherwise
+func readLineStatsContent(path string, size int64, countLines bool) []byte {
+	if size <= 0 {
+		return []byte{}
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return []byte{}
+	}
+	defer file.Close()
+
+	limit := size
+	if !countLines || size > maxLineStatsFileBytes {
+		limit = binaryDetectionBytes
+	}
+
+	content, err := io.ReadAll(io.LimitReader(file, limit))
+	if err != nil {
+		return []byte{}
+	}
+	return content
+}
+
+// countLineChanges returns how many lines were added and deleted between the two versions of a file
+func countLineChanges(oldContent, newContent []byte) (int, int) {
+	oldLines := splitContentLines(oldContent)
+	newLines := splitContentLines(newContent)
+
+	// Matching lines at the start and end don't need to go through the diff
+	for len(oldLines) > 0 && len(newLines) > 0 && bytes.Equal(oldLines[0], newLines[0]) {
+		oldLines, newLines = oldLines[1:], newLines[1:]
+	}
+	for len(oldLines) > 0 && len(newLines) > 0 && bytes.Equal(oldLines[len(oldLines)-1], newLines[len(newLines)-1]) {
+		oldLines, newLines = oldLines[:len(oldLines)-1], newLines[:len(newLines)-1]
+	}
+
+	commonLines := longestCommonLineCount(oldLines, newLines)
+	return len(newLines) - commonLines, len(oldLines) - commonLines
+}
+
+func splitContentLines(content []byte) [][]byte {
+	if len(content) == 0 {
+		return [][]byte{}
+	}
+	return bytes.Split(bytes.TrimSuffix(content, []byte("\n")), []byte("\n"))
+}
+
+// longestCommonLineCount uses Myers' algorithm to find the number of lines both versions have in common.
+// Only the length is needed, so it only keeps the furthest reaching paths instead of the full edit script
+func longestCommonLineCount(oldLines, newLines [][]byte) int {
+	// Compare line ids instead of the lines themselves
+	lineIds := make(map[string]int)
+	toIds := func(lines [][]byte) []int {
+		ids := make([]int, len(lines))
+		for index, line := range lines {
+			id, exists := lineIds[string(line)]
+			if !exists {
+				id = len(lineIds)
+				lineIds[string(line)] = id
+			}
+			ids[index] = id
+		}
+		return ids
+	}
+	oldIds, newIds := toIds(oldLines), toIds(newLines)
+
+	n, m := len(oldIds), len(newIds)
+	maxEdits := min(n+m, maxLineDiffEdits)
+	offset := maxEdits + 1
+	furthestX := make([]int, 2*maxEdits+3)
+
+	for edits := 0; edits <= maxEdits; edits++ {
+		for diagonal := -edits; diagonal <= edits; diagonal += 2 {
+			var x int
+			if diagonal == -edits || (diagonal != edits && furthestX[offset+diagonal-1] < furthestX[offset+diagonal+1]) {
+				x = furthestX[offset+diagonal+1] // Insertion
+			} else {
+				x = furthestX[offset+diagonal-1] + 1 // Deletion
+			}
+
+			y := x - diagonal
+			for x < n && y < m && oldIds[x] == newIds[y] {
+				x++
+				y++
+			}
+			furthestX[offset+diagonal] = x
+
+			if x >= n && y >= m {
+				return (n + m - edits) / 2
+			}
+		}
+	}
+
+	// Too different to be worth finishing, so treat the remaining lines as replaced
+	return 0
+}
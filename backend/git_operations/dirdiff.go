@@ -14,6 +14,7 @@ type Directory struct {
 	Name    string
 	Files   []*FileInfo  // Files inside this directory
 	SubDirs []*Directory // Subdirectories inside this directory
+	Stats   DirectoryStats
 }
 
 // FileInfo holds information about a file.
@@ -23,11 +24,33 @@ type FileInfo struct {
 	Extension       string
 	LeftDirAbsPath  string
 	RightDirAbsPath string
+
+	Status   FileChangeStatus
+	OldPath  string // The left side's path, when the file was renamed
+	IsBinary bool
+
+	// -1 when the file doesn't exist on that side
+	LeftSize  int64
+	RightSize int64
+
+	LinesAdded   int
+	LinesDeleted int
 }
 
 // Given a left side and a right side path (dir or filepath), the tag will figure out all the files
-// we need to show diffs for (only runs if the program was opened with the --diff-tool flag)
-func ReadDiffs(leftPath, rightPath string) *Directory {
+// we need to show diffs for (only runs if the program was opened with the --diff-tool flag).
+// Files that are identical on both sides are left out when hideUnchanged is set
+func ReadDiffs(leftPath, rightPath string, hideUnchanged bool) *Directory {
+	rootDir := readDiffStructure(leftPath, rightPath)
+	if rootDir == nil {
+		return nil
+	}
+
+	annotateDirDiff(rootDir, hideUnchanged)
+	return rootDir
+}
+
+func readDiffStructure(leftPath, rightPath string) *Directory {
 
 	if leftPath == "" || rightPath == "" {
 		return nil
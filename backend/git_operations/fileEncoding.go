@@ -0,0 +1,57 @@
+package git_operations
+
+import (
+	"bytes"
+	"encoding/binary"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+type FileEncoding string
+
+const (
+	EncodingUtf8    FileEncoding = "utf-8"
+	EncodingUtf8Bom FileEncoding = "utf-8-bom"
+	EncodingUtf16LE FileEncoding = "utf-16le"
+	EncodingUtf16BE FileEncoding = "utf-16be"
+	EncodingLatin1  FileEncoding = "latin1" // Anything that isn't valid UTF-8 but has no NUL bytes
+	EncodingBinary  FileEncoding = "binary"
+)
+
+var (
+	utf8Bom    = []byte{0xEF, 0xBB, 0xBF}
+	utf16LEBom = []byte{0xFF, 0xFE}
+	utf16BEBom = []byte{0xFE, 0xFF}
+)
+
+// decodeFileContent detects the encoding of a file and converts it to a UTF-8 string that can be shown in
+// the diff editor. The byte order mark is dropped. Binary files are returned with an empty string
+func decodeFileContent(content []byte) (string, FileEncoding) {
+	switch {
+	case bytes.HasPrefix(content, utf8Bom):
+		return string(content[len(utf8Bom):]), EncodingUtf8Bom
+	case bytes.HasPrefix(content, utf16LEBom) && len(content)%2 == 0:
+		return decodeUtf16(content[len(utf16LEBom):], binary.LittleEndian), EncodingUtf16LE
+	case bytes.HasPrefix(content, utf16BEBom) && len(content)%2 == 0:
+		return decodeUtf16(content[len(utf16BEBom):], binary.BigEndian), EncodingUtf16BE
+	case isBinaryContent(content):
+		return "", EncodingBinary
+	case utf8.Valid(content):
+		return string(content), EncodingUtf8
+	}
+
+	// Every byte maps to the unicode code point with the same value in Latin-1
+	runes := make([]rune, len(content))
+	for i, b := range content {
+		runes[i] = rune(b)
+	}
+	return string(runes), EncodingLatin1
+}
+
+func decodeUtf16(content []byte, byteOrder binary.ByteOrder) string {
+	units := make([]uint16, len(content)/2)
+	for i := range units {
+		units[i] = byteOrder.Uint16(content[i*2:])
+	}
+	return string(utf16.Decode(units))
+}
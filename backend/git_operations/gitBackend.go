@@ -0,0 +1,93 @@
+package git_operations
+
+import (
+	"context"
+	"fmt"
+	"gitwhale/backend/logger"
+	"sync"
+)
+
+// GitBackend covers the read paths that are called the most (loading the log, refs, status and file contents),
+// so they can be served without starting a git process every time. Anything that writes to the repo always
+// goes through the git executable
+type GitBackend interface {
+	ReadRefs(ctx context.Context, repoPath string) ([]GitRef, error)
+	ReadLog(ctx context.Context, repoPath string, options GitLogOptions) ([]GitLogCommitInfo, error)
+
+	// ReadCommit loads a commit's metadata and message. The changed files and stats are left empty
+	ReadCommit(ctx context.Context, repoPath, commitHash string) (*DetailedCommitInfo, error)
+
+	ReadStatus(ctx context.Context, repoPath string) (*GitStatus, error)
+
+	// ReadBlob reads a file at a revision, or from the index when the ref is "index", "staged" or a stage like ":2".
+	// The bool is false when the file doesn't exist there
+	ReadBlob(repoPath, ref, path string) ([]byte, bool, error)
+}
+
+type GitBackendType string
+
+const (
+	GitBackendCli   GitBackendType = "cli"
+	GitBackendGoGit GitBackendType = "goGit"
+)
+
+// Validate allows an empty type, which older configs have, and which means the CLI backend
+func (backendType GitBackendType) Validate() error {
+	switch backendType {
+	case "", GitBackendCli, GitBackendGoGit:
+		return nil
+	default:
+		return fmt.Errorf("unknown git backend: %s", backendType)
+	}
+}
+
+var (
+	activeGitBackend      GitBackend = &CliGitBackend{}
+	activeGitBackendMutex sync.RWMutex
+)
+
+// SetGitBackend switches the backend used by the read operations, e.g. after the settings change
+func SetGitBackend(backendType GitBackendType) {
+	var backend GitBackend
+	switch backendType {
+	case GitBackendGoGit:
+		backend = &GoGitBackend{}
+	default:
+		backend = &CliGitBackend{}
+		clearGoGitRepositories()
+	}
+
+	activeGitBackendMutex.Lock()
+	defer activeGitBackendMutex.Unlock()
+	activeGitBackend = backend
+	logger.Log.Info("Using the %T git backend", backend)
+}
+
+func currentGitBackend() GitBackend {
+	activeGitBackendMutex.RLock()
+	defer activeGitBackendMutex.RUnlock()
+	return activeGitBackend
+}
+
+// CliGitBackend runs the git executable for every read
+type CliGitBackend struct{}
+
+func (backend *CliGitBackend) ReadRefs(ctx context.Context, repoPath string) ([]GitRef, error) {
+	return readRefsWithCli(ctx, repoPath)
+}
+
+func (backend *CliGitBackend) ReadLog(ctx context.Context, repoPath string, options GitLogOptions) ([]GitLogCommitInfo, error) {
+	return readLogWithCli(ctx, repoPath, options)
+}
+
+func (backend *CliGitBackend) ReadCommit(ctx context.Context, repoPath, commitHash string) (*DetailedCommitInfo, error) {
+	return readCommitWithCli(ctx, repoPath, commitHash)
+}
+
+func (backend *CliGitBackend) ReadStatus(ctx context.Context, repoPath string) (*GitStatus, error) {
+	return readStatusWithCli(ctx, repoPath)
+}
+
+func (backend *CliGitBackend) ReadBlob(repoPath, ref, path string) ([]byte, bool, error) {
+	return readBlobWithCli(repoPath, ref, path)
+}
@@ -0,0 +1,628 @@
+package git_operations
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"gitwhale/backend/logger"
+	"io"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/format/index"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-git/go-git/v5/storage/filesystem"
+)
+
+// GoGitBackend reads the repo in-process with go-git, which avoids starting a process for every read (slow on
+// Windows especially). go-git doesn't support everything git does, so whenever it fails (an unsupported revision
+// syntax, a shallow clone, a SHA-256 repo...) the read is retried with the CLI backend. The status always comes from
+// the CLI, since go-git's worktree status ignores the index stat cache and is much slower than git's
+type GoGitBackend struct {
+	cli CliGitBackend
+}
+
+func openGoGitRepository(repoPath string) (*git.Repository, error) {
+	return git.PlainOpenWithOptions(repoPath, &git.PlainOpenOptions{
+		DetectDotGit:          true,
+		EnableDotGitCommonDir: true,
+	})
+}
+
+// goGitRepository is an opened repo that is kept for the rest of the session, since opening one reads its config and
+// the indexes of its packfiles again, which adds up when the log is paged through
+type goGitRepository struct {
+	mutex          sync.Mutex // go-git repos aren't safe to read from concurrently
+	repo           *git.Repository
+	packDirModTime time.Time
+}
+
+var (
+	goGitRepositories      = make(map[string]*goGitRepository)
+	goGitRepositoriesMutex sync.Mutex
+)
+
+// withGoGitRepository runs read with the cached repo of the given path, opening it on first use
+func withGoGitRepository(repoPath string, read func(repo *git.Repository) error) error {
+	goGitRepositoriesMutex.Lock()
+	cached, ok := goGitRepositories[repoPath]
+	if !ok {
+		cached = &goGitRepository{}
+		goGitRepositories[repoPath] = cached
+	}
+	goGitRepositoriesMutex.Unlock()
+
+	cached.mutex.Lock()
+	defer cached.mutex.Unlock()
+
+	if cached.repo == nil {
+		repo, err := openGoGitRepository(repoPath)
+		if err != nil {
+			return err
+		}
+		cached.repo = repo
+		cached.packDirModTime = time.Time{}
+	}
+	cached.reindexIfPacksChanged()
+
+	err := read(cached.repo)
+	if err != nil {
+		// Opened again next time, in case the repo was moved or replaced
+		cached.repo = nil
+	}
+	return err
+}
+
+// Drops the cached repos, once the go-git backend isn't used anymore
+func clearGoGitRepositories() {
+	goGitRepositoriesMutex.Lock()
+	defer goGitRepositoriesMutex.Unlock()
+	goGitRepositories = make(map[string]*goGitRepository)
+}
+
+// go-git reads the list of packfiles once, so it has to be told when a fetch or gc adds or removes some.
+// Loose objects and refs are always read from disk
+func (cached *goGitRepository) reindexIfPacksChanged() {
+	storage, ok := cached.repo.Storer.(*filesystem.Storage)
+	if !ok {
+		return
+	}
+
+	info, err := storage.Filesystem().Stat("objects/pack")
+	if err != nil {
+		return
+	}
+
+	if !info.ModTime().Equal(cached.packDirModTime) {
+		if !cached.packDirModTime.IsZero() {
+			storage.Reindex()
+		}
+		cached.packDirModTime = info.ModTime()
+	}
+}
+
+func logGoGitFallback(operation, repoPath string, err error) {
+	logger.Log.Debug("go-git couldn't %s in %s, falling back to the git CLI: %v", operation, repoPath, err)
+}
+
+func (backend *GoGitBackend) ReadRefs(ctx context.Context, repoPath string) ([]GitRef, error) {
+	refs, err := readRefsWithGoGit(repoPath)
+	if err != nil {
+		logGoGitFallback("read the refs", repoPath, err)
+		return backend.cli.ReadRefs(ctx, repoPath)
+	}
+	return refs, nil
+}
+
+func (backend *GoGitBackend) ReadLog(ctx context.Context, repoPath string, options GitLogOptions) ([]GitLogCommitInfo, error) {
+	logs, err := readLogWithGoGit(repoPath, options)
+	if err != nil {
+		logGoGitFallback("read the log", repoPath, err)
+		return backend.cli.ReadLog(ctx, repoPath, options)
+	}
+	return logs, nil
+}
+
+func (backend *GoGitBackend) ReadCommit(ctx context.Context, repoPath, commitHash string) (*DetailedCommitInfo, error) {
+	commit, err := readCommitWithGoGit(repoPath, commitHash)
+	if err != nil {
+		logGoGitFallback("read commit "+commitHash, repoPath, err)
+		return backend.cli.ReadCommit(ctx, repoPath, commitHash)
+	}
+	return commit, nil
+}
+
+func (backend *GoGitBackend) ReadStatus(ctx context.Context, repoPath string) (*GitStatus, error) {
+	return backend.cli.ReadStatus(ctx, repoPath)
+}
+
+func (backend *GoGitBackend) ReadBlob(repoPath, ref, path string) ([]byte, bool, error) {
+	content, found, err := readBlobWithGoGit(repoPath, ref, path)
+	if err != nil {
+		logGoGitFallback(fmt.Sprintf("read %s:%s", ref, path), repoPath, err)
+		return backend.cli.ReadBlob(repoPath, ref, path)
+	}
+	return content, found, nil
+}
+
+// Lists the same refs as `git show-ref`, sorted by their full name
+func readRefsWithGoGit(repoPath string) ([]GitRef, error) {
+	var refs []GitRef
+	err := withGoGitRepository(repoPath, func(repo *git.Repository) error {
+		references, err := readResolvedReferences(repo)
+		if err != nil {
+			return err
+		}
+
+		refs = []GitRef{}
+		for _, reference := range references {
+			if gitRef, ok := newGitRef(reference.Name().String(), reference.Hash().String()); ok {
+				refs = append(refs, gitRef)
+			}
+		}
+		return nil
+	})
+	return refs, err
+}
+
+// Returns every ref except HEAD with symbolic refs (like origin/HEAD) resolved to a hash, sorted by name
+func readResolvedReferences(repo *git.Repository) ([]*plumbing.Reference, error) {
+	iterator, err := repo.References()
+	if err != nil {
+		return nil, err
+	}
+
+	references := []*plumbing.Reference{}
+	err = iterator.ForEach(func(reference *plumbing.Reference) error {
+		if reference.Name() == plumbing.HEAD {
+			return nil
+		}
+
+		if reference.Type() == plumbing.SymbolicReference {
+			resolved, err := repo.Reference(reference.Name(), true)
+			if err != nil {
+				// Like show-ref, dangling symbolic refs are left out
+				return nil
+			}
+			reference = plumbing.NewHashReference(reference.Name(), resolved.Hash())
+		}
+
+		references = append(references, reference)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(references, func(i, j int) bool {
+		return references[i].Name() < references[j].Name()
+	})
+	return references, nil
+}
+
+// Builds the decorations of every commit, formatted like git's %D. fullNames matches --decorate=full
+func readGoGitDecorations(repo *git.Repository, fullNames bool) (map[plumbing.Hash][]string, error) {
+	references, err := readResolvedReferences(repo)
+	if err != nil {
+		return nil, err
+	}
+
+	refName := func(name plumbing.ReferenceName) string {
+		if fullNames {
+			return name.String()
+		}
+		return name.Short()
+	}
+
+	// git lists the decorations of a commit in reverse order of their names
+	decorations := make(map[plumbing.Hash][]string)
+	for i := len(references) - 1; i >= 0; i-- {
+		reference := references[i]
+		hash := reference.Hash()
+		decoration := refName(reference.Name())
+
+		if reference.Name().IsTag() {
+			decoration = "tag: " + decoration
+
+			// Annotated tags decorate the commit they point to
+			if tag, err := repo.TagObject(hash); err == nil {
+				if commit, err := tag.Commit(); err == nil {
+					hash = commit.Hash
+				}
+			}
+		}
+
+		decorations[hash] = append(decorations[hash], decoration)
+	}
+
+	head, err := repo.Reference(plumbing.HEAD, false)
+	if err != nil {
+		// A repo without any commits has nothing to decorate
+		return decorations, nil
+	}
+
+	if head.Type() == plumbing.HashReference {
+		decorations[head.Hash()] = append([]string{"HEAD"}, decorations[head.Hash()]...)
+		return decorations, nil
+	}
+
+	branch, err := repo.Reference(head.Target(), false)
+	if err != nil {
+		return decorations, nil
+	}
+
+	// A checked out branch is shown as "HEAD -> branch" instead of being listed on its own
+	branchName := refName(branch.Name())
+	otherDecorations := []string{"HEAD -> " + branchName}
+	for _, decoration := range decorations[branch.Hash()] {
+		if decoration != branchName {
+			otherDecorations = append(otherDecorations, decoration)
+		}
+	}
+	decorations[branch.Hash()] = otherDecorations
+
+	return decorations, nil
+}
+
+func readLogWithGoGit(repoPath string, options GitLogOptions) ([]GitLogCommitInfo, error) {
+	// git simplifies the history of a path in ways that go-git's path filter doesn't match
+	if len(options.Paths) > 0 {
+		return nil, errors.New("go-git doesn't filter the log by path like git does")
+	}
+
+	var logs []GitLogCommitInfo
+	err := withGoGitRepository(repoPath, func(repo *git.Repository) error {
+		var err error
+		logs, err = readGoGitLog(repo, options)
+		return err
+	})
+	return logs, err
+}
+
+func readGoGitLog(repo *git.Repository, options GitLogOptions) ([]GitLogCommitInfo, error) {
+	fromRef := "HEAD"
+	if options.FromRef != nil && *options.FromRef != "" {
+		fromRef = *options.FromRef
+	}
+
+	fromHash, err := repo.ResolveRevision(plumbing.Revision(fromRef))
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve %s: %w", fromRef, err)
+	}
+
+	commits, err := newGoGitTopoWalker(repo, *fromHash)
+	if err != nil {
+		return nil, err
+	}
+
+	decorations, err := readGoGitDecorations(repo, true)
+	if err != nil {
+		return nil, err
+	}
+
+	var searchRegex *regexp.Regexp
+	if options.SearchQuery != nil && *options.SearchQuery != "" {
+		searchRegex, err = regexp.Compile("(?im)" + *options.SearchQuery)
+		if err != nil {
+			// git uses POSIX regexes, so fall back to a plain text search for the ones Go can't parse
+			searchRegex = regexp.MustCompile("(?i)" + regexp.QuoteMeta(*options.SearchQuery))
+		}
+	}
+
+	commitsToSkip := 0
+	if options.CommitsToSkip != nil {
+		commitsToSkip = *options.CommitsToSkip
+	}
+
+	// Only as much of the history is walked as the page needs
+	logs := []GitLogCommitInfo{}
+	for options.CommitsToLoad == nil || len(logs) < *options.CommitsToLoad {
+		commit, err := commits.Next()
+		if err != nil {
+			return nil, err
+		}
+		if commit == nil {
+			break
+		}
+
+		if searchRegex != nil && !searchRegex.MatchString(commit.Message) {
+			continue
+		}
+
+		if commitsToSkip > 0 {
+			commitsToSkip--
+			continue
+		}
+
+		logs = append(logs, GitLogCommitInfo{
+			CommitHash:         commit.Hash.String(),
+			Username:           commit.Author.Name,
+			UserEmail:          commit.Author.Email,
+			CommitTimeStamp:    strconv.FormatInt(commit.Committer.When.Unix(), 10),
+			AuthoredTimeStamp:  strconv.FormatInt(commit.Author.When.Unix(), 10),
+			ParentCommitHashes: hashStrings(commit.ParentHashes),
+			Refs:               strings.Join(decorations[commit.Hash], ", "),
+			CommitMessage:      strings.Split(commit.Message, "\n"),
+			ShortStat:          readGoGitShortStat(commit),
+		})
+	}
+
+	return logs, nil
+}
+
+// goGitTopoWalker orders the commits reachable from the tip like `git log --topo-order`: children are shown before
+// their parents, and the commits of a merged branch are kept together instead of being interleaved by date.
+// The history is read newest first, and only as far as needed to know that every child of the next commit was shown,
+// so a page of the log doesn't walk the whole history. Like git without a commit-graph, this relies on commits being
+// newer than their parents, and the order can differ from git's where clocks were skewed
+type goGitTopoWalker struct {
+	byDate      object.CommitIter
+	exhausted   bool
+	oldestDate  time.Time
+	walked      map[plumbing.Hash]*object.Commit
+	childCounts map[plumbing.Hash]int // Children that were walked but not shown yet
+	shown       map[plumbing.Hash]bool
+	stack       []*object.Commit
+}
+
+func newGoGitTopoWalker(repo *git.Repository, tip plumbing.Hash) (*goGitTopoWalker, error) {
+	iterator, err := repo.Log(&git.LogOptions{From: tip, Order: git.LogOrderCommitterTime})
+	if err != nil {
+		return nil, err
+	}
+
+	walker := &goGitTopoWalker{
+		byDate:      iterator,
+		walked:      make(map[plumbing.Hash]*object.Commit),
+		childCounts: make(map[plumbing.Hash]int),
+		shown:       make(map[plumbing.Hash]bool),
+	}
+
+	if err := walker.walkPast(tip); err != nil {
+		return nil, err
+	}
+	if tipCommit, ok := walker.walked[tip]; ok {
+		walker.stack = append(walker.stack, tipCommit)
+	}
+	return walker, nil
+}
+
+// Reads commits by date until the given one was read, along with everything newer than it (which includes its children)
+func (walker *goGitTopoWalker) walkPast(hash plumbing.Hash) error {
+	for !walker.exhausted {
+		if commit, ok := walker.walked[hash]; ok && walker.oldestDate.Before(commit.Committer.When) {
+			return nil
+		}
+
+		commit, err := walker.byDate.Next()
+		if err == io.EOF {
+			walker.exhausted = true
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		walker.walked[commit.Hash] = commit
+		walker.oldestDate = commit.Committer.When
+		for _, parentHash := range commit.ParentHashes {
+			walker.childCounts[parentHash]++
+		}
+	}
+	return nil
+}
+
+// Next returns the next commit in topological order, or nil once every commit was shown
+func (walker *goGitTopoWalker) Next() (*object.Commit, error) {
+	for len(walker.stack) > 0 {
+		commit := walker.stack[len(walker.stack)-1]
+		walker.stack = walker.stack[:len(walker.stack)-1]
+
+		// With skewed clocks a commit can be ready more than once
+		if walker.shown[commit.Hash] {
+			continue
+		}
+		walker.shown[commit.Hash] = true
+
+		// A parent is ready once all of its children are shown. Like git, the stack makes the last parent of a merge
+		// (the merged branch) come out before the first one
+		for _, parentHash := range commit.ParentHashes {
+			if err := walker.walkPast(parentHash); err != nil {
+				return nil, err
+			}
+
+			parent, ok := walker.walked[parentHash]
+			if !ok {
+				continue
+			}
+
+			walker.childCounts[parentHash]--
+			if walker.childCounts[parentHash] == 0 {
+				walker.stack = append(walker.stack, parent)
+			}
+		}
+
+		return commit, nil
+	}
+
+	return nil, nil
+}
+
+// Formats the changes against the first parent like `git diff --shortstat`. go-git has its own diff implementation,
+// so the line counts can be off by a few from git's for larger changes
+func readGoGitShortStat(commit *object.Commit) string {
+	stats, err := commit.Stats()
+	if err != nil {
+		logger.Log.Warning("Failed to read the stats of commit %s: %v", commit.Hash, err)
+		return ""
+	}
+	if len(stats) == 0 {
+		return ""
+	}
+
+	insertions, deletions := 0, 0
+	for _, fileStat := range stats {
+		insertions += fileStat.Addition
+		deletions += fileStat.Deletion
+	}
+
+	shortStat := pluralize(len(stats), "file changed", "files changed")
+	if insertions > 0 || deletions == 0 {
+		shortStat += ", " + pluralize(insertions, "insertion(+)", "insertions(+)")
+	}
+	if deletions > 0 || insertions == 0 {
+		shortStat += ", " + pluralize(deletions, "deletion(-)", "deletions(-)")
+	}
+	return shortStat
+}
+
+func pluralize(count int, singular, plural string) string {
+	if count == 1 {
+		return fmt.Sprintf("%d %s", count, singular)
+	}
+	return fmt.Sprintf("%d %s", count, plural)
+}
+
+func hashStrings(hashes []plumbing.Hash) []string {
+	hashStrings := make([]string, len(hashes))
+	for i, hash := range hashes {
+		hashStrings[i] = hash.String()
+	}
+	return hashStrings
+}
+
+func readCommitWithGoGit(repoPath, commitHash string) (*DetailedCommitInfo, error) {
+	var commit *DetailedCommitInfo
+	err := withGoGitRepository(repoPath, func(repo *git.Repository) error {
+		var err error
+		commit, err = readGoGitCommit(repo, commitHash)
+		return err
+	})
+	return commit, err
+}
+
+func readGoGitCommit(repo *git.Repository, commitHash string) (*DetailedCommitInfo, error) {
+	hash, err := repo.ResolveRevision(plumbing.Revision(commitHash))
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve %s: %w", commitHash, err)
+	}
+
+	commit, err := repo.CommitObject(*hash)
+	if err != nil {
+		return nil, err
+	}
+
+	decorations, err := readGoGitDecorations(repo, false)
+	if err != nil {
+		return nil, err
+	}
+
+	return &DetailedCommitInfo{
+		CommitHash:         commit.Hash.String(),
+		Username:           commit.Author.Name,
+		UserEmail:          commit.Author.Email,
+		CommitterName:      commit.Committer.Name,
+		CommitterEmail:     commit.Committer.Email,
+		CommitTimeStamp:    strconv.FormatInt(commit.Committer.When.Unix(), 10),
+		AuthoredTimeStamp:  strconv.FormatInt(commit.Author.When.Unix(), 10),
+		ParentCommitHashes: hashStrings(commit.ParentHashes),
+		Refs:               strings.Join(decorations[commit.Hash], ", "),
+		CommitMessage:      strings.Split(commit.Message, "\n"), // Raw, like readCommitWithCli
+	}, nil
+}
+
+func readBlobWithGoGit(repoPath, ref, path string) ([]byte, bool, error) {
+	var content []byte
+	var found bool
+	err := withGoGitRepository(repoPath, func(repo *git.Repository) error {
+		var err error
+		content, found, err = readGoGitBlobAtRef(repo, ref, path)
+		return err
+	})
+	return content, found, err
+}
+
+func readGoGitBlobAtRef(repo *git.Repository, ref, path string) ([]byte, bool, error) {
+	if stage, ok := parseIndexStage(ref); ok {
+		return readGoGitIndexBlob(repo, stage, path)
+	}
+
+	hash, err := repo.ResolveRevision(plumbing.Revision(ref))
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to resolve %s: %w", ref, err)
+	}
+
+	commit, err := repo.CommitObject(*hash)
+	if err != nil {
+		return nil, false, err
+	}
+
+	file, err := commit.File(path)
+	if errors.Is(err, object.ErrFileNotFound) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+
+	return readGoGitBlob(&file.Blob)
+}
+
+// Parses the refs that read from the index: "index" and "staged" for the staged version, or ":1" to ":3" for the
+// versions of a conflicted file
+func parseIndexStage(ref string) (index.Stage, bool) {
+	switch ref {
+	case "index", "staged":
+		// Not index.Merged, which go-git defines as 1 even though merged entries are read with stage 0
+		return 0, true
+	case ":1":
+		return index.AncestorMode, true
+	case ":2":
+		return index.OurMode, true
+	case ":3":
+		return index.TheirMode, true
+	default:
+		return 0, false
+	}
+}
+
+func readGoGitIndexBlob(repo *git.Repository, stage index.Stage, path string) ([]byte, bool, error) {
+	repoIndex, err := repo.Storer.Index()
+	if err != nil {
+		return nil, false, err
+	}
+
+	for _, entry := range repoIndex.Entries {
+		if entry.Name != path || entry.Stage != stage {
+			continue
+		}
+
+		blob, err := repo.BlobObject(entry.Hash)
+		if err != nil {
+			return nil, false, err
+		}
+		return readGoGitBlob(blob)
+	}
+
+	return nil, false, nil
+}
+
+func readGoGitBlob(blob *object.Blob) ([]byte, bool, error) {
+	reader, err := blob.Reader()
+	if err != nil {
+		return nil, false, err
+	}
+	defer reader.Close()
+
+	content, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, false, err
+	}
+	return content, true, nil
+}
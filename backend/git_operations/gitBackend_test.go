@@ -0,0 +1,135 @@
+package git_operations
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// Builds a history of the given length with git fast-import. Every 7th commit on main merges a two-commit branch
+// whose commits are dated in between main's, so the topological order differs from the date order
+func newTestHistory(t testing.TB, commitCount int) string {
+	t.Helper()
+
+	repoPath := newTestRepo(t)
+	const baseTime = 1700000000
+
+	var stream strings.Builder
+	writeCommit := func(ref string, mark, timestamp int, message string, parents ...int) {
+		fmt.Fprintf(&stream, "commit %s\nmark :%d\n", ref, mark)
+		fmt.Fprintf(&stream, "author Test <test@gitwhale.local> %d +0000\n", timestamp)
+		fmt.Fprintf(&stream, "committer Test <test@gitwhale.local> %d +0000\n", timestamp)
+		fmt.Fprintf(&stream, "data %d\n%s\n", len(message), message)
+		for i, parent := range parents {
+			if i == 0 {
+				fmt.Fprintf(&stream, "from :%d\n", parent)
+			} else {
+				fmt.Fprintf(&stream, "merge :%d\n", parent)
+			}
+		}
+		content := fmt.Sprintf("%s\n", message)
+		fmt.Fprintf(&stream, "M 644 inline %s.txt\ndata %d\n%s\n", ref[len("refs/heads/"):], len(content), content)
+	}
+
+	for i := 1; i <= commitCount; i++ {
+		timestamp := baseTime + i*60
+		if i%7 == 0 {
+			featureStart, featureEnd := 100000+i, 200000+i
+			writeCommit("refs/heads/feature", featureStart, timestamp-110, fmt.Sprintf("Feature %d start", i), i-3)
+			writeCommit("refs/heads/feature", featureEnd, timestamp-50, fmt.Sprintf("Feature %d end", i), featureStart)
+			writeCommit("refs/heads/main", i, timestamp, fmt.Sprintf("Merge feature %d", i), i-1, featureEnd)
+			continue
+		}
+
+		if i == 1 {
+			writeCommit("refs/heads/main", i, timestamp, "Commit 1")
+		} else {
+			writeCommit("refs/heads/main", i, timestamp, fmt.Sprintf("Commit %d", i), i-1)
+		}
+	}
+
+	cmd := exec.Command("git", "fast-import", "--quiet")
+	cmd.Dir = repoPath
+	cmd.Env = append(os.Environ(), "GIT_CONFIG_NOSYSTEM=1", "GIT_CONFIG_GLOBAL="+os.DevNull)
+	cmd.Stdin = strings.NewReader(stream.String())
+	if output, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("git fast-import failed: %v\n%s", err, output)
+	}
+
+	runTestGit(t, repoPath, "symbolic-ref", "HEAD", "refs/heads/main")
+	runTestGit(t, repoPath, "tag", "-a", "-m", "Release", "v1.0", "HEAD~5")
+	return repoPath
+}
+
+func TestGoGitLogMatchesCli(t *testing.T) {
+	repoPath := newTestHistory(t, 60)
+
+	intPointer := func(value int) *int { return &value }
+	testCases := []struct {
+		name          string
+		commitsToLoad *int
+		commitsToSkip *int
+	}{
+		{name: "everything", commitsToLoad: intPointer(1000)},
+		{name: "first page", commitsToLoad: intPointer(10)},
+		{name: "page in the middle of a merge", commitsToLoad: intPointer(9), commitsToSkip: intPointer(12)},
+		{name: "last page", commitsToLoad: intPointer(50), commitsToSkip: intPointer(50)},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			options := GitLogOptions{CommitsToLoad: testCase.commitsToLoad, CommitsToSkip: testCase.commitsToSkip}
+
+			cliLogs, err := readLogWithCli(context.Background(), repoPath, options)
+			if err != nil {
+				t.Fatal(err)
+			}
+			goGitLogs, err := readLogWithGoGit(repoPath, options)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			summarize := func(logs []GitLogCommitInfo) []string {
+				summaries := []string{}
+				for _, log := range logs {
+					summaries = append(summaries, fmt.Sprintf("%s %v (%s)", log.CommitHash, log.ParentCommitHashes, log.Refs))
+				}
+				return summaries
+			}
+
+			if cliSummary, goGitSummary := summarize(cliLogs), summarize(goGitLogs); !reflect.DeepEqual(cliSummary, goGitSummary) {
+				t.Fatalf("the backends disagree:\ncli:\n%s\ngo-git:\n%s", strings.Join(cliSummary, "\n"), strings.Join(goGitSummary, "\n"))
+			}
+		})
+	}
+}
+
+// Loads the first page of the log, the way the log view does when a repo is opened
+func BenchmarkReadLog(b *testing.B) {
+	repoPath := newTestHistory(b, 3000)
+	commitsToLoad := 100
+	options := GitLogOptions{CommitsToLoad: &commitsToLoad}
+
+	backends := []struct {
+		name    string
+		backend GitBackend
+	}{
+		{name: "cli", backend: &CliGitBackend{}},
+		{name: "goGit", backend: &GoGitBackend{}},
+	}
+
+	for _, backend := range backends {
+		b.Run(backend.name, func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				logs, err := backend.backend.ReadLog(context.Background(), repoPath, options)
+				if err != nil || len(logs) != commitsToLoad {
+					b.Fatalf("expected %d commits, got %d: %v", commitsToLoad, len(logs), err)
+				}
+			}
+		})
+	}
+}
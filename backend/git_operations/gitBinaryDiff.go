@@ -0,0 +1,328 @@
+package git_operations
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"gitwhale/backend/logger"
+	"image"
+	"image/color"
+	"image/png"
+	"mime"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+
+	// Registers the decoders used by image.Decode
+	_ "image/gif"
+	_ "image/jpeg"
+)
+
+const (
+	maxThumbnailSize = 256
+
+	// Decoding allocates every pixel up front, so bigger images only get their dimensions shown
+	maxThumbnailSourcePixels = 40 * 1000 * 1000
+
+	defaultHexDumpLength = 512
+	maxHexDumpLength     = 64 * 1024
+	hexDumpBytesPerLine  = 16
+)
+
+// BinaryDiffOptions selects the two versions of a file to compare. Like GetFileContentFromRef, a ref can be
+// a commit-ish, "staged"/"index" for the staging area, or empty for the working directory
+type BinaryDiffOptions struct {
+	RepoPath string `json:"repoPath"`
+
+	LeftRef  string `json:"leftRef"`
+	LeftPath string `json:"leftPath"` // Empty when the file doesn't exist on the left side
+
+	RightRef  string `json:"rightRef"`
+	RightPath string `json:"rightPath"` // Empty when the file doesn't exist on the right side
+
+	// The window of bytes to show in the hex dump of non-image files
+	HexDumpOffset int64 `json:"hexDumpOffset"`
+	HexDumpLength int   `json:"hexDumpLength"` // Defaults to 512 bytes
+}
+
+type HexDumpLine struct {
+	Offset int64  `json:"offset"`
+	Hex    string `json:"hex"`   // Space separated byte values
+	Ascii  string `json:"ascii"` // Printable characters, with '.' for everything else
+}
+
+// BinarySideInfo describes one version of a binary file
+type BinarySideInfo struct {
+	Exists   bool   `json:"exists"`
+	Path     string `json:"path"`
+	MimeType string `json:"mimeType"`
+	Size     int64  `json:"size"`
+
+	// Only set for images that could be decoded (PNG, JPEG and GIF)
+	IsImage   bool   `json:"isImage"`
+	Width     int    `json:"width"`
+	Height    int    `json:"height"`
+	Thumbnail string `json:"thumbnail"` // A base64 PNG data URL, no bigger than 256x256
+
+	// Only set for files that aren't images
+	HexDump []HexDumpLine `json:"hexDump"`
+}
+
+type BinaryDiffInfo struct {
+	Left  BinarySideInfo `json:"left"`
+	Right BinarySideInfo `json:"right"`
+
+	// How much bigger (or smaller, when negative) the right side is
+	SizeDelta int64 `json:"sizeDelta"`
+}
+
+// GetBinaryDiffInfo describes the two versions of a binary file, with image previews or hex dumps
+func GetBinaryDiffInfo(ctx context.Context, options BinaryDiffOptions) (*BinaryDiffInfo, error) {
+	logger.Log.Debug("Getting binary diff info for %s (%s) vs %s (%s) in repo %s", options.LeftPath, options.LeftRef, options.RightPath, options.RightRef, options.RepoPath)
+
+	if options.LeftPath == "" && options.RightPath == "" {
+		return nil, fmt.Errorf("at least one side of the binary diff must have a path")
+	}
+
+	if options.HexDumpOffset < 0 {
+		return nil, fmt.Errorf("hex dump offset cannot be negative")
+	}
+
+	hexDumpLength := options.HexDumpLength
+	if hexDumpLength <= 0 {
+		hexDumpLength = defaultHexDumpLength
+	}
+	hexDumpLength = min(hexDumpLength, maxHexDumpLength)
+
+	left, err := readBinarySide(ctx, options.RepoPath, options.LeftRef, options.LeftPath, options.HexDumpOffset, hexDumpLength)
+	if err != nil {
+		return nil, err
+	}
+
+	right, err := readBinarySide(ctx, options.RepoPath, options.RightRef, options.RightPath, options.HexDumpOffset, hexDumpLength)
+	if err != nil {
+		return nil, err
+	}
+
+	return &BinaryDiffInfo{
+		Left:      *left,
+		Right:     *right,
+		SizeDelta: right.Size - left.Size,
+	}, nil
+}
+
+// GetCommitBinaryDiffInfo describes a binary file changed by a commit, compared to the commit's first parent
+func GetCommitBinaryDiffInfo(ctx context.Context, repoPath, commitHash string, fileChange FileChange, hexDumpOffset int64, hexDumpLength int) (*BinaryDiffInfo, error) {
+	options := BinaryDiffOptions{
+		RepoPath:      repoPath,
+		LeftRef:       commitHash + "^",
+		LeftPath:      fileChange.Path,
+		RightRef:      commitHash,
+		RightPath:     fileChange.Path,
+		HexDumpOffset: hexDumpOffset,
+		HexDumpLength: hexDumpLength,
+	}
+
+	if fileChange.OldPath != "" {
+		options.LeftPath = fileChange.OldPath
+	}
+
+	switch fileChange.Status {
+	case "A":
+		options.LeftPath = ""
+	case "D":
+		options.RightPath = ""
+	}
+
+	return GetBinaryDiffInfo(ctx, options)
+}
+
+func readBinarySide(ctx context.Context, repoPath, ref, path string, hexDumpOffset int64, hexDumpLength int) (*BinarySideInfo, error) {
+	side := &BinarySideInfo{Path: path, HexDump: []HexDumpLine{}}
+	if path == "" {
+		return side, nil
+	}
+
+	content, exists, err := readFileBytesFromRef(ctx, repoPath, ref, path)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return side, nil
+	}
+
+	side.Exists = true
+	side.Size = int64(len(content))
+	side.MimeType = detectMimeType(path, content)
+
+	imageConfig, _, err := image.DecodeConfig(bytes.NewReader(content))
+	if err == nil {
+		side.Width = imageConfig.Width
+		side.Height = imageConfig.Height
+
+		thumbnail, err := createImageThumbnail(content)
+		if err == nil {
+			side.IsImage = true
+			side.Thumbnail = thumbnail
+			return side, nil
+		}
+		logger.Log.Warning("Failed to create a thumbnail for %s: %v", path, err)
+	}
+
+	side.HexDump = createHexDump(content, hexDumpOffset, hexDumpLength)
+	return side, nil
+}
+
+// Reads the raw bytes of a file. The boolean is false when the file doesn't exist in that ref
+func readFileBytesFromRef(ctx context.Context, repoPath, ref, path string) ([]byte, bool, error) {
+	if ref == "" {
+		repoRoot, err := FindRepoRoot(ctx, repoPath)
+		if err != nil {
+			return nil, false, err
+		}
+
+		content, err := os.ReadFile(filepath.Join(repoRoot, filepath.FromSlash(path)))
+		if os.IsNotExist(err) {
+			return nil, false, nil
+		}
+		if err != nil {
+			return nil, false, fmt.Errorf("failed to read working directory file %s: %v", path, err)
+		}
+		return content, true, nil
+	}
+
+	return currentGitBackend().ReadBlob(repoPath, ref, path)
+}
+
+func readBlobWithCli(repoPath, ref, path string) ([]byte, bool, error) {
+	objectName := fmt.Sprintf("%s:%s", ref, path)
+	if ref == "index" || ref == "staged" {
+		objectName = ":" + path
+	}
+
+	reader := &blobReader{repoPath: repoPath}
+	defer reader.Close()
+
+	content, err := reader.Read(objectName)
+	if errors.Is(err, errObjectMissing) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to read %s: %v", objectName, err)
+	}
+	return content, true, nil
+}
+
+// Sniffs the content first, and falls back to the file extension when that isn't conclusive
+func detectMimeType(path string, content []byte) string {
+	mimeType := http.DetectContentType(content)
+	if mimeType != "application/octet-stream" {
+		return mimeType
+	}
+
+	if extensionType := mime.TypeByExtension(filepath.Ext(path)); extensionType != "" {
+		return extensionType
+	}
+	return mimeType
+}
+
+// Scales the image down to fit in the thumbnail size (never up), and encodes it as a PNG data URL
+func createImageThumbnail(content []byte) (string, error) {
+	// The header is enough to turn down huge images (or ones that only claim to be) before decoding them
+	config, _, err := image.DecodeConfig(bytes.NewReader(content))
+	if err != nil {
+		return "", err
+	}
+	if int64(config.Width)*int64(config.Height) > maxThumbnailSourcePixels {
+		return "", fmt.Errorf("the image is too large for a thumbnail (%dx%d)", config.Width, config.Height)
+	}
+
+	source, _, err := image.Decode(bytes.NewReader(content))
+	if err != nil {
+		return "", err
+	}
+
+	bounds := source.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+	if width == 0 || height == 0 {
+		return "", fmt.Errorf("image has no pixels")
+	}
+
+	scale := min(1, float64(maxThumbnailSize)/float64(max(width, height)))
+	thumbWidth := max(1, int(float64(width)*scale))
+	thumbHeight := max(1, int(float64(height)*scale))
+
+	// Box filter: every thumbnail pixel averages the source pixels it covers
+	thumbnail := image.NewNRGBA(image.Rect(0, 0, thumbWidth, thumbHeight))
+	for y := 0; y < thumbHeight; y++ {
+		sourceTop := bounds.Min.Y + y*height/thumbHeight
+		sourceBottom := max(sourceTop+1, bounds.Min.Y+(y+1)*height/thumbHeight)
+
+		for x := 0; x < thumbWidth; x++ {
+			sourceLeft := bounds.Min.X + x*width/thumbWidth
+			sourceRight := max(sourceLeft+1, bounds.Min.X+(x+1)*width/thumbWidth)
+
+			var r, g, b, a, count uint64
+			for sourceY := sourceTop; sourceY < sourceBottom; sourceY++ {
+				for sourceX := sourceLeft; sourceX < sourceRight; sourceX++ {
+					pixel := color.NRGBAModel.Convert(source.At(sourceX, sourceY)).(color.NRGBA)
+					r += uint64(pixel.R)
+					g += uint64(pixel.G)
+					b += uint64(pixel.B)
+					a += uint64(pixel.A)
+					count++
+				}
+			}
+
+			thumbnail.SetNRGBA(x, y, color.NRGBA{
+				R: uint8(r / count),
+				G: uint8(g / count),
+				B: uint8(b / count),
+				A: uint8(a / count),
+			})
+		}
+	}
+
+	var encoded bytes.Buffer
+	if err := png.Encode(&encoded, thumbnail); err != nil {
+		return "", err
+	}
+
+	return "data:image/png;base64," + base64.StdEncoding.EncodeToString(encoded.Bytes()), nil
+}
+
+// Formats a window of the content like `xxd`, 16 bytes per line
+func createHexDump(content []byte, offset int64, length int) []HexDumpLine {
+	lines := []HexDumpLine{}
+	if offset >= int64(len(content)) {
+		return lines
+	}
+
+	window := content[offset:min(int64(len(content)), offset+int64(length))]
+	for start := 0; start < len(window); start += hexDumpBytesPerLine {
+		chunk := window[start:min(len(window), start+hexDumpBytesPerLine)]
+
+		hexBytes := make([]string, len(chunk))
+		var ascii strings.Builder
+		for index, value := range chunk {
+			hexBytes[index] = hex.EncodeToString([]byte{value})
+			if value >= 0x20 && value < 0x7f {
+				ascii.WriteByte(value)
+			} else {
+				ascii.WriteByte('.')
+			}
+		}
+
+		lines = append(lines, HexDumpLine{
+			Offset: offset + int64(start),
+			Hex:    strings.Join(hexBytes, " "),
+			Ascii:  ascii.String(),
+		})
+	}
+
+	return lines
+}
@@ -0,0 +1,303 @@
+package git_operations
+
+import (
+	"context"
+	"fmt"
+	"gitwhale/backend/command_utils"
+	"gitwhale/backend/lib"
+	"gitwhale/backend/logger"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+type BisectMark string
+
+const (
+	BisectGood BisectMark = "good"
+	BisectBad  BisectMark = "bad"
+	BisectSkip BisectMark = "skip"
+)
+
+// BisectState describes an in-progress (or finished) `git bisect` session
+type BisectState struct {
+	IsActive bool `json:"isActive"`
+
+	// The commit that is currently checked out for testing
+	CurrentCommit *GitLogCommitInfo `json:"currentCommit"`
+
+	// Estimates from git for how much work is left
+	RemainingRevisions int `json:"remainingRevisions"`
+	EstimatedSteps     int `json:"estimatedSteps"`
+
+	BadRef   string   `json:"badRef"`
+	GoodRefs []string `json:"goodRefs"`
+	SkipRefs []string `json:"skipRefs"`
+
+	// Set once the bisect has narrowed things down to a single commit
+	FirstBadCommit *GitLogCommitInfo `json:"firstBadCommit"`
+}
+
+// BisectRunResult is streamed (as StreamedCommandEvent.Data) once `git bisect run` finds the first bad commit
+type BisectRunResult struct {
+	FirstBadCommit *GitLogCommitInfo `json:"firstBadCommit"`
+}
+
+var firstBadCommitOutputRegex = regexp.MustCompile(`^([0-9a-f]{40}|[0-9a-f]{64}) is the first bad commit`)
+var firstBadCommitLogRegex = regexp.MustCompile(`^# first bad commit: \[([0-9a-f]+)\]`)
+
+// StartBisect starts a bisect session between a known bad ref and one or more known good refs
+func StartBisect(ctx context.Context, repoPath, badRef string, goodRefs []string) (*BisectState, error) {
+	logger.Log.Info("Starting bisect in repo: %s, bad: %s, good: %v", repoPath, badRef, goodRefs)
+
+	if strings.TrimSpace(badRef) == "" || len(goodRefs) == 0 {
+		return nil, fmt.Errorf("a bad ref and at least one good ref are required to start bisecting")
+	}
+
+	if isBisectActive(ctx, repoPath) {
+		return nil, fmt.Errorf("a bisect is already in progress, reset it before starting a new one")
+	}
+
+	args := append([]string{"bisect", "start", badRef}, goodRefs...)
+	args = append(args, "--")
+	if err := runBisectCommand(ctx, repoPath, args...); err != nil {
+		return nil, err
+	}
+
+	return GetBisectState(ctx, repoPath)
+}
+
+// MarkBisectCommit marks a commit (or the current candidate, if ref is empty) as good, bad, or skipped
+func MarkBisectCommit(ctx context.Context, repoPath string, mark BisectMark, ref string) (*BisectState, error) {
+	logger.Log.Info("Marking %s as %s for bisect in repo: %s", ref, mark, repoPath)
+
+	if mark != BisectGood && mark != BisectBad && mark != BisectSkip {
+		return nil, fmt.Errorf("unsupported bisect mark: %s", mark)
+	}
+
+	if !isBisectActive(ctx, repoPath) {
+		return nil, fmt.Errorf("no bisect is in progress")
+	}
+
+	args := []string{"bisect", string(mark)}
+	if ref != "" {
+		args = append(args, ref)
+	}
+
+	if err := runBisectCommand(ctx, repoPath, args...); err != nil {
+		return nil, err
+	}
+
+	return GetBisectState(ctx, repoPath)
+}
+
+// ResetBisect ends the bisect session and checks out the branch the user was on before it started
+func ResetBisect(ctx context.Context, repoPath string) error {
+	logger.Log.Info("Resetting bisect in repo: %s", repoPath)
+	return runBisectCommand(ctx, repoPath, "bisect", "reset")
+}
+
+// GetBisectLog returns the output of `git bisect log`
+func GetBisectLog(ctx context.Context, repoPath string) (string, error) {
+	if !isBisectActive(ctx, repoPath) {
+		return "", fmt.Errorf("no bisect is in progress")
+	}
+
+	result, err := runGit(ctx, repoPath, "bisect", "log")
+	if err != nil {
+		return "", fmt.Errorf("failed to read the bisect log: %w", err)
+	}
+
+	return result.Stdout, nil
+}
+
+// GetBisectState reads the current bisect session from the repository
+func GetBisectState(ctx context.Context, repoPath string) (*BisectState, error) {
+	state := &BisectState{
+		GoodRefs: []string{},
+		SkipRefs: []string{},
+	}
+
+	if !isBisectActive(ctx, repoPath) {
+		return state, nil
+	}
+	state.IsActive = true
+
+	if err := readBisectRefs(ctx, repoPath, state); err != nil {
+		return nil, err
+	}
+
+	if headCommit, err := GetGitLogCommitInfo(ctx, repoPath, "HEAD"); err == nil {
+		state.CurrentCommit = headCommit
+	} else {
+		logger.Log.Warning("Failed to load the current bisect candidate: %v", err)
+	}
+
+	if state.BadRef != "" && len(state.GoodRefs) > 0 {
+		if err := readBisectEstimates(ctx, repoPath, state); err != nil {
+			logger.Log.Warning("Failed to estimate the remaining bisect steps: %v", err)
+		}
+	}
+
+	if firstBadHash := readFirstBadCommitFromLog(ctx, repoPath); firstBadHash != "" {
+		firstBadCommit, err := GetGitLogCommitInfo(ctx, repoPath, firstBadHash)
+		if err != nil {
+			return nil, err
+		}
+		state.FirstBadCommit = firstBadCommit
+	}
+
+	return state, nil
+}
+
+// ValidateBisectRun checks that `git bisect run` can be started with the script
+func ValidateBisectRun(ctx context.Context, repoPath, scriptPath string) error {
+	if !isBisectActive(ctx, repoPath) {
+		return fmt.Errorf("no bisect is in progress")
+	}
+
+	if !lib.FileExists(scriptPath) {
+		return fmt.Errorf("bisect script not found at: %s", scriptPath)
+	}
+	return nil
+}
+
+// RunBisectScript runs `git bisect run <script>` and streams its output to the topic until it finishes. Once git finds
+// the first bad commit, a BisectRunResult is streamed along with that line. Check the script with ValidateBisectRun
+// first. Cancelling ctx stops the run
+func RunBisectScript(ctx context.Context, repoPath, scriptPath string, scriptArgs []string, broadcastToTopic string) error {
+	logger.Log.Info("Starting automated bisect run with script %s in repo: %s", scriptPath, repoPath)
+
+	commandArgs := append([]string{"git", "bisect", "run", scriptPath}, scriptArgs...)
+	return command_utils.RunAndStreamParsedCommand(ctx, commandArgs, repoPath, broadcastToTopic, &bisectRunOutputParser{ctx: ctx, repoPath: repoPath}, false)
+}
+
+// bisectRunOutputParser streams every line of `git bisect run` as-is, and attaches the first bad commit once found
+type bisectRunOutputParser struct {
+	ctx      context.Context
+	repoPath string
+}
+
+func (parser *bisectRunOutputParser) ParseLine(line string) *command_utils.StreamedCommandEvent {
+	event := &command_utils.StreamedCommandEvent{Output: line}
+
+	matches := firstBadCommitOutputRegex.FindStringSubmatch(line)
+	if matches == nil {
+		return event
+	}
+
+	firstBadCommit, err := GetGitLogCommitInfo(parser.ctx, parser.repoPath, matches[1])
+	if err != nil {
+		logger.Log.Error("Failed to load the first bad commit %s: %v", matches[1], err)
+		return event
+	}
+
+	event.Data = BisectRunResult{FirstBadCommit: firstBadCommit}
+	return event
+}
+
+func (parser *bisectRunOutputParser) Flush() *command_utils.StreamedCommandEvent {
+	return nil
+}
+
+func (parser *bisectRunOutputParser) IsSuccessExitCode(exitCode int) bool {
+	return false
+}
+
+func runBisectCommand(ctx context.Context, repoPath string, args ...string) error {
+	if _, err := command_utils.RunGit(ctx, repoPath, args...); err != nil {
+		return fmt.Errorf("failed to run git %s: %w", strings.Join(args, " "), err)
+	}
+	return nil
+}
+
+// Returns the path of a file inside the repo's git directory
+func getGitPath(ctx context.Context, repoPath, name string) (string, error) {
+	result, err := runGit(ctx, repoPath, "rev-parse", "--git-path", name)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve git path %s: %w", name, err)
+	}
+
+	gitPath := strings.TrimSpace(result.Stdout)
+	if !filepath.IsAbs(gitPath) {
+		gitPath = filepath.Join(repoPath, gitPath)
+	}
+	return gitPath, nil
+}
+
+func isBisectActive(ctx context.Context, repoPath string) bool {
+	bisectLogPath, err := getGitPath(ctx, repoPath, "BISECT_LOG")
+	if err != nil {
+		return false
+	}
+	return lib.FileExists(bisectLogPath)
+}
+
+// Reads the good/bad/skip refs from refs/bisect/
+func readBisectRefs(ctx context.Context, repoPath string, state *BisectState) error {
+	result, err := runGit(ctx, repoPath, "for-each-ref", "--format=%(objectname) %(refname)", "refs/bisect/")
+	if err != nil {
+		return fmt.Errorf("failed to read the bisect refs: %w", err)
+	}
+
+	for _, line := range strings.Split(strings.TrimSpace(result.Stdout), "\n") {
+		hash, refName, found := strings.Cut(line, " ")
+		if !found {
+			continue
+		}
+
+		switch {
+		case refName == "refs/bisect/bad":
+			state.BadRef = hash
+		case strings.HasPrefix(refName, "refs/bisect/good-"):
+			state.GoodRefs = append(state.GoodRefs, hash)
+		case strings.HasPrefix(refName, "refs/bisect/skip-"):
+			state.SkipRefs = append(state.SkipRefs, hash)
+		}
+	}
+
+	return nil
+}
+
+// Uses `git rev-list --bisect-vars` to estimate the remaining revisions and steps
+func readBisectEstimates(ctx context.Context, repoPath string, state *BisectState) error {
+	args := []string{"rev-list", "--bisect-vars", state.BadRef, "--not"}
+	args = append(args, state.GoodRefs...)
+
+	result, err := runGit(ctx, repoPath, args...)
+	if err != nil {
+		return fmt.Errorf("failed to read the bisect estimates: %w", err)
+	}
+
+	for _, line := range strings.Split(result.Stdout, "\n") {
+		name, value, found := strings.Cut(strings.TrimSpace(line), "=")
+		if !found {
+			continue
+		}
+
+		switch name {
+		case "bisect_nr":
+			state.RemainingRevisions, _ = strconv.Atoi(strings.Trim(value, "'"))
+		case "bisect_steps":
+			state.EstimatedSteps, _ = strconv.Atoi(strings.Trim(value, "'"))
+		}
+	}
+
+	return nil
+}
+
+// git records the result in the bisect log once the first bad commit is found
+func readFirstBadCommitFromLog(ctx context.Context, repoPath string) string {
+	bisectLog, err := GetBisectLog(ctx, repoPath)
+	if err != nil {
+		return ""
+	}
+
+	for _, line := range strings.Split(bisectLog, "\n") {
+		if matches := firstBadCommitLogRegex.FindStringSubmatch(line); matches != nil {
+			return matches[1]
+		}
+	}
+	return ""
+}
@@ -0,0 +1,102 @@
+package git_operations
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// BlameLine is one line of a file, with the commit that last changed it
+type BlameLine struct {
+	LineNumber  int    `json:"lineNumber"`
+	CommitHash  string `json:"commitHash"` // All zeros for lines that aren't committed yet
+	Author      string `json:"author"`
+	AuthorEmail string `json:"authorEmail"`
+	AuthorTime  int64  `json:"authorTime"` // Unix seconds
+	Summary     string `json:"summary"`    // First line of the commit message
+	Content     string `json:"content"`
+}
+
+// The commit details that `git blame --porcelain` prints the first time a commit shows up
+type blameCommitInfo struct {
+	author      string
+	authorEmail string
+	authorTime  int64
+	summary     string
+}
+
+// GetBlame blames the working tree version of the file, relative to the repo's root
+func GetBlame(ctx context.Context, repoPath, filePath string) ([]BlameLine, error) {
+	result, err := runGit(ctx, repoPath, "blame", "--porcelain", "--", filePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to blame %s: %w", filePath, err)
+	}
+
+	lines, err := parseBlamePorcelain(result.Stdout)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse the blame of %s: %w", filePath, err)
+	}
+	return lines, nil
+}
+
+// Every line of the file starts with "<hash> <original line> <final line> [<lines in group>]", followed by the
+// commit's details the first time that commit shows up, and ends with the line itself prefixed by a tab
+func parseBlamePorcelain(output string) ([]BlameLine, error) {
+	commits := map[string]*blameCommitInfo{}
+	blameLines := []BlameLine{}
+
+	var current *BlameLine
+	for _, line := range strings.Split(output, "\n") {
+		if current == nil {
+			if line == "" {
+				continue
+			}
+
+			fields := strings.Fields(line)
+			if len(fields) < 3 {
+				return nil, fmt.Errorf("unexpected header line: %q", line)
+			}
+			lineNumber, err := strconv.Atoi(fields[2])
+			if err != nil {
+				return nil, fmt.Errorf("invalid line number in %q: %w", line, err)
+			}
+
+			current = &BlameLine{LineNumber: lineNumber, CommitHash: fields[0]}
+			if _, ok := commits[current.CommitHash]; !ok {
+				commits[current.CommitHash] = &blameCommitInfo{}
+			}
+			continue
+		}
+
+		if content, ok := strings.CutPrefix(line, "\t"); ok {
+			commit := commits[current.CommitHash]
+			current.Author = commit.author
+			current.AuthorEmail = commit.authorEmail
+			current.AuthorTime = commit.authorTime
+			current.Summary = commit.summary
+			current.Content = content
+			blameLines = append(blameLines, *current)
+			current = nil
+			continue
+		}
+
+		key, value, _ := strings.Cut(line, " ")
+		commit := commits[current.CommitHash]
+		switch key {
+		case "author":
+			commit.author = value
+		case "author-mail":
+			commit.authorEmail = strings.Trim(value, "<>")
+		case "author-time":
+			commit.authorTime, _ = strconv.ParseInt(value, 10, 64)
+		case "summary":
+			commit.summary = value
+		}
+	}
+
+	if current != nil {
+		return nil, fmt.Errorf("the output ended in the middle of line %d", current.LineNumber)
+	}
+	return blameLines, nil
+}
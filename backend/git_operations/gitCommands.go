@@ -1,10 +1,12 @@
 package git_operations
 
 import (
+	"context"
 	"fmt"
 	"gitwhale/backend/command_utils"
+	"gitwhale/backend/lib"
 	"gitwhale/backend/logger"
-	"os/exec"
+	"path/filepath"
 	"strconv"
 	"strings"
 )
@@ -20,6 +22,9 @@ type GitLogOptions struct {
 	FromRef       *string `json:"fromRef"`
 	SearchQuery   *string `json:"searchQuery"`
 	CommitsToSkip *int    `json:"commitsToSkip"`
+
+	// Only the commits that touched these paths (relative to the repo's root)
+	Paths []string `json:"paths,omitempty"`
 }
 
 // Represents a line in `git log`'s output
@@ -31,89 +36,175 @@ type GitLogCommitInfo struct {
 	AuthoredTimeStamp  string   `json:"authoredTimeStamp"`
 	ParentCommitHashes []string `json:"parentCommitHashes"`
 	Refs               string   `json:"refs"`
-	CommitMessage      []string `json:"commitMessage"`
+	CommitMessage      []string `json:"commitMessage"` // The raw message split on "\n", joining it gives the message back
 	ShortStat          string   `json:"shortStat"`
 }
 
-func GetCurrentBranchName(repoPath string) string {
-	cmd := exec.Command("git", "rev-parse", "--abbrev-ref", "HEAD")
-	cmd.Dir = repoPath
-	branchName, exitCode, err := command_utils.RunCommandAndLogErr(cmd)
-	if err != nil || exitCode != 0 {
+func GetCurrentBranchName(ctx context.Context, repoPath string) string {
+	result, err := runGit(ctx, repoPath, "rev-parse", "--abbrev-ref", "HEAD")
+	if err != nil {
 		return ""
 	}
-	return strings.TrimSpace(branchName)
+	return strings.TrimSpace(result.Stdout)
+}
+
+// FindRepoRoot returns the root of the worktree that contains path (a file or a folder inside of it). It asks git,
+// so worktrees, submodules and GIT_DIR are found the same way the other commands see them
+func FindRepoRoot(ctx context.Context, path string) (string, error) {
+	folderPath := path
+	if isDir, err := lib.IsDir(path); err == nil && !isDir {
+		folderPath = filepath.Dir(path)
+	}
+
+	result, err := runGit(ctx, folderPath, "rev-parse", "--show-toplevel")
+	if err != nil {
+		return "", fmt.Errorf("%s is not inside a git repository: %w", path, err)
+	}
+	return filepath.FromSlash(strings.TrimSpace(result.Stdout)), nil
 }
 
-// parseGitLogOutput parses the output lines from git log command into GitLogCommitInfo structs
-func parseGitLogOutput(outputLines []string) []GitLogCommitInfo {
+// Separators used by gitLogFormat. Every record starts with gitLogRecordStart and ends
+// with a NUL terminator (commit messages can never contain NUL bytes). The fields inside
+// a record are separated by gitLogFieldSeparator, and the commit message is always the
+// last field so that any separator characters inside of it can't shift the other fields.
+const (
+	gitLogRecordStart      = "\x1e"
+	gitLogFieldSeparator   = "\x1f"
+	gitLogRecordTerminator = "\x00"
+	gitLogFieldCount       = 8
+)
+
+const gitLogRecordFields = "%H%x1f%aN%x1f%aE%x1f%at%x1f%ct%x1f%P%x1f%D%x1f%B"
+
+var gitLogFormat = "--format=%x1e" + gitLogRecordFields + "%x00"
+
+// gitCommitFormat is a gitLogFormat record with the committer in front of it, which the log doesn't need
+var gitCommitFormat = "--format=%x1e%cN%x1f%cE%x1f" + gitLogRecordFields + "%x00"
+
+// GitLogParseError describes a single `git log` record that could not be parsed
+type GitLogParseError struct {
+	RecordIndex int
+	Record      string
+	Reason      string
+}
+
+func (e *GitLogParseError) Error() string {
+	return fmt.Sprintf("failed to parse git log record %d: %s", e.RecordIndex, e.Reason)
+}
+
+// parseGitLogOutput parses the output of `git log` (run with gitLogFormat) into GitLogCommitInfo structs.
+// Records that can't be parsed are skipped and reported through the returned errors
+func parseGitLogOutput(output string) ([]GitLogCommitInfo, []error) {
 	parsedLogs := []GitLogCommitInfo{}
-	currentLog := GitLogCommitInfo{}
-	currentSubLineCount := -1
-	nextLineIsShortStatLine := false
-
-	for _, line := range outputLines {
-		// logger.Log.Debug("Parsing line: %v", line)
-		currentSubLineCount += 1
-
-		// The null terminators comes right before the short-stat line
-		// (because of the -z flag)
-		if strings.HasSuffix(line, "\x00") {
-			line = strings.TrimSuffix(line, "\x00")
-			nextLineIsShortStatLine = true
-
-			if line != "" {
-				// Append anything remaining in the line as the commit message
-				if currentLog.CommitMessage == nil {
-					currentLog.CommitMessage = []string{}
-				}
-				currentLog.CommitMessage = append(currentLog.CommitMessage, line)
+	parseErrors := []error{}
+
+	// Each chunk holds the shortstat of the previous record, followed by the header and message of the next one
+	chunks := strings.Split(output, gitLogRecordTerminator)
+	var previousLog *GitLogCommitInfo
+	for chunkIndex, chunk := range chunks {
+		// Anything before the record start marker belongs to the previous record (its --shortstat section)
+		statSection, record, hasRecord := strings.Cut(chunk, gitLogRecordStart)
+		if previousLog != nil {
+			previousLog.ShortStat = strings.TrimSpace(statSection)
+			parsedLogs = append(parsedLogs, *previousLog)
+			previousLog = nil
+		}
+
+		if !hasRecord {
+			// Only the last chunk is allowed to have no record in it
+			if chunkIndex != len(chunks)-1 && strings.TrimSpace(chunk) != "" {
+				parseErrors = append(parseErrors, &GitLogParseError{RecordIndex: chunkIndex, Record: chunk, Reason: "missing record start marker"})
 			}
 			continue
 		}
 
-		if nextLineIsShortStatLine {
-			currentLog.ShortStat = line
-			currentSubLineCount = -1
-			nextLineIsShortStatLine = false
-			parsedLogs = append(parsedLogs, currentLog)
-			currentLog = GitLogCommitInfo{}
+		if chunkIndex == len(chunks)-1 {
+			parseErrors = append(parseErrors, &GitLogParseError{RecordIndex: chunkIndex, Record: record, Reason: "missing record terminator"})
 			continue
 		}
 
-		switch currentSubLineCount {
-		case 0:
-			currentLog.CommitHash = line
-		case 1:
-			currentLog.Username = line
-		case 2:
-			currentLog.UserEmail = line
-		case 3:
-			currentLog.AuthoredTimeStamp = line
-		case 4:
-			currentLog.CommitTimeStamp = line
-		case 5:
-			currentLog.ParentCommitHashes = strings.Split(line, " ")
-		case 6:
-			currentLog.Refs = line
-		case 7:
-			currentLog.CommitMessage = []string{line}
-		default:
-			currentLog.CommitMessage = append(currentLog.CommitMessage, line)
+		commitInfo, err := parseGitLogRecord(record)
+		if err != nil {
+			parseErrors = append(parseErrors, &GitLogParseError{RecordIndex: chunkIndex, Record: record, Reason: err.Error()})
+			continue
 		}
+
+		previousLog = commitInfo
 	}
 
-	return parsedLogs
+	return parsedLogs, parseErrors
 }
 
-func ReadGitLog(repoPath string, options GitLogOptions) []GitLogCommitInfo {
+// parseGitLogRecord parses the fields of a single record (without its start marker and terminator)
+func parseGitLogRecord(record string) (*GitLogCommitInfo, error) {
+	fields := strings.SplitN(record, gitLogFieldSeparator, gitLogFieldCount)
+	if len(fields) != gitLogFieldCount {
+		return nil, fmt.Errorf("expected %d fields, found %d", gitLogFieldCount, len(fields))
+	}
+
+	commitHash := fields[0]
+	if !isCommitHash(commitHash) {
+		return nil, fmt.Errorf("invalid commit hash '%s'", commitHash)
+	}
+
+	for _, timestamp := range fields[3:5] {
+		if _, err := strconv.ParseInt(timestamp, 10, 64); err != nil {
+			return nil, fmt.Errorf("invalid timestamp '%s' for commit %s", timestamp, commitHash)
+		}
+	}
+
+	parentCommitHashes := strings.Fields(fields[5])
+	for _, parentHash := range parentCommitHashes {
+		if !isCommitHash(parentHash) {
+			return nil, fmt.Errorf("invalid parent hash '%s' for commit %s", parentHash, commitHash)
+		}
+	}
+
+	// %B is the raw message, so joining the lines gives back the message byte-for-byte
+	message := fields[7]
+
+	return &GitLogCommitInfo{
+		CommitHash:         commitHash,
+		Username:           fields[1],
+		UserEmail:          fields[2],
+		AuthoredTimeStamp:  fields[3],
+		CommitTimeStamp:    fields[4],
+		ParentCommitHashes: parentCommitHashes,
+		Refs:               fields[6],
+		CommitMessage:      strings.Split(message, "\n"),
+	}, nil
+}
+
+// isCommitHash checks for a full SHA-1 or SHA-256 object name
+func isCommitHash(hash string) bool {
+	if len(hash) != 40 && len(hash) != 64 {
+		return false
+	}
+
+	for _, char := range hash {
+		if !strings.ContainsRune("0123456789abcdef", char) {
+			return false
+		}
+	}
+	return true
+}
+
+func ReadGitLog(ctx context.Context, repoPath string, options GitLogOptions) []GitLogCommitInfo {
 	logger.Log.Info("Running git log with options on repo: %v", repoPath)
 
+	logs, err := currentGitBackend().ReadLog(ctx, repoPath, options)
+	if err != nil {
+		logger.Log.Error("Failed to read the log of %s: %v", repoPath, err)
+		return make([]GitLogCommitInfo, 0)
+	}
+	return logs
+}
+
+func readLogWithCli(ctx context.Context, repoPath string, options GitLogOptions) ([]GitLogCommitInfo, error) {
 	// Build git log command arguments safely
 	args := []string{
 		"log",
-		"--format=%H%n%aN%n%aE%n%at%n%ct%n%P%n%D%n%B",
-		"-z",
+		gitLogFormat,
 		"--shortstat",
 		"--topo-order",
 		"--decorate=full",
@@ -136,21 +227,26 @@ func ReadGitLog(repoPath string, options GitLogOptions) []GitLogCommitInfo {
 	}
 	args = append(args, *options.FromRef)
 
-	cmd := exec.Command("git", args...)
-	cmd.Dir = repoPath
-	cmdOutput, exitCode, err := command_utils.RunCommandAndLogErr(cmd)
-	if err != nil || exitCode != 0 {
-		return make([]GitLogCommitInfo, 0)
+	if len(options.Paths) > 0 {
+		args = append(args, "--")
+		args = append(args, options.Paths...)
 	}
 
-	outputLines := strings.Split(cmdOutput, "\n")
-	parsedLogs := parseGitLogOutput(outputLines)
-	return parsedLogs
+	result, err := runGit(ctx, repoPath, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	parsedLogs, parseErrors := parseGitLogOutput(result.Stdout)
+	for _, parseErr := range parseErrors {
+		logger.Log.Error("%v", parseErr)
+	}
+	return parsedLogs, nil
 }
 
 // GetGitLogCommitInfo fetches basic commit information for a single commit using git log
 // This is much faster than GetDetailedCommitInfo as it uses a single git log command
-func GetGitLogCommitInfo(repoPath, commitHash string) (*GitLogCommitInfo, error) {
+func GetGitLogCommitInfo(ctx context.Context, repoPath, commitHash string) (*GitLogCommitInfo, error) {
 	logger.Log.Info("Fetching git log commit info for %s in %s", commitHash, repoPath)
 
 	// Use git log with -n1 to get just this commit
@@ -160,7 +256,7 @@ func GetGitLogCommitInfo(repoPath, commitHash string) (*GitLogCommitInfo, error)
 		FromRef:       &commitHash,
 	}
 
-	commits := ReadGitLog(repoPath, options)
+	commits := ReadGitLog(ctx, repoPath, options)
 	if len(commits) == 0 {
 		return nil, fmt.Errorf("commit %s not found in repository %s", commitHash, repoPath)
 	}
@@ -169,20 +265,57 @@ func GetGitLogCommitInfo(repoPath, commitHash string) (*GitLogCommitInfo, error)
 	return &commits[0], nil
 }
 
-func GetAllRefs(repoPath string) []GitRef {
+// ReadGitLogCommits loads the log info for a specific set of commits (full or abbreviated hashes), keyed by their full hash
+func ReadGitLogCommits(ctx context.Context, repoPath string, commitHashes []string) (map[string]GitLogCommitInfo, error) {
+	commits := make(map[string]GitLogCommitInfo)
+	if len(commitHashes) == 0 {
+		return commits, nil
+	}
+
+	args := []string{"log", gitLogFormat, "--shortstat", "--decorate=full", "--diff-merges=first-parent", "--no-walk=unsorted"}
+	args = append(args, commitHashes...)
+
+	result, err := runGit(ctx, repoPath, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load commits %v: %w", commitHashes, err)
+	}
+
+	parsedLogs, parseErrors := parseGitLogOutput(result.Stdout)
+	for _, parseErr := range parseErrors {
+		logger.Log.Error("%v", parseErr)
+	}
+
+	for _, commit := range parsedLogs {
+		commits[commit.CommitHash] = commit
+	}
+	return commits, nil
+}
+
+func GetAllRefs(ctx context.Context, repoPath string) []GitRef {
 	logger.Log.Info("Getting branches for repo: %v", repoPath)
 
+	refs, err := currentGitBackend().ReadRefs(ctx, repoPath)
+	if err != nil {
+		logger.Log.Error("Failed to read the refs of %s: %v", repoPath, err)
+		return make([]GitRef, 0)
+	}
+	return refs
+}
+
+func readRefsWithCli(ctx context.Context, repoPath string) ([]GitRef, error) {
 	parsedRefs := []GitRef{}
 
 	// Get local branches
-	cmd := exec.Command("git", "show-ref")
-	cmd.Dir = repoPath
-	commandOutput, exitCode, err := command_utils.RunCommandAndLogErr(cmd)
-	if err != nil || exitCode != 0 {
-		return make([]GitRef, 0)
+	result, err := runGit(ctx, repoPath, "show-ref")
+	if err != nil {
+		// show-ref exits with 1 when the repo doesn't have any refs yet
+		if result.ExitCode == 1 && result.Stderr == "" {
+			return parsedRefs, nil
+		}
+		return nil, err
 	}
 
-	for _, line := range strings.Split(commandOutput, "\n") {
+	for _, line := range strings.Split(result.Stdout, "\n") {
 		line = strings.TrimSpace(line)
 		if line == "" {
 			continue
@@ -195,33 +328,38 @@ func GetAllRefs(repoPath string) []GitRef {
 		refHash := lineSubComponents[0]
 		fullRefName := lineSubComponents[1]
 
-		// Figure out the type of ref this might be
-		refType := ""
-		shortRefName := ""
-		if strings.HasPrefix(fullRefName, "refs/heads/") {
-			refType = "localBranch"
-			shortRefName = strings.TrimPrefix(fullRefName, "refs/heads/")
-		} else if strings.HasPrefix(fullRefName, "refs/tags/") {
-			refType = "tag"
-			shortRefName = strings.TrimPrefix(fullRefName, "refs/tags/")
-		} else if strings.HasPrefix(fullRefName, "refs/remotes/") {
-			refType = "remoteBranch"
-			shortRefName = strings.TrimPrefix(fullRefName, "refs/remotes/")
+		if gitRef, ok := newGitRef(fullRefName, refHash); ok {
+			parsedRefs = append(parsedRefs, gitRef)
 		}
+	}
 
-		if refType == "" || shortRefName == "" {
-			continue
-		}
+	return parsedRefs, nil
+}
 
-		parsedRefs = append(parsedRefs, GitRef{
-			Name: shortRefName,
-			Hash: refHash,
-			Type: refType,
-		})
+// newGitRef figures out the type of ref a full ref name is. Refs that aren't branches or tags (like the stash) are skipped
+func newGitRef(fullRefName, refHash string) (GitRef, bool) {
+	refType := ""
+	shortRefName := ""
+	if strings.HasPrefix(fullRefName, "refs/heads/") {
+		refType = "localBranch"
+		shortRefName = strings.TrimPrefix(fullRefName, "refs/heads/")
+	} else if strings.HasPrefix(fullRefName, "refs/tags/") {
+		refType = "tag"
+		shortRefName = strings.TrimPrefix(fullRefName, "refs/tags/")
+	} else if strings.HasPrefix(fullRefName, "refs/remotes/") {
+		refType = "remoteBranch"
+		shortRefName = strings.TrimPrefix(fullRefName, "refs/remotes/")
+	}
 
+	if refType == "" || shortRefName == "" {
+		return GitRef{}, false
 	}
 
-	return parsedRefs
+	return GitRef{
+		Name: shortRefName,
+		Hash: refHash,
+		Type: refType,
+	}, true
 }
 
 type WorktreeInfo struct {
@@ -231,13 +369,11 @@ type WorktreeInfo struct {
 	Bare   bool   `json:"bare"`
 }
 
-func GetWorktrees(repoPath string) []WorktreeInfo {
+func GetWorktrees(ctx context.Context, repoPath string) []WorktreeInfo {
 	logger.Log.Info("Getting worktrees for repo: %v", repoPath)
 
-	cmd := exec.Command("git", "worktree", "list", "--porcelain")
-	cmd.Dir = repoPath
-	output, exitCode, err := command_utils.RunCommandAndLogErr(cmd)
-	if err != nil || exitCode != 0 {
+	result, err := runGit(ctx, repoPath, "worktree", "list", "--porcelain")
+	if err != nil {
 		// If worktree command fails, this might not be a git repo or worktrees not supported
 		logger.Log.Debug("Failed to get worktrees (likely not a worktree repo): %v", err)
 		return make([]WorktreeInfo, 0)
@@ -246,7 +382,7 @@ func GetWorktrees(repoPath string) []WorktreeInfo {
 	var worktrees []WorktreeInfo
 	var current WorktreeInfo
 
-	for _, line := range strings.Split(strings.TrimSpace(output), "\n") {
+	for _, line := range strings.Split(strings.TrimSpace(result.Stdout), "\n") {
 		line = strings.TrimSpace(line)
 		if line == "" {
 			// Empty line indicates end of worktree entry
@@ -276,15 +412,20 @@ func GetWorktrees(repoPath string) []WorktreeInfo {
 	return worktrees
 }
 
-func GitFetch(repoPath string) error {
+func GitFetch(ctx context.Context, repoPath string) error {
 	logger.Log.Info("Fetching for repo: %v", repoPath)
 
-	cmd := exec.Command("git", "fetch", "origin")
-	cmd.Dir = repoPath
-	output, exitCode, err := command_utils.RunCommandAndLogErr(cmd)
-	if err != nil || exitCode != 0 {
-		logger.Log.Error("Error fetching: %v, output: %s", err, string(output))
-		return fmt.Errorf("failed to fetch: %v", err)
+	_, err := command_utils.RunCommand(ctx, command_utils.Command{
+		Args:    []string{"fetch", "--progress", "origin"},
+		Dir:     repoPath,
+		Timeout: gitNetworkTimeout,
+		Progress: func(event command_utils.ProgressEvent) {
+			command_utils.ReportGitProgress(ctx, event)
+		},
+	})
+	if err != nil {
+		logger.Log.Error("Error fetching: %v", err)
+		return fmt.Errorf("failed to fetch: %w", err)
 	}
 
 	logger.Log.Info("Successfully fetched for repo: %v", repoPath)
@@ -292,7 +433,7 @@ func GitFetch(repoPath string) error {
 }
 
 // ValidateGitRef checks if a Git reference (branch, tag, commit hash, etc.) exists and is valid
-func ValidateGitRef(repoPath, ref string) bool {
+func ValidateGitRef(ctx context.Context, repoPath, ref string) bool {
 	logger.Log.Info(fmt.Sprintf("ValidateGitRef: Starting validation for ref '%s' in repo '%s'", ref, repoPath))
 
 	if strings.TrimSpace(ref) == "" {
@@ -303,11 +444,9 @@ func ValidateGitRef(repoPath, ref string) bool {
 	// Use git rev-parse --verify which is very fast and handles all ref types
 	// This works for: commit hashes, branch names, tag names, HEAD~1, etc.
 	logger.Log.Debug(fmt.Sprintf("ValidateGitRef: Running 'git rev-parse --verify --quiet %s' in repo '%s'", ref, repoPath))
-	cmd := exec.Command("git", "rev-parse", "--verify", "--quiet", ref)
-	cmd.Dir = repoPath
-	_, exitCode, err := command_utils.RunCommandAndLogErr(cmd)
+	_, err := runGit(ctx, repoPath, "rev-parse", "--verify", "--quiet", ref)
 
-	isValid := err == nil && exitCode == 0
+	isValid := err == nil
 
 	// If the command succeeds (exit code 0), the ref is valid
 	return isValid
@@ -351,18 +490,17 @@ type DetailedCommitInfo struct {
 }
 
 // GetDetailedCommitInfo fetches comprehensive information about a specific commit
-func GetDetailedCommitInfo(repoPath string, commitHash string) (*DetailedCommitInfo, error) {
+func GetDetailedCommitInfo(ctx context.Context, repoPath string, commitHash string, diffSettings *DiffSettings) (*DetailedCommitInfo, error) {
 	logger.Log.Info("Fetching detailed commit info for %s in %s", commitHash, repoPath)
 
-	commit := &DetailedCommitInfo{}
-
 	// Step 1: Get basic commit information
-	if err := getBasicCommitInfo(repoPath, commitHash, commit); err != nil {
+	commit, err := currentGitBackend().ReadCommit(ctx, repoPath, commitHash)
+	if err != nil {
 		return nil, err
 	}
 
-	// Step 2: Get file changes using git diff-tree
-	if err := getCommitFileChanges(repoPath, commitHash, commit); err != nil {
+	// Step 2: Get file changes using git diff-tree. This always uses the CLI, since it has to honor the diff settings
+	if err := getCommitFileChanges(ctx, repoPath, commitHash, commit, diffSettings); err != nil {
 		return nil, err
 	}
 
@@ -370,52 +508,62 @@ func GetDetailedCommitInfo(repoPath string, commitHash string) (*DetailedCommitI
 	return commit, nil
 }
 
-// getBasicCommitInfo fetches the core commit information using git show
-func getBasicCommitInfo(repoPath, commitHash string, commit *DetailedCommitInfo) error {
-	// Use git show with precise format to get basic commit info
-	cmd := exec.Command("git", "show", "--no-patch", "--format=%H%n%an%n%ae%n%cn%n%ce%n%ct%n%at%n%P%n%D%n%s%n%B", commitHash)
-	cmd.Dir = repoPath
-	output, exitCode, err := command_utils.RunCommandAndLogErr(cmd)
-	if err != nil || output == "" || exitCode != 0 {
-		return fmt.Errorf("failed to get commit info for %s: %v", commitHash, err)
-	}
-
-	lines := strings.Split(output, "\n")
-	if len(lines) < 10 {
-		return fmt.Errorf("invalid commit format for %s", commitHash)
-	}
-
-	// Parse fixed-position fields
-	commit.CommitHash = strings.TrimSpace(lines[0])        // %H
-	commit.Username = strings.TrimSpace(lines[1])          // %an
-	commit.UserEmail = strings.TrimSpace(lines[2])         // %ae
-	commit.CommitterName = strings.TrimSpace(lines[3])     // %cn
-	commit.CommitterEmail = strings.TrimSpace(lines[4])    // %ce
-	commit.CommitTimeStamp = strings.TrimSpace(lines[5])   // %ct
-	commit.AuthoredTimeStamp = strings.TrimSpace(lines[6]) // %at
-	commit.ParentCommitHashes = strings.Fields(lines[7])   // %P
-	commit.Refs = strings.TrimSpace(lines[8])              // %D
-
-	// Parse commit message (subject + body) -- %s and %B
-	messageLines := []string{}
-	if len(lines) > 9 {
-		// Remaining lines are the body (%b), including empty lines
-		for i := 10; i < len(lines); i++ {
-			messageLines = append(messageLines, lines[i])
-		}
+// readCommitWithCli fetches the core commit information using git show. The changed files and stats are left empty
+func readCommitWithCli(ctx context.Context, repoPath, commitHash string) (*DetailedCommitInfo, error) {
+	result, err := runGit(ctx, repoPath, "show", "--no-patch", gitCommitFormat, commitHash)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get commit info for %s: %w", commitHash, err)
+	}
 
-		// Remove trailing empty lines only
-		for len(messageLines) > 0 && strings.TrimSpace(messageLines[len(messageLines)-1]) == "" {
-			messageLines = messageLines[:len(messageLines)-1]
-		}
+	commit, err := parseGitCommitOutput(result.Stdout)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse commit %s: %w", commitHash, err)
 	}
-	commit.CommitMessage = messageLines
+	return commit, nil
+}
 
-	return nil
+// parseGitCommitOutput parses the single record that `git show` (run with gitCommitFormat) prints
+func parseGitCommitOutput(output string) (*DetailedCommitInfo, error) {
+	// git ends the formatted commit with a newline of its own, after the terminator
+	record, hasStart := strings.CutPrefix(strings.TrimSuffix(output, "\n"), gitLogRecordStart)
+	if !hasStart {
+		return nil, fmt.Errorf("missing record start marker")
+	}
+	record, hasTerminator := strings.CutSuffix(record, gitLogRecordTerminator)
+	if !hasTerminator {
+		return nil, fmt.Errorf("missing record terminator")
+	}
+
+	committerFields := strings.SplitN(record, gitLogFieldSeparator, 3)
+	if len(committerFields) != 3 {
+		return nil, fmt.Errorf("expected the committer's name and email, found %d fields", len(committerFields)-1)
+	}
+
+	logInfo, err := parseGitLogRecord(committerFields[2])
+	if err != nil {
+		return nil, err
+	}
+
+	return &DetailedCommitInfo{
+		CommitHash:         logInfo.CommitHash,
+		Username:           logInfo.Username,
+		UserEmail:          logInfo.UserEmail,
+		CommitTimeStamp:    logInfo.CommitTimeStamp,
+		AuthoredTimeStamp:  logInfo.AuthoredTimeStamp,
+		ParentCommitHashes: logInfo.ParentCommitHashes,
+		Refs:               logInfo.Refs,
+		CommitMessage:      logInfo.CommitMessage,
+		CommitterName:      committerFields[0],
+		CommitterEmail:     committerFields[1],
+	}, nil
 }
 
 // getCommitFileChanges uses git diff-tree to get accurate file change information
-func getCommitFileChanges(repoPath, commitHash string, commit *DetailedCommitInfo) error {
+func getCommitFileChanges(ctx context.Context, repoPath, commitHash string, commit *DetailedCommitInfo, diffSettings *DiffSettings) error {
+	if err := diffSettings.Validate(); err != nil {
+		return fmt.Errorf("invalid diff settings: %v", err)
+	}
+
 	// For root commit, compare against empty tree
 	parentRef := ""
 	if len(commit.ParentCommitHashes) == 0 {
@@ -427,21 +575,22 @@ func getCommitFileChanges(repoPath, commitHash string, commit *DetailedCommitInf
 	}
 
 	// Get file status changes using diff-tree with proper options
-	cmd := exec.Command("git", "diff-tree", "-r", "--name-status", "-z", "--diff-filter=ADMR", "--find-renames=50%", parentRef, commitHash)
-	cmd.Dir = repoPath
-	output, exitCode, err := command_utils.RunCommandAndLogErr(cmd)
-	if err != nil || exitCode != 0 {
-		return fmt.Errorf("failed to get file changes for %s: %v", commitHash, err)
+	diffTreeArgs := []string{"diff-tree", "-r", "--name-status", "-z", "--diff-filter=ADMRC"}
+	diffTreeArgs = append(diffTreeArgs, diffSettings.gitArgs()...)
+	diffTreeArgs = append(diffTreeArgs, parentRef, commitHash)
+	result, err := runGit(ctx, repoPath, diffTreeArgs...)
+	if err != nil {
+		return fmt.Errorf("failed to get file changes for %s: %w", commitHash, err)
 	}
 
 	// Parse null-separated output
-	fileChanges, err := parseFileChanges(output)
+	fileChanges, err := parseFileChanges(result.Stdout)
 	if err != nil {
 		return fmt.Errorf("failed to parse file changes: %v", err)
 	}
 
 	// Get line count statistics and enrich file changes in one pass
-	stats, err := getNumstatData(repoPath, parentRef, commitHash, fileChanges)
+	stats, err := getNumstatData(ctx, repoPath, parentRef, commitHash, fileChanges, diffSettings)
 	if err != nil {
 		logger.Log.Error("Failed to get numstat data for %s: %v", commitHash, err)
 		// Continue with empty stats rather than failing
@@ -452,11 +601,11 @@ func getCommitFileChanges(repoPath, commitHash string, commit *DetailedCommitInf
 	commit.CommitStats = *stats
 
 	// Get short stat summary
-	shortStatCmd := exec.Command("git", "diff", "--shortstat", parentRef, commitHash)
-	shortStatCmd.Dir = repoPath
-	shortStatOutput, exitCode, err := command_utils.RunCommandAndLogErr(shortStatCmd)
-	if err == nil || exitCode != 0 {
-		commit.ShortStat = strings.TrimSpace(shortStatOutput)
+	shortStatArgs := append([]string{"diff", "--shortstat"}, diffSettings.gitArgs()...)
+	shortStatArgs = append(shortStatArgs, parentRef, commitHash)
+	shortStatResult, err := runGit(ctx, repoPath, shortStatArgs...)
+	if err == nil {
+		commit.ShortStat = strings.TrimSpace(shortStatResult.Stdout)
 	}
 
 	return nil
@@ -512,13 +661,14 @@ func parseFileChanges(output string) ([]FileChange, error) {
 }
 
 // getNumstatData runs git diff --numstat once and returns both aggregate stats and enriches file changes
-func getNumstatData(repoPath, parentRef, commitHash string, fileChanges []FileChange) (*CommitStats, error) {
-	cmd := exec.Command("git", "diff", "--numstat", "-z", parentRef, commitHash)
-	cmd.Dir = repoPath
-	output, exitCode, err := command_utils.RunCommandAndLogErr(cmd)
-	if err != nil || exitCode != 0 {
+func getNumstatData(ctx context.Context, repoPath, parentRef, commitHash string, fileChanges []FileChange, diffSettings *DiffSettings) (*CommitStats, error) {
+	numstatArgs := append([]string{"diff", "--numstat", "-z"}, diffSettings.gitArgs()...)
+	numstatArgs = append(numstatArgs, parentRef, commitHash)
+	result, err := runGit(ctx, repoPath, numstatArgs...)
+	if err != nil {
 		return nil, err
 	}
+	output := result.Stdout
 
 	stats := &CommitStats{}
 
@@ -532,7 +682,8 @@ func getNumstatData(repoPath, parentRef, commitHash string, fileChanges []FileCh
 	// Split by null character for -z output, removing empty last element
 	records := strings.Split(strings.TrimSuffix(output, "\x00"), "\x00")
 
-	for _, record := range records {
+	for recordIndex := 0; recordIndex < len(records); recordIndex++ {
+		record := records[recordIndex]
 		if record == "" {
 			continue
 		}
@@ -548,6 +699,12 @@ func getNumstatData(repoPath, parentRef, commitHash string, fileChanges []FileCh
 		deletionsStr := parts[1]
 		filepath := parts[2]
 
+		// Renames and copies are "additions\tdeletions\t\0old_path\0new_path"
+		if filepath == "" && recordIndex+2 < len(records) {
+			filepath = records[recordIndex+2]
+			recordIndex += 2
+		}
+
 		var linesAdded, linesDeleted int
 		var isBinary bool
 
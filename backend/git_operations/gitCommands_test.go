@@ -0,0 +1,120 @@
+package git_operations
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+// Runs git in the test repo, without the user's or the system's config getting in the way
+func runTestGit(t testing.TB, repoPath string, args ...string) string {
+	t.Helper()
+
+	cmd := exec.Command("git", append([]string{"-c", "user.name=GitWhale Test", "-c", "user.email=test@gitwhale.local", "-c", "commit.gpgsign=false"}, args...)...)
+	cmd.Dir = repoPath
+	cmd.Env = append(os.Environ(), "GIT_CONFIG_NOSYSTEM=1", "GIT_CONFIG_GLOBAL="+os.DevNull)
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("git %s failed: %v\n%s", strings.Join(args, " "), err, output)
+	}
+	return string(output)
+}
+
+func newTestRepo(t testing.TB) string {
+	t.Helper()
+
+	repoPath := t.TempDir()
+	runTestGit(t, repoPath, "init", "--quiet")
+	return repoPath
+}
+
+// Commits the message exactly as given, without git's cleanup of whitespace and comments
+func commitTestMessage(t testing.TB, repoPath, message string) {
+	t.Helper()
+
+	messagePath := filepath.Join(t.TempDir(), "message")
+	if err := os.WriteFile(messagePath, []byte(message), 0644); err != nil {
+		t.Fatal(err)
+	}
+	runTestGit(t, repoPath, "commit", "--quiet", "--allow-empty", "--allow-empty-message", "--cleanup=verbatim", "-F", messagePath)
+}
+
+func FuzzParseGitLogOutput(f *testing.F) {
+	f.Add("Subject only")
+	f.Add("Subject\n\nBody after a blank line\n")
+	f.Add("Trailing newlines\n\n\n")
+	f.Add("\n\nLeading blank lines")
+	f.Add("Record start \x1e inside the subject")
+	f.Add("Field separator \x1f inside the subject\n\nand \x1f\x1f in the body")
+	f.Add("\x1e\x1f\x1e\x1f\n")
+	f.Add("")
+
+	f.Fuzz(func(t *testing.T, message string) {
+		// git ends the message at the first NUL byte, and stores invalid UTF-8 as if it was Latin-1
+		if strings.Contains(message, "\x00") || !utf8.ValidString(message) {
+			t.Skip()
+		}
+
+		repoPath := newTestRepo(t)
+		commitTestMessage(t, repoPath, message)
+
+		commitsToLoad := 1
+		logs := ReadGitLog(context.Background(), repoPath, GitLogOptions{CommitsToLoad: &commitsToLoad})
+		if len(logs) != 1 {
+			t.Fatalf("expected 1 commit, got %d", len(logs))
+		}
+
+		if parsedMessage := strings.Join(logs[0].CommitMessage, "\n"); parsedMessage != message {
+			t.Fatalf("the message didn't round-trip through the log:\nwant %q\ngot  %q", message, parsedMessage)
+		}
+
+		for name, readCommit := range map[string]func(string, string) (*DetailedCommitInfo, error){
+			"cli": func(repoPath, commitHash string) (*DetailedCommitInfo, error) {
+				return readCommitWithCli(context.Background(), repoPath, commitHash)
+			},
+			"goGit": readCommitWithGoGit,
+		} {
+			commit, err := readCommit(repoPath, "HEAD")
+			if err != nil {
+				t.Fatalf("%s: failed to read the commit: %v", name, err)
+			}
+			if parsedMessage := strings.Join(commit.CommitMessage, "\n"); parsedMessage != message {
+				t.Fatalf("%s: the message didn't round-trip through the commit:\nwant %q\ngot  %q", name, message, parsedMessage)
+			}
+		}
+	})
+}
+
+func TestFindRepoRoot(t *testing.T) {
+	repoPath, err := filepath.EvalSymlinks(newTestRepo(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	folderPath := filepath.Join(repoPath, "folder")
+	filePath := filepath.Join(folderPath, "file.txt")
+	if err := os.MkdirAll(folderPath, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filePath, []byte("content\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, path := range []string{repoPath, folderPath, filePath} {
+		root, err := FindRepoRoot(context.Background(), path)
+		if err != nil {
+			t.Fatalf("FindRepoRoot(%s): %v", path, err)
+		}
+		if root != repoPath {
+			t.Errorf("FindRepoRoot(%s) = %s, want %s", path, root, repoPath)
+		}
+	}
+
+	if _, err := FindRepoRoot(context.Background(), t.TempDir()); err == nil {
+		t.Error("FindRepoRoot outside of a repo didn't fail")
+	}
+}
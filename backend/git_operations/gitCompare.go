@@ -0,0 +1,137 @@
+package git_operations
+
+import (
+	"context"
+	"fmt"
+	"gitwhale/backend/logger"
+	"strconv"
+	"strings"
+)
+
+// RefComparison describes how two refs relate to each other, similar to what you'd check before opening a PR
+type RefComparison struct {
+	RepoPath  string `json:"repoPath"`
+	RefA      string `json:"refA"`
+	RefB      string `json:"refB"`
+	MergeBase string `json:"mergeBase"` // Empty when the refs don't share any history
+
+	// The commits reachable from one ref but not the other (limited to the requested number of commits)
+	CommitsOnlyInA []GitLogCommitInfo `json:"commitsOnlyInA"`
+	CommitsOnlyInB []GitLogCommitInfo `json:"commitsOnlyInB"`
+
+	// The total number of commits only in A and only in B
+	AheadCount  int `json:"aheadCount"`
+	BehindCount int `json:"behindCount"`
+
+	// True when A is an ancestor of B, so A can be fast-forwarded to B
+	CanFastForward bool `json:"canFastForward"`
+
+	// The three-dot diff (merge-base vs B). Nil when there's no merge-base
+	DiffSession *DiffSession `json:"diffSession"`
+}
+
+// CompareRefs loads the merge-base, the commits unique to each side, and the three-dot diff session between two refs.
+// Cancelling ctx stops the diff session from being created
+func CompareRefs(ctx context.Context, repoPath, refA, refB string, commitsToLoad int, diffSettings *DiffSettings) (*RefComparison, error) {
+	logger.Log.Info("Comparing refs %s and %s in repo: %s", refA, refB, repoPath)
+
+	if strings.TrimSpace(refA) == "" || strings.TrimSpace(refB) == "" {
+		return nil, fmt.Errorf("both refs must be specified to compare them")
+	}
+
+	if err := validateGitRef(ctx, repoPath, refA); err != nil {
+		return nil, fmt.Errorf("invalid first ref: %v", err)
+	}
+
+	if err := validateGitRef(ctx, repoPath, refB); err != nil {
+		return nil, fmt.Errorf("invalid second ref: %v", err)
+	}
+
+	comparison := &RefComparison{
+		RepoPath:       repoPath,
+		RefA:           refA,
+		RefB:           refB,
+		CommitsOnlyInA: []GitLogCommitInfo{},
+		CommitsOnlyInB: []GitLogCommitInfo{},
+	}
+
+	mergeBase, err := GetMergeBase(ctx, repoPath, refA, refB)
+	if err != nil {
+		return nil, err
+	}
+	comparison.MergeBase = mergeBase
+
+	comparison.AheadCount, comparison.BehindCount, err = getAheadBehindCounts(ctx, repoPath, refA, refB)
+	if err != nil {
+		return nil, err
+	}
+
+	onlyInA := fmt.Sprintf("%s..%s", refB, refA)
+	comparison.CommitsOnlyInA = ReadGitLog(ctx, repoPath, GitLogOptions{CommitsToLoad: &commitsToLoad, FromRef: &onlyInA})
+
+	onlyInB := fmt.Sprintf("%s..%s", refA, refB)
+	comparison.CommitsOnlyInB = ReadGitLog(ctx, repoPath, GitLogOptions{CommitsToLoad: &commitsToLoad, FromRef: &onlyInB})
+
+	if mergeBase == "" {
+		logger.Log.Info("Refs %s and %s don't have a merge-base", refA, refB)
+		return comparison, nil
+	}
+
+	// A is an ancestor of B exactly when it has no commits of its own
+	comparison.CanFastForward = comparison.AheadCount == 0
+
+	// The three-dot diff shows what B changed since it diverged from A
+	comparison.DiffSession, err = CreateDiffSession(ctx, DiffOptions{
+		RepoPath:     repoPath,
+		FromRef:      refB,
+		ToRef:        refA,
+		RangeMode:    DiffRangeThreeDot,
+		DiffSettings: diffSettings,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create the diff session for %s...%s: %v", refA, refB, err)
+	}
+
+	return comparison, nil
+}
+
+// GetMergeBase returns the best common ancestor of two refs, or an empty string if they don't share history
+func GetMergeBase(ctx context.Context, repoPath, refA, refB string) (string, error) {
+	result, err := runGit(ctx, repoPath, "merge-base", refA, refB)
+
+	// merge-base exits with 1 (and no output) when there is no common ancestor
+	if result.ExitCode == 1 && strings.TrimSpace(result.Stdout) == "" && strings.TrimSpace(result.Stderr) == "" {
+		return "", nil
+	}
+
+	if err != nil {
+		return "", fmt.Errorf("failed to find the merge-base of %s and %s: %w", refA, refB, err)
+	}
+
+	return strings.TrimSpace(result.Stdout), nil
+}
+
+// getAheadBehindCounts returns how many commits are only in A, and how many are only in B
+func getAheadBehindCounts(ctx context.Context, repoPath, refA, refB string) (int, int, error) {
+	result, err := runGit(ctx, repoPath, "rev-list", "--left-right", "--count", fmt.Sprintf("%s...%s", refA, refB))
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to count the commits between %s and %s: %w", refA, refB, err)
+	}
+
+	counts := strings.Fields(result.Stdout)
+	if len(counts) != 2 {
+		return 0, 0, fmt.Errorf("unexpected output from git rev-list: %s", result.Stdout)
+	}
+
+	ahead, err := strconv.Atoi(counts[0])
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid ahead count '%s': %v", counts[0], err)
+	}
+
+	behind, err := strconv.Atoi(counts[1])
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid behind count '%s': %v", counts[1], err)
+	}
+
+	return ahead, behind, nil
+}
@@ -4,13 +4,9 @@ import (
 	"context"
 	"crypto/md5"
 	"fmt"
-	"gitwhale/backend/command_utils"
-	"gitwhale/backend/lib"
 	"gitwhale/backend/logger"
 	"os"
-	"os/exec"
 	"path/filepath"
-	"runtime"
 	"strings"
 	"time"
 )
@@ -28,6 +24,16 @@ type DiffSession struct {
 	DirectoryData     *Directory        `json:"directoryData"`
 	HasDiffData       bool              `json:"hasDiffData"`
 	CommitInformation *GitLogCommitInfo `json:"commitInformation"`
+
+	// Lazy sessions don't write anything to disk. Their file contents are loaded with DiffSessionManager.GetFileContent
+	IsLazy   bool                `json:"isLazy"`
+	Manifest []DiffManifestEntry `json:"manifest"`
+
+	// The (normalized) options the session was created with, so it can be recreated after a restart
+	Options DiffOptions `json:"options"`
+
+	// How many bytes the session's files take up on disk
+	DiskSize int64 `json:"diskSize"`
 }
 
 type DiffOptions struct {
@@ -41,119 +47,154 @@ type DiffOptions struct {
 
 	// Whether the "toRef" property is trying to reference the user's working directory changes, or if the user is diffing a single commit with it's parent
 	IsSingleCommitDiff bool `json:"isSingleCommitDiff"`
+
+	// Which parent a single commit diff compares against (1-based, defaults to the first parent).
+	// Merge commits can be diffed against each of their parents
+	ParentIndex int `json:"parentIndex"`
+
+	// How the two refs are compared (two-dot by default)
+	RangeMode DiffRangeMode `json:"rangeMode"`
+
+	// Lines of context around each change for unified diffs (defaults to 3)
+	ContextLines *int `json:"contextLines"`
+
+	// Limits the diff to the matching paths
+	Pathspecs []string `json:"pathspecs"`
+
+	// How git should compute the diff. Nil falls back to the user's defaults from GitSettings
+	DiffSettings *DiffSettings `json:"diffSettings"`
+
+	// Only load the list of changed files up front, and fetch their contents on demand
+	LazyLoad bool `json:"lazyLoad"`
 }
 
-// Parses script output and re-logs [DIFF-SCRIPT] messages through backend Logger
-func parseAndRelogScriptOutput(output string) {
-	if output == "" {
-		logger.Log.Debug("DIFF-SCRIPT had no output to re-log")
-		return
-	}
+// The right side is the working tree, which keeps changing after the diff was made
+func (options DiffOptions) comparesWorkingTree() bool {
+	return options.ToRef == "" && !options.IsSingleCommitDiff
+}
 
-	lines := strings.Split(output, "\n")
-	for _, line := range lines {
-		line = strings.TrimSpace(line)
-		if line == "" {
-			continue
-		}
+type DiffRangeMode string
 
-		// Check if this is a [DIFF-SCRIPT] message
-		if strings.HasPrefix(line, "[DIFF-SCRIPT]") {
-			// Remove the [DIFF-SCRIPT] prefix for cleaner backend logging
-			message := strings.TrimSpace(strings.TrimPrefix(line, "[DIFF-SCRIPT]"))
-
-			// Determine log level based on message content
-			if strings.Contains(message, "ERROR:") {
-				logger.Log.Error("DIFF-SCRIPT: %s", message)
-			} else if strings.Contains(message, "Starting") || strings.Contains(message, "completed") {
-				logger.Log.Info("DIFF-SCRIPT: %s", message)
-			} else {
-				logger.Log.Debug("DIFF-SCRIPT: %s", message)
-			}
-		}
-	}
+const (
+	// ToRef..FromRef: the direct difference between the two refs
+	DiffRangeTwoDot DiffRangeMode = ""
+
+	// ToRef...FromRef: only the changes made on FromRef since it diverged from ToRef
+	DiffRangeThreeDot DiffRangeMode = "threeDot"
+)
+
+type WhitespaceMode string
+
+const (
+	WhitespaceShowAll      WhitespaceMode = ""
+	WhitespaceIgnoreAll    WhitespaceMode = "all"
+	WhitespaceIgnoreChange WhitespaceMode = "change"
+	WhitespaceIgnoreAtEol  WhitespaceMode = "eol"
+)
+
+type DiffAlgorithm string
+
+const (
+	DiffAlgorithmDefault   DiffAlgorithm = ""
+	DiffAlgorithmMyers     DiffAlgorithm = "myers"
+	DiffAlgorithmMinimal   DiffAlgorithm = "minimal"
+	DiffAlgorithmPatience  DiffAlgorithm = "patience"
+	DiffAlgorithmHistogram DiffAlgorithm = "histogram"
+)
+
+// DiffSettings controls how git computes diffs
+type DiffSettings struct {
+	IgnoreWhitespace WhitespaceMode `json:"ignoreWhitespace"`
+	IgnoreBlankLines bool           `json:"ignoreBlankLines"`
+	Algorithm        DiffAlgorithm  `json:"algorithm"`
+
+	// Similarity percentages for rename and copy detection. A rename threshold of 0 uses git's default (50%),
+	// and a copy threshold of 0 turns copy detection off
+	RenameThreshold int `json:"renameThreshold"`
+	CopyThreshold   int `json:"copyThreshold"`
 }
 
-// Ensures git difftool is configured to use our helper script
-func SetupGitDirDiffHelperScript() error {
-	scriptPath, err := saveNewHelperDiffScript()
-	if err != nil {
-		return fmt.Errorf("failed to ensure helper script: %v", err)
+// gitArgs converts the settings into `git diff` arguments
+func (settings *DiffSettings) gitArgs() []string {
+	if settings == nil {
+		return []string{"--find-renames"}
 	}
 
-	toolName := HELPER_SCRIPT_TOOL_NAME
-	configKey := "difftool." + toolName + ".cmd"
-	configValue := scriptPath + " \"$LOCAL\" \"$REMOTE\""
+	args := []string{}
+	switch settings.IgnoreWhitespace {
+	case WhitespaceIgnoreAll:
+		args = append(args, "--ignore-all-space")
+	case WhitespaceIgnoreChange:
+		args = append(args, "--ignore-space-change")
+	case WhitespaceIgnoreAtEol:
+		args = append(args, "--ignore-space-at-eol")
+	}
 
-	// Check if already configured correctly
-	checkCmd := exec.Command("git", "config", "--global", configKey)
-	if output, exitCode, err := command_utils.RunCommandAndLogErr(checkCmd); err == nil || exitCode != 0 {
-		currentValue := strings.TrimSpace(string(output))
-		if currentValue == configValue {
-			logger.Log.Debug("Git difftool already configured correctly")
-			return nil
-		}
+	if settings.IgnoreBlankLines {
+		args = append(args, "--ignore-blank-lines")
 	}
 
-	// Set the configuration
-	configCmd := exec.Command("git", "config", "--global", configKey, configValue)
-	if _, exitCode, err := command_utils.RunCommandAndLogErr(configCmd); err != nil || exitCode != 0 {
-		return fmt.Errorf("failed to configure git difftool: %v", err)
+	if settings.Algorithm != DiffAlgorithmDefault {
+		args = append(args, "--diff-algorithm="+string(settings.Algorithm))
 	}
 
-	logger.Log.Info("Configured git difftool to use helper script: %s", scriptPath)
-	return nil
+	if settings.RenameThreshold > 0 {
+		args = append(args, fmt.Sprintf("--find-renames=%d%%", settings.RenameThreshold))
+	} else {
+		args = append(args, "--find-renames")
+	}
+
+	if settings.CopyThreshold > 0 {
+		args = append(args, fmt.Sprintf("--find-copies=%d%%", settings.CopyThreshold))
+	}
+
+	return args
 }
 
-// Ensures the helper diff script exists and returns its path
-func saveNewHelperDiffScript() (string, error) {
-	appFolderPath, err := lib.GetAppFolderPath()
-	if err != nil {
-		return "", err
+// Validate checks that the settings only hold values git understands
+func (settings *DiffSettings) Validate() error {
+	if settings == nil {
+		return nil
 	}
 
-	var scriptName, scriptContent string
-	if runtime.GOOS == "windows" {
-		scriptName = "gitwhale-diff.bat"
-		scriptContent = WINDOWS_HELPER_SCRIPT_CONTENTS
-	} else {
-		scriptName = "gitwhale-diff.sh"
-		scriptContent = UNIX_HELPER_SCRIPT_CONTENTS
+	switch settings.IgnoreWhitespace {
+	case WhitespaceShowAll, WhitespaceIgnoreAll, WhitespaceIgnoreChange, WhitespaceIgnoreAtEol:
+	default:
+		return fmt.Errorf("unsupported whitespace mode: %s", settings.IgnoreWhitespace)
 	}
 
-	scriptPath := filepath.Join(appFolderPath, scriptName)
-	scriptPath, err = filepath.Abs(scriptPath)
-	if err != nil {
-		return "", fmt.Errorf("failed to resolve the path where the helper script should be saved to: %v", err)
+	switch settings.Algorithm {
+	case DiffAlgorithmDefault, DiffAlgorithmMyers, DiffAlgorithmMinimal, DiffAlgorithmPatience, DiffAlgorithmHistogram:
+	default:
+		return fmt.Errorf("unsupported diff algorithm: %s", settings.Algorithm)
 	}
-	scriptPath = filepath.Clean(scriptPath)
-	if runtime.GOOS == "windows" {
-		scriptPath = strings.Replace(scriptPath, "\\", "/", -1)
+
+	if settings.RenameThreshold < 0 || settings.RenameThreshold > 100 {
+		return fmt.Errorf("rename threshold must be between 0 and 100, got %d", settings.RenameThreshold)
 	}
 
-	// Create the script
-	err = os.WriteFile(scriptPath, []byte(scriptContent), 0755)
-	if err != nil {
-		return "", fmt.Errorf("failed to write helper script: %v", err)
+	if settings.CopyThreshold < 0 || settings.CopyThreshold > 100 {
+		return fmt.Errorf("copy threshold must be between 0 and 100, got %d", settings.CopyThreshold)
 	}
 
-	logger.Log.Info("Created helper diff script: %s", scriptPath)
-	return scriptPath, nil
+	return nil
 }
 
-// Creates a new diff session with simplified flow
-func CreateDiffSession(options DiffOptions) (*DiffSession, error) {
+// Creates a new diff session with simplified flow. Cancelling ctx stops writing the files
+func CreateDiffSession(ctx context.Context, options DiffOptions) (*DiffSession, error) {
 	logger.Log.Info("Creating diff session for repo: %s, from: %s, to: %s", options.RepoPath, options.FromRef, options.ToRef)
 
-	if options.IsSingleCommitDiff {
-		options.ToRef = fmt.Sprintf("%s^", options.FromRef)
-	}
+	options = normalizeDiffOptions(options)
 
 	// Step 1: Validate all inputs
-	if err := validateDiffInputs(options); err != nil {
+	if err := validateDiffInputs(ctx, options); err != nil {
 		return nil, err
 	}
 
+	if options.LazyLoad {
+		return createLazyDiffSession(ctx, options)
+	}
+
 	// Step 2: Generate session ID and create destinations
 	sessionId := generateSessionId(options)
 	logger.Log.Debug("Created diff session ID: %s", sessionId)
@@ -163,8 +204,8 @@ func CreateDiffSession(options DiffOptions) (*DiffSession, error) {
 		return nil, err
 	}
 
-	// Step 3: Execute enhanced script (does all the copying)
-	changesFound, err := executeDiffScript(options.RepoPath, options.FromRef, options.ToRef, leftPath, rightPath)
+	// Step 3: Write the changed files into the session folders
+	changesFound, err := materializeDiff(ctx, options, leftPath, rightPath)
 	if err != nil {
 		CleanupDiffSession(sessionId) // Cleanup on failure
 		return nil, err
@@ -184,6 +225,7 @@ func CreateDiffSession(options DiffOptions) (*DiffSession, error) {
 		DirectoryData:     nil,
 		HasDiffData:       changesFound,
 		CommitInformation: nil,
+		Options:           options,
 	}
 
 	if !changesFound {
@@ -192,7 +234,7 @@ func CreateDiffSession(options DiffOptions) (*DiffSession, error) {
 	}
 
 	if options.IsSingleCommitDiff {
-		session.CommitInformation, err = GetGitLogCommitInfo(options.RepoPath, options.FromRef)
+		session.CommitInformation, err = GetGitLogCommitInfo(ctx, options.RepoPath, options.FromRef)
 		if err != nil {
 			CleanupDiffSession(sessionId)
 			return nil, fmt.Errorf("failed to load information about the commit %s", options.FromRef)
@@ -211,7 +253,7 @@ func CreateDiffSession(options DiffOptions) (*DiffSession, error) {
 }
 
 // Validates all inputs required for diff operation
-func validateDiffInputs(options DiffOptions) error {
+func validateDiffInputs(ctx context.Context, options DiffOptions) error {
 	// Check repository path exists
 	if options.RepoPath == "" {
 		return fmt.Errorf("repository path cannot be empty")
@@ -223,18 +265,16 @@ func validateDiffInputs(options DiffOptions) error {
 
 	// Check if it's a git repository
 	// TODO: only need to check then when opening a repo. Move there please
-	cmd := exec.Command("git", "rev-parse", "--git-dir")
-	cmd.Dir = options.RepoPath
-	if _, exitCode, err := command_utils.RunCommandAndLogErr(cmd); err != nil || exitCode != 0 {
+	if _, err := runGit(ctx, options.RepoPath, "rev-parse", "--git-dir"); err != nil {
 		return fmt.Errorf("not a valid git repository: %s", options.RepoPath)
 	}
 
 	// Validate refs if provided
-	if err := validateGitRef(options.RepoPath, options.FromRef); err != nil {
+	if err := validateGitRef(ctx, options.RepoPath, options.FromRef); err != nil {
 		return fmt.Errorf("invalid fromRef: %v", err)
 	}
 
-	if err := validateGitRef(options.RepoPath, options.ToRef); err != nil {
+	if err := validateGitRef(ctx, options.RepoPath, options.ToRef); err != nil {
 		return fmt.Errorf("invalid toRef: %v", err)
 	}
 
@@ -243,20 +283,36 @@ func validateDiffInputs(options DiffOptions) error {
 		return fmt.Errorf("at least one reference must be specified")
 	}
 
+	switch options.RangeMode {
+	case DiffRangeTwoDot:
+	case DiffRangeThreeDot:
+		if options.FromRef == "" || options.ToRef == "" {
+			return fmt.Errorf("a three-dot diff needs both refs, it can't be used with the working tree")
+		}
+	default:
+		return fmt.Errorf("unsupported range mode: %s", options.RangeMode)
+	}
+
+	if options.ParentIndex < 0 {
+		return fmt.Errorf("parent index cannot be negative")
+	}
+
+	if err := options.DiffSettings.Validate(); err != nil {
+		return fmt.Errorf("invalid diff settings: %v", err)
+	}
+
 	return nil
 }
 
 // Validates that a git ref exists and is valid
-func validateGitRef(repoPath, ref string) error {
+func validateGitRef(ctx context.Context, repoPath, ref string) error {
 	if ref == "" {
 		return nil
 	}
 
-	cmd := exec.Command("git", "rev-parse", "--verify", ref+"^{commit}")
-	cmd.Dir = repoPath
-	output, exitCode, err := command_utils.RunCommandAndLogErr(cmd)
-	if err != nil || exitCode != 0 {
-		gitError := strings.TrimSpace(string(output))
+	result, err := runGit(ctx, repoPath, "rev-parse", "--verify", ref+"^{commit}")
+	if err != nil {
+		gitError := strings.TrimSpace(result.Stderr)
 		if gitError != "" {
 			return fmt.Errorf("invalid git reference '%s': %s", ref, gitError)
 		}
@@ -269,8 +325,7 @@ func validateGitRef(repoPath, ref string) error {
 
 // Creates destination directories for diff session
 func createDiffDestinations(sessionId string) (leftPath, rightPath string, err error) {
-	tempDir := os.TempDir()
-	sessionDir := filepath.Join(tempDir, "gitwhale-diff", sessionId)
+	sessionDir := getDiffSessionFolder(sessionId)
 
 	leftPath = filepath.Join(sessionDir, "left")
 	rightPath = filepath.Join(sessionDir, "right")
@@ -286,123 +341,68 @@ func createDiffDestinations(sessionId string) (leftPath, rightPath string, err e
 	return leftPath, rightPath, nil
 }
 
-// Executes the diff script using helper script and environment variables
-// Boolean is for whether there were any changes found
-func executeDiffScript(repoPath, fromRef, toRef, leftDest, rightDest string) (bool, error) {
-	logger.Log.Info("Starting diff operation for repo: %s,  %s -> %s", repoPath, fromRef, toRef)
-	logger.Log.Debug("Diff destinations - Left: %s, Right: %s", leftDest, rightDest)
-
-	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
-	defer cancel()
-
-	// Build git difftool command
-	toolName := HELPER_SCRIPT_TOOL_NAME
-	var cmdArgs []string
-	if toRef == "" {
-		cmdArgs = []string{"difftool", "-d", "--tool=" + toolName, "--no-prompt", fromRef}
-	} else {
-		cmdArgs = []string{"difftool", "-d", "--tool=" + toolName, "--no-prompt", toRef, fromRef}
-	}
+func generateSessionId(options DiffOptions) string {
+	data := fmt.Sprintf("%s-%s-%s-%d", options.RepoPath, options.FromRef, options.ToRef, time.Now().UnixNano())
+	hash := md5.Sum([]byte(data))
+	return fmt.Sprintf("diff_%x", hash)[:16]
+}
 
-	// Execute git difftool with environment variables
-	cmd := exec.CommandContext(ctx, "git", cmdArgs...)
-	cmd.Dir = repoPath
+// DiffTitle describes the diff like the title of its diff session, e.g. "main...feature -- src/foo"
+func DiffTitle(options DiffOptions) string {
+	return generateDiffTitle(normalizeDiffOptions(options))
+}
 
-	// Set environment variables for the script
-	envVars := []string{
-		"GITWHALE_LEFT_DEST=" + leftDest,
-		"GITWHALE_RIGHT_DEST=" + rightDest,
+// Describes the diff with git's range notation (e.g. "main...feature -- src/foo"). Expects normalized options
+func generateDiffTitle(options DiffOptions) string {
+	var title string
+	switch {
+	case options.ToRef == "":
+		title = fmt.Sprintf("%s vs Working Tree", options.FromRef)
+	case options.RangeMode == DiffRangeThreeDot:
+		title = fmt.Sprintf("%s...%s", options.ToRef, options.FromRef)
+	default:
+		title = fmt.Sprintf("%s..%s", options.ToRef, options.FromRef)
 	}
-	cmd.Env = append(os.Environ(), envVars...)
-
-	// Log detailed command information
-	logger.Log.Debug("Executing git difftool command: %s", strings.Join(cmdArgs, " "))
-	logger.Log.Debug("Working directory: %s", repoPath)
-	logger.Log.Debug("Environment variables: %v", envVars)
-	logger.Log.Debug("Command timeout: 60 seconds")
-
-	startTime := time.Now()
-	outputStr, exitCode, err := command_utils.RunCommandAndLogErr(cmd)
-	duration := time.Since(startTime)
 
-	logger.Log.Debug("Git difftool execution completed in %v", duration)
-	logger.Log.Debug("Git difftool output length: %d bytes", len(outputStr))
-	if len(outputStr) > 0 {
-		logger.Log.Debug("Git difftool output: %s", outputStr)
+	if len(options.Pathspecs) > 0 {
+		title = fmt.Sprintf("%s -- %s", title, strings.Join(options.Pathspecs, " "))
 	}
+	return title
+}
 
-	// Parse and re-log [DIFF-SCRIPT] messages through backend Logger (for both success and error cases)
-	parseAndRelogScriptOutput(outputStr)
-
-	if len(outputStr) == 0 {
-		logger.Log.Debug("Git difftool found changes")
-		return false, nil
+// CreateMergeCommitDiffSessions diffs a (merge) commit against each of its parents, one session per parent
+func CreateMergeCommitDiffSessions(ctx context.Context, options DiffOptions) ([]*DiffSession, error) {
+	commit, err := GetGitLogCommitInfo(ctx, options.RepoPath, options.FromRef)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load information about the commit %s: %v", options.FromRef, err)
 	}
 
-	if err != nil || exitCode != 0 {
-		logger.Log.Error("Git difftool command failed with error: %v", err)
-
-		// Check for exit error to get more details
-		if exitError, ok := err.(*exec.ExitError); ok {
-			logger.Log.Error("Git difftool exit code: %d", exitError.ExitCode())
-			logger.Log.Error("Git difftool stderr: %s", string(exitError.Stderr))
-		}
-
-		// Check if it's a git error
-		if strings.Contains(outputStr, "fatal:") || strings.Contains(outputStr, "error:") {
-			logger.Log.Error("Git reported error in difftool operation: %s", outputStr)
-			return false, fmt.Errorf("git difftool failed: %s", outputStr)
-		}
-
-		logger.Log.Error("Diff operation failed with non-git error: %v", err)
-		return false, fmt.Errorf("diff operation failed: %v", err)
+	if len(commit.ParentCommitHashes) == 0 {
+		return nil, fmt.Errorf("commit %s has no parents to diff against", options.FromRef)
 	}
 
-	logger.Log.Debug("Git difftool command completed successfully")
-
-	// Check script output for success/failure
-	if strings.Contains(outputStr, "ERROR:") {
-		logger.Log.Error("Diff script reported error in output: %s", outputStr)
+	sessions := []*DiffSession{}
+	for parentIndex := 1; parentIndex <= len(commit.ParentCommitHashes); parentIndex++ {
+		parentOptions := options
+		parentOptions.IsSingleCommitDiff = true
+		parentOptions.ParentIndex = parentIndex
+		parentOptions.RangeMode = DiffRangeTwoDot
 
-		// Extract error message
-		lines := strings.Split(outputStr, "\n")
-		for _, line := range lines {
-			if strings.HasPrefix(line, "ERROR:") {
-				errorMsg := strings.TrimPrefix(line, "ERROR:")
-				logger.Log.Error("Extracted error message: %s", errorMsg)
-				return false, fmt.Errorf("diff script failed: %s", errorMsg)
+		session, err := CreateDiffSession(ctx, parentOptions)
+		if err != nil {
+			for _, createdSession := range sessions {
+				CleanupDiffSession(createdSession.SessionId)
 			}
+			return nil, fmt.Errorf("failed to diff %s against parent %d: %w", options.FromRef, parentIndex, err)
 		}
-		logger.Log.Error("Error indicator found but no specific error message extracted")
-		return false, fmt.Errorf("diff script failed with unknown error")
+		sessions = append(sessions, session)
 	}
 
-	if !strings.Contains(outputStr, "SUCCESS") {
-		logger.Log.Warning("No SUCCESS indicator found in diff script output: %s", outputStr)
-		return false, fmt.Errorf("diff script did not report success: %s", outputStr)
-	}
-
-	logger.Log.Debug("Diff script reported success: found SUCCESS indicator")
-	logger.Log.Info("Diff operation completed successfully")
-	return true, nil
-}
-
-func generateSessionId(options DiffOptions) string {
-	data := fmt.Sprintf("%s-%s-%s-%d", options.RepoPath, options.FromRef, options.ToRef, time.Now().UnixNano())
-	hash := md5.Sum([]byte(data))
-	return fmt.Sprintf("diff_%x", hash)[:16]
-}
-
-func generateDiffTitle(options DiffOptions) string {
-	if options.ToRef == "" {
-		return fmt.Sprintf("%s vs Working Tree", options.FromRef)
-	}
-	return fmt.Sprintf("%s vs %s", options.FromRef, options.ToRef)
+	return sessions, nil
 }
 
 func CleanupDiffSession(sessionId string) error {
-	tempDir := os.TempDir()
-	sessionDir := filepath.Join(tempDir, "gitwhale-diff", sessionId)
+	sessionDir := getDiffSessionFolder(sessionId)
 
 	logger.Log.Info("Cleaning up diff session: %s", sessionId)
 	err := os.RemoveAll(sessionDir)
@@ -420,40 +420,6 @@ func GetDiffSessionDirectory(session *DiffSession) *Directory {
 	}
 
 	logger.Log.Info("Getting directory structure for diff session: %s", session.SessionId)
-	session.LastAccessed = time.Now()
-
-	return ReadDiffs(session.LeftPath, session.RightPath)
-}
-
-func CleanupOldDiffSessions() error {
-	tempDir := os.TempDir()
-	diffDir := filepath.Join(tempDir, "gitwhale-diff")
-
-	entries, err := os.ReadDir(diffDir)
-	if err != nil {
-		if os.IsNotExist(err) {
-			return nil
-		}
-		return err
-	}
 
-	cutoff := time.Now().Add(-24 * time.Hour)
-	for _, entry := range entries {
-		if !entry.IsDir() {
-			continue
-		}
-
-		sessionPath := filepath.Join(diffDir, entry.Name())
-		info, err := entry.Info()
-		if err != nil {
-			continue
-		}
-
-		if info.ModTime().Before(cutoff) {
-			logger.Log.Info("Cleaning up old diff session: %s", entry.Name())
-			os.RemoveAll(sessionPath)
-		}
-	}
-
-	return nil
+	return ReadDiffs(session.LeftPath, session.RightPath, false)
 }
@@ -0,0 +1,228 @@
+package git_operations
+
+import (
+	"context"
+	"fmt"
+	"gitwhale/backend/command_utils"
+	"gitwhale/backend/logger"
+	"strconv"
+	"strings"
+)
+
+// GrepTarget selects which version of the repository's content gets searched
+type GrepTarget string
+
+const (
+	GrepWorkingTree GrepTarget = "workingTree"
+	GrepIndex       GrepTarget = "index"
+	GrepRef         GrepTarget = "ref"
+)
+
+type GrepOptions struct {
+	RepoPath string `json:"repoPath"`
+	Query    string `json:"query"`
+
+	// Where to search, and the ref to search in when Target is "ref"
+	Target GrepTarget `json:"target"`
+	Ref    string     `json:"ref"`
+
+	IsRegex       bool     `json:"isRegex"`
+	CaseSensitive bool     `json:"caseSensitive"`
+	Pathspecs     []string `json:"pathspecs"`
+	ContextLines  int      `json:"contextLines"`
+}
+
+// GrepLine is either a matching line, or a context line around a match
+type GrepLine struct {
+	LineNumber   int    `json:"lineNumber"`
+	Column       int    `json:"column"` // 1-based byte offset of the first match, 0 for context lines
+	Text         string `json:"text"`
+	IsMatch      bool   `json:"isMatch"`
+	IsGroupStart bool   `json:"isGroupStart"` // True when this line isn't contiguous with the previous one
+}
+
+// GrepFileResult holds all the matches found in a single file
+type GrepFileResult struct {
+	Path string `json:"path"`
+
+	// Where the file was searched, to open it with GetGrepFileContent. Ref is only set for GrepRef
+	Target GrepTarget `json:"target"`
+	Ref    string     `json:"ref"`
+
+	Lines      []GrepLine `json:"lines"`
+	MatchCount int        `json:"matchCount"`
+}
+
+// StartGrepSearch runs `git grep` in the background and streams a GrepFileResult to the topic for every
+// file with matches. The frontend can cancel the search the same way as any other streamed command
+func StartGrepSearch(ctx context.Context, options GrepOptions, broadcastToTopic string) error {
+	logger.Log.Info("Starting grep search for '%s' in repo: %s", options.Query, options.RepoPath)
+
+	args, err := buildGrepArgs(ctx, options)
+	if err != nil {
+		return err
+	}
+
+	parser := &grepOutputParser{target: options.Target}
+	if parser.target == "" {
+		parser.target = GrepWorkingTree
+	}
+	if options.Target == GrepRef {
+		parser.ref = options.Ref
+		parser.pathPrefix = options.Ref + ":"
+	}
+
+	command_utils.StartRunningAndStreamParsedCommand(ctx, append([]string{"git"}, args...), options.RepoPath, broadcastToTopic, parser, false)
+	return nil
+}
+
+func buildGrepArgs(ctx context.Context, options GrepOptions) ([]string, error) {
+	if options.Query == "" {
+		return nil, fmt.Errorf("search query cannot be empty")
+	}
+
+	if options.ContextLines < 0 {
+		return nil, fmt.Errorf("context lines cannot be negative")
+	}
+
+	args := []string{"grep", "-n", "-z", "--column", "--full-name", "--no-color", "-I"}
+
+	if options.IsRegex {
+		args = append(args, "-E")
+	} else {
+		args = append(args, "-F")
+	}
+
+	if !options.CaseSensitive {
+		args = append(args, "-i")
+	}
+
+	if options.ContextLines > 0 {
+		args = append(args, fmt.Sprintf("-C%d", options.ContextLines))
+	}
+
+	args = append(args, "-e", options.Query)
+
+	switch options.Target {
+	case GrepWorkingTree, "":
+	case GrepIndex:
+		args = append(args, "--cached")
+	case GrepRef:
+		if err := validateGitRef(ctx, options.RepoPath, options.Ref); err != nil || options.Ref == "" {
+			return nil, fmt.Errorf("invalid ref to search in: '%s'", options.Ref)
+		}
+		args = append(args, options.Ref)
+	default:
+		return nil, fmt.Errorf("unsupported grep target: %s", options.Target)
+	}
+
+	args = append(args, "--")
+	args = append(args, options.Pathspecs...)
+	return args, nil
+}
+
+// GetGrepFileContent loads the version of a file that a search ran in. The target decides where it's read from,
+// so a branch that happens to be called "index" is still read from the branch
+func GetGrepFileContent(ctx context.Context, repoPath string, target GrepTarget, ref, filePath string) (string, error) {
+	switch target {
+	case GrepWorkingTree, "":
+		return GetWorkingDirectoryFileContent(repoPath, filePath)
+	case GrepIndex:
+		return readFileFromGitObject(ctx, repoPath, filePath, ":"+filePath)
+	case GrepRef:
+		if ref == "" {
+			return "", fmt.Errorf("a ref is required to read %s from", filePath)
+		}
+		return readFileFromGitObject(ctx, repoPath, filePath, ref+":"+filePath)
+	default:
+		return "", fmt.Errorf("unsupported grep target: %s", target)
+	}
+}
+
+// grepOutputParser groups the lines printed by `git grep -n -z --column` into per-file results.
+// Match lines look like "path\0line\0column\0text", context lines like "path\0line\0text",
+// and "--" separates non-contiguous groups of lines
+type grepOutputParser struct {
+	pathPrefix       string
+	target           GrepTarget
+	ref              string
+	current          *GrepFileResult
+	nextIsGroupStart bool
+}
+
+func (parser *grepOutputParser) ParseLine(line string) *command_utils.StreamedCommandEvent {
+	if line == "--" {
+		parser.nextIsGroupStart = true
+		return nil
+	}
+
+	fields := strings.SplitN(line, "\x00", 4)
+	if len(fields) < 3 {
+		logger.Log.Warning("Skipping unexpected git grep output line: %q", line)
+		return nil
+	}
+
+	path := strings.TrimPrefix(fields[0], parser.pathPrefix)
+	lineNumber, err := strconv.Atoi(fields[1])
+	if err != nil {
+		logger.Log.Warning("Skipping git grep output line with an invalid line number: %q", line)
+		return nil
+	}
+
+	grepLine := GrepLine{LineNumber: lineNumber, Text: fields[2]}
+	if len(fields) == 4 {
+		column, err := strconv.Atoi(fields[2])
+		if err != nil {
+			logger.Log.Warning("Skipping git grep output line with an invalid column: %q", line)
+			return nil
+		}
+		grepLine.Column = column
+		grepLine.Text = fields[3]
+		grepLine.IsMatch = true
+	}
+
+	// A new file means the previous one is complete
+	var completed *GrepFileResult
+	if parser.current != nil && parser.current.Path != path {
+		completed = parser.current
+		parser.current = nil
+	}
+
+	if parser.current == nil {
+		parser.current = &GrepFileResult{
+			Path:   path,
+			Target: parser.target,
+			Ref:    parser.ref,
+			Lines:  []GrepLine{},
+		}
+		parser.nextIsGroupStart = true
+	}
+
+	grepLine.IsGroupStart = parser.nextIsGroupStart
+	parser.nextIsGroupStart = false
+
+	parser.current.Lines = append(parser.current.Lines, grepLine)
+	if grepLine.IsMatch {
+		parser.current.MatchCount++
+	}
+
+	if completed == nil {
+		return nil
+	}
+	return &command_utils.StreamedCommandEvent{Data: completed}
+}
+
+func (parser *grepOutputParser) Flush() *command_utils.StreamedCommandEvent {
+	if parser.current == nil {
+		return nil
+	}
+
+	completed := parser.current
+	parser.current = nil
+	return &command_utils.StreamedCommandEvent{Data: completed}
+}
+
+// git grep exits with 1 when nothing matched
+func (parser *grepOutputParser) IsSuccessExitCode(exitCode int) bool {
+	return exitCode == 1
+}
@@ -0,0 +1,157 @@
+package git_operations
+
+import (
+	"bufio"
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// Feeds the output to the parser line by line, split the same way as the streamed stdout of a command
+func parseTestGrepOutput(t *testing.T, parser *grepOutputParser, output string) []GrepFileResult {
+	t.Helper()
+
+	results := []GrepFileResult{}
+	collect := func(event any) {
+		if event != nil {
+			results = append(results, *event.(*GrepFileResult))
+		}
+	}
+
+	scanner := bufio.NewScanner(strings.NewReader(output))
+	for scanner.Scan() {
+		if event := parser.ParseLine(scanner.Text()); event != nil {
+			collect(event.Data)
+		}
+	}
+	if event := parser.Flush(); event != nil {
+		collect(event.Data)
+	}
+	return results
+}
+
+func TestGrepOutputParser(t *testing.T) {
+	testCases := []struct {
+		name   string
+		parser *grepOutputParser
+		output string
+		want   []GrepFileResult
+	}{
+		{
+			// A branch called "index", with context lines around the matches. CRLF line endings are dropped along with
+			// the line, while a lone \r stays part of the text
+			name:   "ref",
+			parser: &grepOutputParser{target: GrepRef, ref: "index", pathPrefix: "index:"},
+			output: "index:crlf.txt\x001\x007\x00first needle\r\n" +
+				"index:crlf.txt\x002\x00second line\r\n" +
+				"--\n" +
+				"index:crlf.txt\x004\x00fourth\r\n" +
+				"index:crlf.txt\x005\x007\x00fifth needle\r\n" +
+				"--\n" +
+				"index:dir/a:b.txt\x001\x0014\x00progress 10%\rneedle 100%\n",
+			want: []GrepFileResult{
+				{
+					Path: "crlf.txt", Target: GrepRef, Ref: "index", MatchCount: 2,
+					Lines: []GrepLine{
+						{LineNumber: 1, Column: 7, Text: "first needle", IsMatch: true, IsGroupStart: true},
+						{LineNumber: 2, Text: "second line"},
+						{LineNumber: 4, Text: "fourth", IsGroupStart: true},
+						{LineNumber: 5, Column: 7, Text: "fifth needle", IsMatch: true},
+					},
+				},
+				{
+					Path: "dir/a:b.txt", Target: GrepRef, Ref: "index", MatchCount: 1,
+					Lines: []GrepLine{
+						{LineNumber: 1, Column: 14, Text: "progress 10%\rneedle 100%", IsMatch: true, IsGroupStart: true},
+					},
+				},
+			},
+		},
+		{
+			name:   "index",
+			parser: &grepOutputParser{target: GrepIndex},
+			output: "crlf.txt\x001\x007\x00first needle\r\n" +
+				"crlf.txt\x005\x007\x00fifth needle\r\n" +
+				"dir/a:b.txt\x001\x0014\x00progress 10%\rneedle 100%\n",
+			want: []GrepFileResult{
+				{
+					Path: "crlf.txt", Target: GrepIndex, MatchCount: 2,
+					Lines: []GrepLine{
+						{LineNumber: 1, Column: 7, Text: "first needle", IsMatch: true, IsGroupStart: true},
+						{LineNumber: 5, Column: 7, Text: "fifth needle", IsMatch: true},
+					},
+				},
+				{
+					Path: "dir/a:b.txt", Target: GrepIndex, MatchCount: 1,
+					Lines: []GrepLine{
+						{LineNumber: 1, Column: 14, Text: "progress 10%\rneedle 100%", IsMatch: true, IsGroupStart: true},
+					},
+				},
+			},
+		},
+		{
+			name:   "separators inside the text",
+			parser: &grepOutputParser{target: GrepWorkingTree},
+			output: "notes.txt\x003\x001\x00a:b\x00c\n" +
+				"not a grep line\n",
+			want: []GrepFileResult{
+				{
+					Path: "notes.txt", Target: GrepWorkingTree, MatchCount: 1,
+					Lines: []GrepLine{
+						{LineNumber: 3, Column: 1, Text: "a:b\x00c", IsMatch: true, IsGroupStart: true},
+					},
+				},
+			},
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			results := parseTestGrepOutput(t, testCase.parser, testCase.output)
+			if !reflect.DeepEqual(results, testCase.want) {
+				t.Fatalf("unexpected results:\nwant %+v\ngot  %+v", testCase.want, results)
+			}
+		})
+	}
+}
+
+func TestGetGrepFileContentDoesNotMistakeRefsForTheIndex(t *testing.T) {
+	repoPath := newTestRepo(t)
+	writeFile := func(content string) {
+		if err := os.WriteFile(filepath.Join(repoPath, "file.txt"), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	writeFile("committed\n")
+	runTestGit(t, repoPath, "add", "file.txt")
+	runTestGit(t, repoPath, "commit", "--quiet", "-m", "initial")
+	runTestGit(t, repoPath, "branch", "index")
+
+	writeFile("staged\n")
+	runTestGit(t, repoPath, "add", "file.txt")
+	writeFile("working\n")
+
+	testCases := []struct {
+		target GrepTarget
+		ref    string
+		want   string
+	}{
+		{target: GrepRef, ref: "index", want: "committed\n"},
+		{target: GrepIndex, want: "staged\n"},
+		{target: GrepWorkingTree, want: "working\n"},
+	}
+
+	for _, testCase := range testCases {
+		content, err := GetGrepFileContent(context.Background(), repoPath, testCase.target, testCase.ref, "file.txt")
+		if err != nil {
+			t.Fatalf("%s %s: %v", testCase.target, testCase.ref, err)
+		}
+		if content != testCase.want {
+			t.Errorf("%s %s: want %q, got %q", testCase.target, testCase.ref, testCase.want, content)
+		}
+	}
+}
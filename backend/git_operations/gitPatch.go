@@ -0,0 +1,473 @@
+package git_operations
+
+import (
+	"context"
+	"fmt"
+	"gitwhale/backend/command_utils"
+	"gitwhale/backend/lib"
+	"gitwhale/backend/logger"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+type PatchFormat string
+
+const (
+	PatchFormatMbox    PatchFormat = "mbox"    // One `git format-patch` file per commit
+	PatchFormatUnified PatchFormat = "unified" // The whole range as a single `git diff` patch
+)
+
+// PatchExportOptions selects the commits to export. Like `git format-patch FromRef..ToRef`,
+// FromRef itself isn't part of the patches
+type PatchExportOptions struct {
+	RepoPath string      `json:"repoPath"`
+	FromRef  string      `json:"fromRef"`
+	ToRef    string      `json:"toRef"` // Defaults to HEAD
+	Format   PatchFormat `json:"format"`
+
+	// A directory for mbox patches, or the file to write a unified patch to
+	OutputPath string `json:"outputPath"`
+}
+
+type PatchExportResult struct {
+	Files       []string `json:"files"` // The patch files that were written
+	CommitCount int      `json:"commitCount"`
+}
+
+type PatchApplyOptions struct {
+	RepoPath  string `json:"repoPath"`
+	PatchPath string `json:"patchPath"` // A plain patch, or an mbox from `git format-patch`
+
+	// Only check whether each hunk applies, without changing the repo
+	DryRun bool `json:"dryRun"`
+}
+
+// PatchHunkCheck is the `git apply --check` result of a single hunk
+type PatchHunkCheck struct {
+	Path      string `json:"path"`
+	HunkIndex int    `json:"hunkIndex"` // -1 for changes without text hunks (binary, mode-only or pure renames)
+	OldStart  int    `json:"oldStart"`
+	OldLines  int    `json:"oldLines"`
+	NewStart  int    `json:"newStart"`
+	NewLines  int    `json:"newLines"`
+	Applies   bool   `json:"applies"`
+	Error     string `json:"error"` // git's explanation when the hunk doesn't apply
+}
+
+type PatchApplyResult struct {
+	IsMailbox bool `json:"isMailbox"` // Mailboxes are applied as commits with `git am`
+	DryRun    bool `json:"dryRun"`
+	Applied   bool `json:"applied"`
+
+	// The plain apply failed, so the patch was (or would need to be) applied with a 3-way merge
+	UsedThreeWay bool `json:"usedThreeWay"`
+
+	// Dry runs only. Every hunk is checked against the working tree on its own, so a hunk that depends
+	// on an earlier patch in the same mailbox can show up as failing
+	Hunks []PatchHunkCheck `json:"hunks"`
+
+	// Files left with conflict markers when the 3-way merge couldn't resolve everything. On dry runs,
+	// the files that would conflict
+	ConflictedFiles []string `json:"conflictedFiles"`
+
+	// `git am` stopped on a patch. Resolve the conflicts, stage them, then call ContinuePatchApply
+	// (or SkipPatch/AbortPatchApply)
+	AmInProgress bool `json:"amInProgress"`
+
+	Output string `json:"output"`
+}
+
+// A file section of a patch, split into the header lines and each of its hunks
+type patchFileSection struct {
+	path   string
+	header []string
+	hunks  []patchHunk
+}
+
+type patchHunk struct {
+	info  DiffHunk // Only the @@ header fields are set
+	lines []string // Including the @@ header
+}
+
+var threeWayConflictRegex = regexp.MustCompile(`(?m)^Applied patch to '(.+)' with conflicts\.$`)
+
+// ExportPatches writes a commit range as mbox patch files or as a single unified patch
+func ExportPatches(ctx context.Context, options PatchExportOptions) (*PatchExportResult, error) {
+	logger.Log.Info("Exporting %s patches for %s..%s in repo: %s", options.Format, options.FromRef, options.ToRef, options.RepoPath)
+
+	if strings.TrimSpace(options.FromRef) == "" {
+		return nil, fmt.Errorf("a base ref is required to export patches")
+	}
+	if strings.TrimSpace(options.OutputPath) == "" {
+		return nil, fmt.Errorf("an output path is required to export patches")
+	}
+
+	toRef := options.ToRef
+	if toRef == "" {
+		toRef = "HEAD"
+	}
+
+	for _, ref := range []string{options.FromRef, toRef} {
+		if err := validateGitRef(ctx, options.RepoPath, ref); err != nil {
+			return nil, fmt.Errorf("invalid ref: %v", err)
+		}
+	}
+
+	revisionRange := fmt.Sprintf("%s..%s", options.FromRef, toRef)
+	commitCount, err := countCommits(ctx, options.RepoPath, revisionRange)
+	if err != nil {
+		return nil, err
+	}
+	if commitCount == 0 {
+		return nil, fmt.Errorf("there are no commits in %s to export", revisionRange)
+	}
+
+	result := &PatchExportResult{Files: []string{}, CommitCount: commitCount}
+
+	switch options.Format {
+	case PatchFormatMbox:
+		commandResult, err := runGit(ctx, options.RepoPath, "format-patch", "--binary", "-o", options.OutputPath, revisionRange)
+		if err != nil {
+			return nil, fmt.Errorf("failed to run git format-patch: %w", err)
+		}
+
+		// format-patch prints the path of every file it wrote
+		for _, line := range strings.Split(strings.TrimSpace(commandResult.Stdout), "\n") {
+			if line != "" {
+				result.Files = append(result.Files, line)
+			}
+		}
+	case PatchFormatUnified:
+		if err := os.MkdirAll(filepath.Dir(options.OutputPath), 0755); err != nil {
+			return nil, fmt.Errorf("failed to create the output directory: %v", err)
+		}
+
+		if _, err := runGit(ctx, options.RepoPath, "diff", "--binary", "--no-color", "--no-ext-diff", "--output="+options.OutputPath, options.FromRef, toRef); err != nil {
+			return nil, fmt.Errorf("failed to write the unified patch: %w", err)
+		}
+		result.Files = append(result.Files, options.OutputPath)
+	default:
+		return nil, fmt.Errorf("unsupported patch format: %s", options.Format)
+	}
+
+	logger.Log.Info("Exported %d commits to %d patch files", result.CommitCount, len(result.Files))
+	return result, nil
+}
+
+// ApplyPatch applies a patch to the working tree (or an mbox as commits), falling back to a 3-way merge
+// when the patch doesn't apply cleanly. Conflicts are left in the working tree for the user to resolve
+func ApplyPatch(ctx context.Context, options PatchApplyOptions) (*PatchApplyResult, error) {
+	logger.Log.Info("Applying patch %s in repo: %s (dry run: %v)", options.PatchPath, options.RepoPath, options.DryRun)
+
+	if !lib.FileExists(options.PatchPath) {
+		return nil, fmt.Errorf("patch file not found at: %s", options.PatchPath)
+	}
+
+	content, err := os.ReadFile(options.PatchPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the patch file: %v", err)
+	}
+
+	// git apply only touches files inside the directory it runs in, so always run from the top level
+	repoRoot, err := FindRepoRoot(ctx, options.RepoPath)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &PatchApplyResult{
+		IsMailbox:       isMailboxPatch(string(content)),
+		DryRun:          options.DryRun,
+		Hunks:           []PatchHunkCheck{},
+		ConflictedFiles: []string{},
+	}
+
+	if options.DryRun {
+		return checkPatch(ctx, repoRoot, options.PatchPath, string(content), result)
+	}
+
+	if result.IsMailbox {
+		return applyMailbox(ctx, repoRoot, options.PatchPath, result)
+	}
+
+	output, exitCode, err := runPatchCommand(ctx, repoRoot, "", "apply", options.PatchPath)
+	if err == nil && exitCode == 0 {
+		result.Applied = true
+		result.Output = output
+		return result, nil
+	}
+
+	logger.Log.Info("Patch %s didn't apply cleanly, retrying with a 3-way merge", options.PatchPath)
+	result.UsedThreeWay = true
+	result.Output, exitCode, err = runPatchCommand(ctx, repoRoot, "", "apply", "--3way", options.PatchPath)
+	if err == nil && exitCode == 0 {
+		result.Applied = true
+		return result, nil
+	}
+
+	result.ConflictedFiles, err = getConflictedFiles(ctx, repoRoot)
+	if err != nil {
+		return nil, err
+	}
+	if len(result.ConflictedFiles) == 0 {
+		return nil, fmt.Errorf("failed to apply the patch: %s", strings.TrimSpace(result.Output))
+	}
+
+	logger.Log.Info("Patch %s left %d conflicted files", options.PatchPath, len(result.ConflictedFiles))
+	return result, nil
+}
+
+// ContinuePatchApply continues `git am` after the conflicts of the current patch were resolved and staged
+func ContinuePatchApply(ctx context.Context, repoPath string) (*PatchApplyResult, error) {
+	return resumeMailbox(ctx, repoPath, "--continue")
+}
+
+// SkipPatch drops the patch `git am` stopped on, and continues with the rest of the mailbox
+func SkipPatch(ctx context.Context, repoPath string) (*PatchApplyResult, error) {
+	return resumeMailbox(ctx, repoPath, "--skip")
+}
+
+// AbortPatchApply stops `git am` and restores the branch to where it was before the mailbox was applied
+func AbortPatchApply(ctx context.Context, repoPath string) error {
+	output, exitCode, err := runPatchCommand(ctx, repoPath, "", "am", "--abort")
+	if err != nil || exitCode != 0 {
+		return fmt.Errorf("failed to abort git am: %v, output: %s", err, strings.TrimSpace(output))
+	}
+	return nil
+}
+
+// Checks the patch as a whole (with and without a 3-way merge) and then every hunk on its own
+func checkPatch(ctx context.Context, repoRoot, patchPath, content string, result *PatchApplyResult) (*PatchApplyResult, error) {
+	output, exitCode, err := runPatchCommand(ctx, repoRoot, "", "apply", "--check", patchPath)
+	result.Applied = err == nil && exitCode == 0
+	result.Output = output
+
+	if !result.Applied {
+		output, _, _ = runPatchCommand(ctx, repoRoot, "", "apply", "--check", "--3way", patchPath)
+		result.UsedThreeWay = true
+		result.Output += output
+
+		// The check passes even when the merge would conflict, so the conflicts are read from the output
+		for _, matches := range threeWayConflictRegex.FindAllStringSubmatch(output, -1) {
+			result.ConflictedFiles = append(result.ConflictedFiles, matches[1])
+		}
+	}
+
+	for _, section := range splitPatchSections(content) {
+		if len(section.hunks) == 0 {
+			output, exitCode, err := runPatchCommand(ctx, repoRoot, "", "apply", "--check", "--include="+section.path, patchPath)
+			result.Hunks = append(result.Hunks, PatchHunkCheck{
+				Path:      section.path,
+				HunkIndex: -1,
+				Applies:   err == nil && exitCode == 0,
+				Error:     patchCheckError(output, err, exitCode),
+			})
+			continue
+		}
+
+		for hunkIndex, hunk := range section.hunks {
+			singleHunkPatch := strings.Join(section.header, "\n") + "\n" + strings.Join(hunk.lines, "\n") + "\n"
+			output, exitCode, err := runPatchCommand(ctx, repoRoot, singleHunkPatch, "apply", "--check", "-")
+			result.Hunks = append(result.Hunks, PatchHunkCheck{
+				Path:      section.path,
+				HunkIndex: hunkIndex,
+				OldStart:  hunk.info.OldStart,
+				OldLines:  hunk.info.OldLines,
+				NewStart:  hunk.info.NewStart,
+				NewLines:  hunk.info.NewLines,
+				Applies:   err == nil && exitCode == 0,
+				Error:     patchCheckError(output, err, exitCode),
+			})
+		}
+	}
+
+	return result, nil
+}
+
+func applyMailbox(ctx context.Context, repoRoot, patchPath string, result *PatchApplyResult) (*PatchApplyResult, error) {
+	if isAmInProgress(ctx, repoRoot) {
+		return nil, fmt.Errorf("git am is already in progress, continue or abort it before applying another mailbox")
+	}
+
+	output, exitCode, err := runPatchCommand(ctx, repoRoot, "", "am", "--3way", patchPath)
+	result.UsedThreeWay = true
+	return finishMailboxCommand(ctx, repoRoot, output, exitCode, err, result)
+}
+
+func resumeMailbox(ctx context.Context, repoPath, action string) (*PatchApplyResult, error) {
+	logger.Log.Info("Running git am %s in repo: %s", action, repoPath)
+
+	if !isAmInProgress(ctx, repoPath) {
+		return nil, fmt.Errorf("there is no git am in progress")
+	}
+
+	output, exitCode, err := runPatchCommand(ctx, repoPath, "", "am", action)
+	result := &PatchApplyResult{IsMailbox: true, UsedThreeWay: true, Hunks: []PatchHunkCheck{}, ConflictedFiles: []string{}}
+	return finishMailboxCommand(ctx, repoPath, output, exitCode, err, result)
+}
+
+// A failed `git am` leaves the mailbox in progress, with any conflicts in the working tree
+func finishMailboxCommand(ctx context.Context, repoPath, output string, exitCode int, commandErr error, result *PatchApplyResult) (*PatchApplyResult, error) {
+	result.Output = output
+	result.AmInProgress = isAmInProgress(ctx, repoPath)
+	if commandErr == nil && exitCode == 0 && !result.AmInProgress {
+		result.Applied = true
+		return result, nil
+	}
+
+	if !result.AmInProgress {
+		return nil, fmt.Errorf("failed to apply the mailbox: %v, output: %s", commandErr, strings.TrimSpace(output))
+	}
+
+	conflictedFiles, err := getConflictedFiles(ctx, repoPath)
+	if err != nil {
+		return nil, err
+	}
+	result.ConflictedFiles = conflictedFiles
+
+	logger.Log.Info("git am stopped with %d conflicted files", len(result.ConflictedFiles))
+	return result, nil
+}
+
+// Runs a git command with the given text as its stdin (when it's not empty). apply and am report most of
+// what they did on stderr, so both streams are returned together for the user to read
+func runPatchCommand(ctx context.Context, repoPath, stdin string, args ...string) (string, int, error) {
+	var stdinReader io.Reader
+	if stdin != "" {
+		stdinReader = strings.NewReader(stdin)
+	}
+	result, err := command_utils.RunCommand(ctx, command_utils.Command{Args: args, Dir: repoPath, Stdin: stdinReader})
+	return result.Stdout + result.Stderr, result.ExitCode, err
+}
+
+func patchCheckError(output string, err error, exitCode int) string {
+	if err == nil && exitCode == 0 {
+		return ""
+	}
+	return strings.TrimSpace(output)
+}
+
+// splitPatchSections splits a patch (or every patch in an mbox) into files and hunks. The hunk line
+// counts decide where each hunk ends, so trailing mail signatures like "-- " aren't mistaken for hunk lines
+func splitPatchSections(content string) []patchFileSection {
+	sections := []patchFileSection{}
+	var currentSection *patchFileSection
+	var currentHunk *patchHunk
+	oldRemaining, newRemaining := 0, 0
+
+	finishSection := func() {
+		if currentSection != nil {
+			sections = append(sections, *currentSection)
+		}
+		currentSection = nil
+		currentHunk = nil
+	}
+
+	for _, line := range strings.Split(strings.ReplaceAll(content, "\r\n", "\n"), "\n") {
+		if currentHunk != nil {
+			if oldRemaining > 0 || newRemaining > 0 {
+				switch {
+				case strings.HasPrefix(line, "-"):
+					oldRemaining--
+				case strings.HasPrefix(line, "+"):
+					newRemaining--
+				case strings.HasPrefix(line, "\\"):
+				default:
+					// Context lines, including blank ones that lost their leading space
+					oldRemaining--
+					newRemaining--
+				}
+				currentHunk.lines = append(currentHunk.lines, line)
+				continue
+			}
+
+			// "\ No newline at end of file" can follow the last line of the hunk
+			if strings.HasPrefix(line, "\\") {
+				currentHunk.lines = append(currentHunk.lines, line)
+				continue
+			}
+			currentHunk = nil
+		}
+
+		if strings.HasPrefix(line, "diff --git ") {
+			finishSection()
+			oldPath, newPath := parseDiffGitHeaderPaths(strings.TrimPrefix(line, "diff --git "))
+			currentSection = &patchFileSection{path: newPath, header: []string{line}}
+			if newPath == "" {
+				currentSection.path = oldPath
+			}
+			continue
+		}
+
+		if currentSection == nil {
+			continue
+		}
+
+		if strings.HasPrefix(line, "@@ ") {
+			matches := hunkHeaderRegex.FindStringSubmatch(line)
+			if matches == nil {
+				continue
+			}
+
+			currentSection.hunks = append(currentSection.hunks, patchHunk{
+				info: DiffHunk{
+					OldStart: atoiOrDefault(matches[1], 0),
+					OldLines: atoiOrDefault(matches[2], 1),
+					NewStart: atoiOrDefault(matches[3], 0),
+					NewLines: atoiOrDefault(matches[4], 1),
+				},
+				lines: []string{line},
+			})
+			currentHunk = &currentSection.hunks[len(currentSection.hunks)-1]
+			oldRemaining, newRemaining = currentHunk.info.OldLines, currentHunk.info.NewLines
+			continue
+		}
+
+		// Anything after the hunks (like mail signatures) isn't part of the file
+		if len(currentSection.hunks) == 0 {
+			currentSection.header = append(currentSection.header, line)
+		}
+	}
+	finishSection()
+
+	return sections
+}
+
+// Mailboxes from `git format-patch` start with a "From <commit hash> <date>" line
+func isMailboxPatch(content string) bool {
+	return strings.HasPrefix(content, "From ")
+}
+
+func isAmInProgress(ctx context.Context, repoPath string) bool {
+	applyingPath, err := getGitPath(ctx, repoPath, "rebase-apply/applying")
+	if err != nil {
+		return false
+	}
+	return lib.FileExists(applyingPath)
+}
+
+// Returns the files that have unresolved merge conflicts
+func getConflictedFiles(ctx context.Context, repoPath string) ([]string, error) {
+	result, err := runGit(ctx, repoPath, "diff", "--name-only", "--diff-filter=U", "-z")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list the conflicted files: %w", err)
+	}
+
+	files := []string{}
+	for _, path := range strings.Split(result.Stdout, "\x00") {
+		if path != "" {
+			files = append(files, path)
+		}
+	}
+	return files, nil
+}
+
+func countCommits(ctx context.Context, repoPath, revisionRange string) (int, error) {
+	result, err := runGit(ctx, repoPath, "rev-list", "--count", revisionRange)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count the commits in %s: %w", revisionRange, err)
+	}
+	return atoiOrDefault(strings.TrimSpace(result.Stdout), 0), nil
+}
@@ -0,0 +1,226 @@
+package git_operations
+
+import (
+	"context"
+	"fmt"
+	"gitwhale/backend/logger"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+type RangeDiffOptions struct {
+	RepoPath string `json:"repoPath"`
+
+	// The two versions of the patch series (e.g. "main..feature-v1" and "main..feature-v2")
+	OldRange string `json:"oldRange"`
+	NewRange string `json:"newRange"`
+
+	// Alternatively, compare a branch with a previous version of itself from its reflog.
+	// Only used when OldRange and NewRange are empty
+	Branch      string `json:"branch"`
+	ReflogEntry int    `json:"reflogEntry"` // Defaults to 1 (the previous value of the branch)
+	Base        string `json:"base"`        // Optional upstream of the series. Uses the merge-base when empty
+
+	CreationFactor int `json:"creationFactor"` // Optional, git's default is 60
+
+	// Used when comparing the two versions of each patch
+	DiffSettings *DiffSettings `json:"diffSettings"`
+}
+
+type RangeDiffPairStatus string
+
+const (
+	RangeDiffUnchanged RangeDiffPairStatus = "unchanged"
+	RangeDiffModified  RangeDiffPairStatus = "modified"
+	RangeDiffAdded     RangeDiffPairStatus = "added"
+	RangeDiffRemoved   RangeDiffPairStatus = "removed"
+)
+
+// RangeDiffPair matches a commit from the old series with its counterpart in the new series
+type RangeDiffPair struct {
+	Status RangeDiffPairStatus `json:"status"`
+
+	// 1-based positions in each series, 0 when the commit is missing on that side
+	OldIndex int `json:"oldIndex"`
+	NewIndex int `json:"newIndex"`
+
+	OldCommit *GitLogCommitInfo `json:"oldCommit"`
+	NewCommit *GitLogCommitInfo `json:"newCommit"`
+
+	// The diff between the two versions of the patch (only for modified pairs)
+	Interdiff string `json:"interdiff"`
+}
+
+type RangeDiffResult struct {
+	OldRange string          `json:"oldRange"`
+	NewRange string          `json:"newRange"`
+	Pairs    []RangeDiffPair `json:"pairs"`
+}
+
+// Matches the pairing lines of `git range-diff`, e.g. "1:  0a44242 ! 3:  637a83c topic c". They start at column 0,
+// apart from the padding git right-aligns the pair numbers with (" 9:" next to "10:")
+var rangeDiffPairRegex = regexp.MustCompile(`^ {0,3}(\d+|-):  ([0-9a-f]+|-+) ([=!<>]) +(\d+|-):  ([0-9a-f]+|-+) ?(.*)$`)
+
+// The body of a pair (its interdiff) is indented, and can contain anything, including lines that look like pairs
+const rangeDiffBodyIndent = "    "
+
+// GetRangeDiff runs `git range-diff` between two versions of a patch series and pairs up their commits
+func GetRangeDiff(ctx context.Context, options RangeDiffOptions) (*RangeDiffResult, error) {
+	args, oldRange, newRange, err := buildRangeDiffArgs(ctx, options)
+	if err != nil {
+		return nil, err
+	}
+
+	logger.Log.Info("Running range-diff between %s and %s in repo: %s", oldRange, newRange, options.RepoPath)
+
+	result, err := runGit(ctx, options.RepoPath, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to run range-diff: %w", err)
+	}
+
+	pairs, commitHashes, err := parseRangeDiffOutput(result.Stdout)
+	if err != nil {
+		return nil, err
+	}
+
+	// Load the commit metadata for both series in one go
+	commits, err := ReadGitLogCommits(ctx, options.RepoPath, commitHashes)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range pairs {
+		pairs[i].OldCommit = findCommitByAbbreviatedHash(commits, pairs[i].OldCommit)
+		pairs[i].NewCommit = findCommitByAbbreviatedHash(commits, pairs[i].NewCommit)
+	}
+
+	return &RangeDiffResult{
+		OldRange: oldRange,
+		NewRange: newRange,
+		Pairs:    pairs,
+	}, nil
+}
+
+// Returns the git arguments, along with the old and new ranges that are being compared
+func buildRangeDiffArgs(ctx context.Context, options RangeDiffOptions) ([]string, string, string, error) {
+	if err := options.DiffSettings.Validate(); err != nil {
+		return nil, "", "", fmt.Errorf("invalid diff settings: %v", err)
+	}
+
+	args := []string{"range-diff", "--no-color"}
+	if options.CreationFactor > 0 {
+		args = append(args, fmt.Sprintf("--creation-factor=%d", options.CreationFactor))
+	}
+	args = append(args, options.DiffSettings.gitArgs()...)
+
+	if options.OldRange != "" || options.NewRange != "" {
+		if options.OldRange == "" || options.NewRange == "" {
+			return nil, "", "", fmt.Errorf("both the old and new ranges must be specified")
+		}
+		args = append(args, options.OldRange, options.NewRange)
+		return args, options.OldRange, options.NewRange, nil
+	}
+
+	if options.Branch == "" {
+		return nil, "", "", fmt.Errorf("either two ranges or a branch must be specified")
+	}
+
+	reflogEntry := options.ReflogEntry
+	if reflogEntry <= 0 {
+		reflogEntry = 1
+	}
+	previousBranch := fmt.Sprintf("%s@{%d}", options.Branch, reflogEntry)
+	if err := validateGitRef(ctx, options.RepoPath, previousBranch); err != nil {
+		return nil, "", "", fmt.Errorf("could not find the previous version of %s in its reflog: %v", options.Branch, err)
+	}
+
+	if options.Base == "" {
+		args = append(args, fmt.Sprintf("%s...%s", previousBranch, options.Branch))
+		return args, previousBranch, options.Branch, nil
+	}
+
+	args = append(args, options.Base, previousBranch, options.Branch)
+	return args, fmt.Sprintf("%s..%s", options.Base, previousBranch), fmt.Sprintf("%s..%s", options.Base, options.Branch), nil
+}
+
+// parseRangeDiffOutput returns the pairs (with placeholder commits that only hold the abbreviated hash),
+// and the list of all the abbreviated hashes that were referenced
+func parseRangeDiffOutput(output string) ([]RangeDiffPair, []string, error) {
+	pairs := []RangeDiffPair{}
+	commitHashes := []string{}
+	var interdiff strings.Builder
+
+	flushInterdiff := func() {
+		if len(pairs) > 0 && interdiff.Len() > 0 {
+			pairs[len(pairs)-1].Interdiff = interdiff.String()
+		}
+		interdiff.Reset()
+	}
+
+	for _, line := range strings.Split(output, "\n") {
+		var matches []string
+		if !strings.HasPrefix(line, rangeDiffBodyIndent) {
+			matches = rangeDiffPairRegex.FindStringSubmatch(line)
+		}
+		if matches == nil {
+			// Everything between two pairing lines is the interdiff of the previous pair
+			if len(pairs) > 0 && line != "" {
+				interdiff.WriteString(strings.TrimPrefix(line, rangeDiffBodyIndent))
+				interdiff.WriteString("\n")
+			}
+			continue
+		}
+
+		flushInterdiff()
+
+		pair := RangeDiffPair{}
+		switch matches[3] {
+		case "=":
+			pair.Status = RangeDiffUnchanged
+		case "!":
+			pair.Status = RangeDiffModified
+		case "<":
+			pair.Status = RangeDiffRemoved
+		case ">":
+			pair.Status = RangeDiffAdded
+		}
+
+		if matches[1] != "-" {
+			pair.OldIndex, _ = strconv.Atoi(matches[1])
+			pair.OldCommit = &GitLogCommitInfo{CommitHash: matches[2]}
+			commitHashes = append(commitHashes, matches[2])
+		}
+
+		if matches[4] != "-" {
+			pair.NewIndex, _ = strconv.Atoi(matches[4])
+			pair.NewCommit = &GitLogCommitInfo{CommitHash: matches[5]}
+			commitHashes = append(commitHashes, matches[5])
+		}
+
+		if pair.OldCommit == nil && pair.NewCommit == nil {
+			return nil, nil, fmt.Errorf("failed to parse range-diff line: %s", line)
+		}
+
+		pairs = append(pairs, pair)
+	}
+	flushInterdiff()
+
+	return pairs, commitHashes, nil
+}
+
+// Swaps a placeholder commit (holding only an abbreviated hash) with its fully loaded info
+func findCommitByAbbreviatedHash(commits map[string]GitLogCommitInfo, placeholder *GitLogCommitInfo) *GitLogCommitInfo {
+	if placeholder == nil {
+		return nil
+	}
+
+	for fullHash, commit := range commits {
+		if strings.HasPrefix(fullHash, placeholder.CommitHash) {
+			return &commit
+		}
+	}
+
+	logger.Log.Warning("Could not load the commit info for %s", placeholder.CommitHash)
+	return placeholder
+}
@@ -0,0 +1,98 @@
+package git_operations
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseRangeDiffOutput(t *testing.T) {
+	testCases := []struct {
+		name       string
+		output     string
+		wantPairs  []RangeDiffPair
+		wantHashes []string
+	}{
+		{
+			// The commit message of the second pair quotes other range-diff pairs, which show up in its interdiff
+			name: "pairs with interdiffs",
+			output: "1:  46cc452 = 1:  46cc452 add a\n" +
+				"2:  2454a3f ! 2:  d2978b3 add b\n" +
+				"    @@ Commit message\n" +
+				"         1:  0a44242 ! 3:  637a83c topic c\n" +
+				"         -:  ------- > 1:  1234567 added\n" +
+				"     \n" +
+				"    +    Signed-off-by: t <a@b>\n" +
+				"    +\n" +
+				"      ## b (new) ##\n" +
+				"     @@\n" +
+				"     +1\n" +
+				"    @@ b (new)\n" +
+				"     +30\n" +
+				"    ++31\n" +
+				"3:  1356a4a < -:  ------- add c\n" +
+				"-:  ------- > 3:  0414ac5 add d\n",
+			wantPairs: []RangeDiffPair{
+				{
+					Status: RangeDiffUnchanged, OldIndex: 1, NewIndex: 1,
+					OldCommit: &GitLogCommitInfo{CommitHash: "46cc452"}, NewCommit: &GitLogCommitInfo{CommitHash: "46cc452"},
+				},
+				{
+					Status: RangeDiffModified, OldIndex: 2, NewIndex: 2,
+					OldCommit: &GitLogCommitInfo{CommitHash: "2454a3f"}, NewCommit: &GitLogCommitInfo{CommitHash: "d2978b3"},
+					Interdiff: "@@ Commit message\n" +
+						"     1:  0a44242 ! 3:  637a83c topic c\n" +
+						"     -:  ------- > 1:  1234567 added\n" +
+						" \n" +
+						"+    Signed-off-by: t <a@b>\n" +
+						"+\n" +
+						"  ## b (new) ##\n" +
+						" @@\n" +
+						" +1\n" +
+						"@@ b (new)\n" +
+						" +30\n" +
+						"++31\n",
+				},
+				{Status: RangeDiffRemoved, OldIndex: 3, OldCommit: &GitLogCommitInfo{CommitHash: "1356a4a"}},
+				{Status: RangeDiffAdded, NewIndex: 3, NewCommit: &GitLogCommitInfo{CommitHash: "0414ac5"}},
+			},
+			wantHashes: []string{"46cc452", "46cc452", "2454a3f", "d2978b3", "1356a4a", "0414ac5"},
+		},
+		{
+			name: "padded pair numbers",
+			output: " 9:  307097d =  9:  307097d n9\n" +
+				"10:  b67b227 = 10:  b67b227 n10\n",
+			wantPairs: []RangeDiffPair{
+				{
+					Status: RangeDiffUnchanged, OldIndex: 9, NewIndex: 9,
+					OldCommit: &GitLogCommitInfo{CommitHash: "307097d"}, NewCommit: &GitLogCommitInfo{CommitHash: "307097d"},
+				},
+				{
+					Status: RangeDiffUnchanged, OldIndex: 10, NewIndex: 10,
+					OldCommit: &GitLogCommitInfo{CommitHash: "b67b227"}, NewCommit: &GitLogCommitInfo{CommitHash: "b67b227"},
+				},
+			},
+			wantHashes: []string{"307097d", "307097d", "b67b227", "b67b227"},
+		},
+		{
+			name:       "no commits",
+			output:     "",
+			wantPairs:  []RangeDiffPair{},
+			wantHashes: []string{},
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			pairs, hashes, err := parseRangeDiffOutput(testCase.output)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(pairs, testCase.wantPairs) {
+				t.Errorf("unexpected pairs:\nwant %+v\ngot  %+v", testCase.wantPairs, pairs)
+			}
+			if !reflect.DeepEqual(hashes, testCase.wantHashes) {
+				t.Errorf("unexpected hashes: want %v, got %v", testCase.wantHashes, hashes)
+			}
+		})
+	}
+}
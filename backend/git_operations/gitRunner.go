@@ -0,0 +1,21 @@
+package git_operations
+
+import (
+	"context"
+	"gitwhale/backend/command_utils"
+	"io"
+	"time"
+)
+
+// runGit runs a git command with the default timeout. Cancelling ctx (e.g. the job it's part of) stops it
+func runGit(ctx context.Context, repoPath string, args ...string) (*command_utils.CommandResult, error) {
+	return command_utils.RunGit(ctx, repoPath, args...)
+}
+
+// runGitWithStdin runs a git command that reads its input from stdin
+func runGitWithStdin(ctx context.Context, repoPath string, stdin io.Reader, args ...string) (*command_utils.CommandResult, error) {
+	return command_utils.RunCommand(ctx, command_utils.Command{Args: args, Dir: repoPath, Stdin: stdin})
+}
+
+// Network operations (fetch, push, clone) can take far longer than local commands
+const gitNetworkTimeout = 10 * time.Minute
@@ -0,0 +1,62 @@
+package git_operations
+
+import (
+	"context"
+	"errors"
+	"gitwhale/backend/command_utils"
+	"testing"
+)
+
+// fakeRunner fails every command with the given kind of error, and records what it was asked to run
+type fakeRunner struct {
+	kind     error
+	exitCode int
+	commands []command_utils.Command
+}
+
+func (runner *fakeRunner) Run(ctx context.Context, command command_utils.Command) (*command_utils.CommandResult, error) {
+	runner.commands = append(runner.commands, command)
+
+	result := &command_utils.CommandResult{ExitCode: runner.exitCode, Stderr: "fatal: simulated failure"}
+	return result, &command_utils.CommandError{Kind: runner.kind, Args: command.Args, ExitCode: runner.exitCode, Stderr: result.Stderr}
+}
+
+func useFakeRunner(t *testing.T, runner command_utils.Runner) {
+	t.Helper()
+
+	previousRunner := command_utils.DefaultRunner
+	command_utils.DefaultRunner = runner
+	t.Cleanup(func() { command_utils.DefaultRunner = previousRunner })
+}
+
+func TestRunnerErrorsAreWrapped(t *testing.T) {
+	testCases := []struct {
+		name     string
+		kind     error
+		exitCode int
+	}{
+		{name: "ref not found", kind: command_utils.ErrRefNotFound, exitCode: 128},
+		{name: "timeout", kind: command_utils.ErrTimeout, exitCode: -1},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			runner := &fakeRunner{kind: testCase.kind, exitCode: testCase.exitCode}
+			useFakeRunner(t, runner)
+
+			_, err := GetMergeBase(context.Background(), "/repo", "main", "missing-branch")
+			if !errors.Is(err, testCase.kind) {
+				t.Fatalf("GetMergeBase: expected %v, got %v", testCase.kind, err)
+			}
+
+			_, err = readCommitWithCli(context.Background(), "/repo", "missing-commit")
+			if !errors.Is(err, testCase.kind) {
+				t.Fatalf("readCommitWithCli: expected %v, got %v", testCase.kind, err)
+			}
+
+			if len(runner.commands) != 2 || runner.commands[0].Dir != "/repo" {
+				t.Fatalf("expected both commands to go through the runner in /repo, got %+v", runner.commands)
+			}
+		})
+	}
+}
@@ -1,16 +1,26 @@
 package git_operations
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"gitwhale/backend/command_utils"
 	"gitwhale/backend/logger"
 	"os"
-	"os/exec"
 	"path/filepath"
 	"strings"
 	"time"
 )
 
+type StagingFileType string
+
+const (
+	StagingFileStaged     StagingFileType = "staged"     // HEAD vs the index
+	StagingFileUnstaged   StagingFileType = "unstaged"   // The index vs the working directory
+	StagingFileUntracked  StagingFileType = "untracked"  // Nothing vs the working directory
+	StagingFileConflicted StagingFileType = "conflicted" // Our side vs their side of a merge conflict
+)
+
 // GitStatusFile represents a file in the Git status output
 type GitStatusFile struct {
 	Path          string `json:"path"`
@@ -18,6 +28,7 @@ type GitStatusFile struct {
 	StagedStatus  string `json:"stagedStatus"`  // Index status (first character)
 	WorkingStatus string `json:"workingStatus"` // Working tree status (second character)
 	OldPath       string `json:"oldPath"`       // For renames, the original path
+	IsConflicted  bool   `json:"isConflicted"`  // The file has unresolved merge conflicts
 }
 
 // GitStatus represents the overall Git status
@@ -28,29 +39,50 @@ type GitStatus struct {
 	HasChanges     bool            `json:"hasChanges"`
 }
 
-// StagingDiffInfo represents information about a staging area diff session
+// StagingDiffInfo holds both versions of a file in the staging area, ready to be shown in the diff editor
 type StagingDiffInfo struct {
-	SessionId  string    `json:"sessionId"`
-	FilePath   string    `json:"filePath"`
-	FileType   string    `json:"fileType"`
-	LeftPath   string    `json:"leftPath"`
-	RightPath  string    `json:"rightPath"`
-	LeftLabel  string    `json:"leftLabel"`
-	RightLabel string    `json:"rightLabel"`
-	CreatedAt  time.Time `json:"createdAt"`
+	FilePath   string          `json:"filePath"`
+	OldPath    string          `json:"oldPath"` // Same as FilePath unless the file was renamed or copied
+	FileType   StagingFileType `json:"fileType"`
+	LeftLabel  string          `json:"leftLabel"`
+	RightLabel string          `json:"rightLabel"`
+	CreatedAt  time.Time       `json:"createdAt"`
+
+	// Exists is false on the side where the file was added or deleted
+	Left  DiffFileContent `json:"left"`
+	Right DiffFileContent `json:"right"`
+
+	// Set when either side is a binary file
+	BinaryInfo *BinaryDiffInfo `json:"binaryInfo"`
+}
+
+// The XY codes `git status` uses for unmerged paths
+var conflictStatuses = map[string]bool{
+	"DD": true, "AU": true, "UD": true, "UA": true, "DU": true, "AA": true, "UU": true,
 }
 
 // GetGitStatus retrieves the current Git status for a repository
-func GetGitStatus(repoPath string) (*GitStatus, error) {
+func GetGitStatus(ctx context.Context, repoPath string) (*GitStatus, error) {
 	logger.Log.Info("Getting Git status for repo: %v", repoPath)
 
-	cmd := exec.Command("git", "status", "--porcelain=v1", "-z")
-	cmd.Dir = repoPath
-	output, exitCode, err := command_utils.RunCommandAndLogErr(cmd)
-	if err != nil || exitCode != 0 {
-		return nil, fmt.Errorf("failed to get git status: %v", err)
+	status, err := currentGitBackend().ReadStatus(ctx, repoPath)
+	if err != nil {
+		return nil, err
 	}
 
+	logger.Log.Info("Git status retrieved: %d staged, %d unstaged, %d untracked files",
+		len(status.StagedFiles), len(status.UnstagedFiles), len(status.UntrackedFiles))
+
+	return status, nil
+}
+
+func readStatusWithCli(ctx context.Context, repoPath string) (*GitStatus, error) {
+	result, err := runGit(ctx, repoPath, "status", "--porcelain=v1", "-z")
+	if err != nil {
+		return nil, fmt.Errorf("failed to get git status: %w", err)
+	}
+	output := result.Stdout
+
 	status := &GitStatus{
 		StagedFiles:    []GitStatusFile{},
 		UnstagedFiles:  []GitStatusFile{},
@@ -64,7 +96,8 @@ func GetGitStatus(repoPath string) (*GitStatus, error) {
 	// Parse null-separated output
 	entries := strings.Split(strings.TrimSuffix(output, "\x00"), "\x00")
 
-	for _, entry := range entries {
+	for i := 0; i < len(entries); i++ {
+		entry := entries[i]
 		if len(entry) < 3 {
 			continue
 		}
@@ -80,18 +113,13 @@ func GetGitStatus(repoPath string) (*GitStatus, error) {
 			Status:        statusChars,
 			StagedStatus:  stagedStatus,
 			WorkingStatus: workingStatus,
+			IsConflicted:  conflictStatuses[statusChars],
 		}
 
-		// Handle renames (format: "R  old_name\x00new_name")
-		if stagedStatus == "R" || stagedStatus == "C" {
-			// For renames, Git outputs both old and new names
-			if strings.Contains(filePath, "\x00") {
-				parts := strings.Split(filePath, "\x00")
-				if len(parts) == 2 {
-					gitFile.OldPath = parts[0]
-					gitFile.Path = parts[1]
-				}
-			}
+		// Renames and copies are followed by the original path as its own entry (format: "R  new_name\x00old_name")
+		if (stagedStatus == "R" || stagedStatus == "C") && i+1 < len(entries) {
+			i++
+			gitFile.OldPath = entries[i]
 		}
 
 		// Categorize the file based on its status
@@ -113,24 +141,18 @@ func GetGitStatus(repoPath string) (*GitStatus, error) {
 
 	status.HasChanges = len(status.StagedFiles) > 0 || len(status.UnstagedFiles) > 0 || len(status.UntrackedFiles) > 0
 
-	logger.Log.Info("Git status retrieved: %d staged, %d unstaged, %d untracked files",
-		len(status.StagedFiles), len(status.UnstagedFiles), len(status.UntrackedFiles))
-
 	return status, nil
 }
 
 // StageFile stages a specific file
-func StageFile(repoPath string, filePaths []string) error {
+func StageFile(ctx context.Context, repoPath string, filePaths []string) error {
 	logger.Log.Info("Staging files: %s in repo: %s", filePaths, repoPath)
 
 	commandArgs := []string{"add", "-A", "--"}
 	commandArgs = append(commandArgs, filePaths...)
 
-	cmd := exec.Command("git", commandArgs...)
-	cmd.Dir = repoPath
-	_, exitCode, err := command_utils.RunCommandAndLogErr(cmd)
-	if err != nil || exitCode != 0 {
-		return fmt.Errorf("failed to stage file %s: %v", filePaths, err)
+	if _, err := command_utils.RunGit(ctx, repoPath, commandArgs...); err != nil {
+		return fmt.Errorf("failed to stage file %s: %w", filePaths, err)
 	}
 
 	logger.Log.Info("Successfully staged file: %s", filePaths)
@@ -138,17 +160,14 @@ func StageFile(repoPath string, filePaths []string) error {
 }
 
 // UnstageFile unstages a specific file
-func UnstageFile(repoPath string, filePaths []string) error {
+func UnstageFile(ctx context.Context, repoPath string, filePaths []string) error {
 	logger.Log.Info("Unstaging files: %s in repo: %s", filePaths, repoPath)
 
 	commandArgs := []string{"reset", "HEAD", "--"}
 	commandArgs = append(commandArgs, filePaths...)
 
-	cmd := exec.Command("git", commandArgs...)
-	cmd.Dir = repoPath
-	_, exitCode, err := command_utils.RunCommandAndLogErr(cmd)
-	if err != nil || exitCode != 0 {
-		return fmt.Errorf("failed to unstage file %s: %v", filePaths, err)
+	if _, err := command_utils.RunGit(ctx, repoPath, commandArgs...); err != nil {
+		return fmt.Errorf("failed to unstage file %s: %w", filePaths, err)
 	}
 
 	logger.Log.Info("Successfully unstaged file: %s", filePaths)
@@ -156,52 +175,60 @@ func UnstageFile(repoPath string, filePaths []string) error {
 }
 
 // CommitChanges commits the staged changes with the provided message
-func CommitChanges(repoPath, message string) error {
+func CommitChanges(ctx context.Context, repoPath, message string) error {
 	logger.Log.Info("Committing changes in repo: %s", repoPath)
 
 	if strings.TrimSpace(message) == "" {
 		return fmt.Errorf("commit message cannot be empty")
 	}
 
-	cmd := exec.Command("git", "commit", "-m", message)
-	cmd.Dir = repoPath
-	output, exitCode, err := command_utils.RunCommandAndLogErr(cmd)
-	if err != nil || exitCode != 0 {
-		return fmt.Errorf("failed to commit changes: %v", err)
+	result, err := command_utils.RunGit(ctx, repoPath, "commit", "-m", message)
+	if err != nil {
+		return fmt.Errorf("failed to commit changes: %w", err)
 	}
 
-	logger.Log.Info("Successfully committed changes: %s", strings.TrimSpace(output))
+	logger.Log.Info("Successfully committed changes: %s", strings.TrimSpace(result.Stdout))
 	return nil
 }
 
 // GetFileContentFromRef gets the content of a file from a specific Git ref (HEAD, staged index, etc.)
-func GetFileContentFromRef(repoPath, filePath, ref string) (string, error) {
+// An empty ref reads the file from the working directory
+func GetFileContentFromRef(ctx context.Context, repoPath, filePath, ref string) (string, error) {
 	logger.Log.Debug("Getting file content for %s from ref %s in repo %s", filePath, ref, repoPath)
 
-	var cmd *exec.Cmd
+	if ref == "" {
+		return GetWorkingDirectoryFileContent(repoPath, filePath)
+	}
+
+	var object string
 	if ref == "HEAD" {
 		// Get file content from HEAD
-		cmd = exec.Command("git", "show", fmt.Sprintf("HEAD:%s", filePath))
+		object = fmt.Sprintf("HEAD:%s", filePath)
 	} else if ref == "index" || ref == "staged" {
 		// Get file content from staging area
-		cmd = exec.Command("git", "show", fmt.Sprintf(":%s", filePath))
+		object = fmt.Sprintf(":%s", filePath)
 	} else {
 		// Get file content from specific ref
-		cmd = exec.Command("git", "show", fmt.Sprintf("%s:%s", ref, filePath))
+		object = fmt.Sprintf("%s:%s", ref, filePath)
 	}
 
-	cmd.Dir = repoPath
-	output, exitCode, err := command_utils.RunCommandAndLogErr(cmd)
+	return readFileFromGitObject(ctx, repoPath, filePath, object)
+}
+
+// Reads a file with `git show <object>`, e.g. "HEAD:path" or ":path" for the index. Files that don't exist there
+// are empty
+func readFileFromGitObject(ctx context.Context, repoPath, filePath, object string) (string, error) {
+	result, err := runGit(ctx, repoPath, "show", object)
 	if err != nil {
 		// If file doesn't exist in this ref, return empty content
-		if exitCode == 128 || strings.Contains(output, "does not exist") || strings.Contains(output, "Path '"+filePath+"' does not exist") {
-			logger.Log.Debug("File %s does not exist in ref %s", filePath, ref)
-			return output, nil
+		if errors.Is(err, command_utils.ErrRefNotFound) || result.ExitCode == 128 {
+			logger.Log.Debug("File %s does not exist in %s", filePath, object)
+			return "", nil
 		}
-		return "", fmt.Errorf("failed to get file content from %s: %v", ref, err)
+		return "", fmt.Errorf("failed to get file content from %s: %w", object, err)
 	}
 
-	return output, nil
+	return result.Stdout, nil
 }
 
 // GetWorkingDirectoryFileContent gets the current working directory content of a file
@@ -221,104 +248,89 @@ func GetWorkingDirectoryFileContent(repoPath, filePath string) (string, error) {
 	return string(content), nil
 }
 
-// CreateStagingDiffSession creates temporary files for staging area diff viewing
-func CreateStagingDiffSession(repoPath, filePath string, fileType string) (*StagingDiffInfo, error) {
-	logger.Log.Info("Creating staging diff session for %s (type: %s) in repo %s", filePath, fileType, repoPath)
+// CreateStagingDiffSession loads both versions of a file in the staging area. Renamed files are read from
+// their old path on the left side, and conflicted files compare our side of the merge against theirs
+func CreateStagingDiffSession(ctx context.Context, repoPath string, file GitStatusFile, fileType StagingFileType) (*StagingDiffInfo, error) {
+	logger.Log.Info("Creating staging diff session for %s (type: %s) in repo %s", file.Path, fileType, repoPath)
 
-	// Create session ID and temp directories
-	sessionId := fmt.Sprintf("staging_%d", time.Now().UnixNano())
-	tempDir := os.TempDir()
-	sessionDir := filepath.Join(tempDir, "gitwhale-staging", sessionId)
-
-	leftPath := filepath.Join(sessionDir, "left")
-	rightPath := filepath.Join(sessionDir, "right")
-
-	if err := os.MkdirAll(leftPath, 0755); err != nil {
-		return nil, fmt.Errorf("failed to create left directory: %v", err)
-	}
-
-	if err := os.MkdirAll(rightPath, 0755); err != nil {
-		return nil, fmt.Errorf("failed to create right directory: %v", err)
-	}
-
-	// Determine what to compare based on file type
-	var leftContent, rightContent string
+	// Refs are in the format readFileBytesFromRef expects. An empty path means that side doesn't exist
+	options := BinaryDiffOptions{RepoPath: repoPath, LeftPath: file.Path, RightPath: file.Path}
 	var leftLabel, rightLabel string
-	var err1, err2 error
 
 	switch fileType {
-	case "staged":
-		// Compare HEAD vs staged
-		leftContent, err1 = GetFileContentFromRef(repoPath, filePath, "HEAD")
-		rightContent, err2 = GetFileContentFromRef(repoPath, filePath, "staged")
-		leftLabel = "HEAD"
-		rightLabel = "Staged"
-	case "unstaged":
-		// Compare staged vs working directory
-		leftContent, err1 = GetFileContentFromRef(repoPath, filePath, "staged")
-		rightContent, err2 = GetWorkingDirectoryFileContent(repoPath, filePath)
-		leftLabel = "Staged"
-		rightLabel = "Working"
-	case "untracked":
-		// Compare empty vs working directory
-		leftContent = ""
-		rightContent, err1 = GetWorkingDirectoryFileContent(repoPath, filePath)
-		leftLabel = "Empty"
-		rightLabel = "Working"
+	case StagingFileStaged:
+		options.LeftRef, options.RightRef = "HEAD", "staged"
+		if file.OldPath != "" {
+			options.LeftPath = file.OldPath
+		}
+		leftLabel, rightLabel = "HEAD", "Staged"
+	case StagingFileUnstaged:
+		options.LeftRef, options.RightRef = "staged", ""
+		leftLabel, rightLabel = "Staged", "Working"
+	case StagingFileUntracked:
+		options.LeftPath = ""
+		leftLabel, rightLabel = "Empty", "Working"
+	case StagingFileConflicted:
+		options.LeftRef, options.RightRef = ":2", ":3"
+		leftLabel, rightLabel = "Ours", "Theirs"
 	default:
 		return nil, fmt.Errorf("unsupported file type for diff: %s", fileType)
 	}
 
-	if err1 != nil {
-		os.RemoveAll(sessionDir)
-		return nil, fmt.Errorf("failed to get file content: %v", err1)
-	}
-
-	if err2 != nil {
-		os.RemoveAll(sessionDir)
-		return nil, fmt.Errorf("failed to get file content: %v", err2)
-	}
-
-	// Write temporary files
-	fileName := filepath.Base(filePath)
-	leftFilePath := filepath.Join(leftPath, fileName)
-	rightFilePath := filepath.Join(rightPath, fileName)
-
-	if err := os.WriteFile(leftFilePath, []byte(leftContent), 0644); err != nil {
-		os.RemoveAll(sessionDir)
-		return nil, fmt.Errorf("failed to write left file: %v", err)
+	left, err := readStagingSide(ctx, repoPath, options.LeftRef, options.LeftPath, DiffSideLeft)
+	if err != nil {
+		return nil, err
 	}
 
-	if err := os.WriteFile(rightFilePath, []byte(rightContent), 0644); err != nil {
-		os.RemoveAll(sessionDir)
-		return nil, fmt.Errorf("failed to write right file: %v", err)
+	right, err := readStagingSide(ctx, repoPath, options.RightRef, options.RightPath, DiffSideRight)
+	if err != nil {
+		return nil, err
 	}
 
 	diffInfo := &StagingDiffInfo{
-		SessionId:  sessionId,
-		FilePath:   filePath,
+		FilePath:   file.Path,
+		OldPath:    file.Path,
 		FileType:   fileType,
-		LeftPath:   leftFilePath,
-		RightPath:  rightFilePath,
 		LeftLabel:  leftLabel,
 		RightLabel: rightLabel,
 		CreatedAt:  time.Now(),
+		Left:       *left,
+		Right:      *right,
+	}
+
+	if options.LeftPath != "" {
+		diffInfo.OldPath = options.LeftPath
+	}
+
+	if left.IsBinary || right.IsBinary {
+		binaryInfo, err := GetBinaryDiffInfo(ctx, options)
+		if err != nil {
+			logger.Log.Warning("Failed to load the binary diff info for %s: %v", file.Path, err)
+		} else {
+			diffInfo.BinaryInfo = binaryInfo
+		}
 	}
 
-	logger.Log.Info("Created staging diff session: %s", sessionId)
 	return diffInfo, nil
 }
 
-// CleanupStagingDiffSession cleans up temporary files for a staging diff session
-func CleanupStagingDiffSession(sessionId string) error {
-	tempDir := os.TempDir()
-	sessionDir := filepath.Join(tempDir, "gitwhale-staging", sessionId)
+func readStagingSide(ctx context.Context, repoPath, ref, path string, side DiffSide) (*DiffFileContent, error) {
+	fileContent := &DiffFileContent{Path: path, Side: side, Size: -1}
+	if path == "" {
+		return fileContent, nil
+	}
 
-	logger.Log.Info("Cleaning up staging diff session: %s", sessionId)
-	err := os.RemoveAll(sessionDir)
+	content, exists, err := readFileBytesFromRef(ctx, repoPath, ref, path)
 	if err != nil {
-		logger.Log.Error("Failed to cleanup staging diff session %s: %v", sessionId, err)
-		return err
+		return nil, fmt.Errorf("failed to load the %s side of %s: %v", side, path, err)
 	}
-	return nil
+	if !exists {
+		return fileContent, nil
+	}
+
+	fileContent.Exists = true
+	fileContent.Size = int64(len(content))
+	fileContent.Content, fileContent.Encoding = decodeFileContent(content)
+	fileContent.IsBinary = fileContent.Encoding == EncodingBinary
+	return fileContent, nil
 }
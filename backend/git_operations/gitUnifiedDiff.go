@@ -0,0 +1,343 @@
+package git_operations
+
+import (
+	"context"
+	"fmt"
+	"gitwhale/backend/logger"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+type DiffLineType string
+
+const (
+	DiffLineContext DiffLineType = "context"
+	DiffLineAdded   DiffLineType = "added"
+	DiffLineDeleted DiffLineType = "deleted"
+)
+
+// IntralineRange marks the changed part of a line, as byte offsets into DiffLine.Content ([Start, End))
+type IntralineRange struct {
+	Start int `json:"start"`
+	End   int `json:"end"`
+}
+
+type DiffLine struct {
+	Type    DiffLineType `json:"type"`
+	Content string       `json:"content"`
+
+	// 1-based line numbers on each side, 0 when the line doesn't exist on that side
+	OldLineNumber int `json:"oldLineNumber"`
+	NewLineNumber int `json:"newLineNumber"`
+
+	NoNewlineAtEndOfFile bool `json:"noNewlineAtEndOfFile"`
+
+	// The words that changed compared to the paired deleted/added line (only for modified lines)
+	IntralineChanges []IntralineRange `json:"intralineChanges"`
+}
+
+type DiffHunk struct {
+	OldStart int        `json:"oldStart"`
+	OldLines int        `json:"oldLines"`
+	NewStart int        `json:"newStart"`
+	NewLines int        `json:"newLines"`
+	Section  string     `json:"section"` // The function/section name git prints after the @@ header
+	Lines    []DiffLine `json:"lines"`
+}
+
+type DiffFile struct {
+	OldPath    string     `json:"oldPath"` // Empty for added files
+	NewPath    string     `json:"newPath"` // Empty for deleted files
+	Status     string     `json:"status"`  // M, A, D, R, C
+	OldMode    string     `json:"oldMode"`
+	NewMode    string     `json:"newMode"`
+	Similarity int        `json:"similarity"` // For renames and copies
+	IsBinary   bool       `json:"isBinary"`
+	Hunks      []DiffHunk `json:"hunks"`
+}
+
+type UnifiedDiff struct {
+	FromRef string     `json:"fromRef"`
+	ToRef   string     `json:"toRef"`
+	Files   []DiffFile `json:"files"`
+}
+
+const defaultDiffContextLines = 3
+
+var hunkHeaderRegex = regexp.MustCompile(`^@@ -(\d+)(?:,(\d+))? \+(\d+)(?:,(\d+))? @@ ?(.*)$`)
+
+// GetUnifiedDiff runs `git diff` for the given options and parses it into files, hunks and lines
+func GetUnifiedDiff(ctx context.Context, options DiffOptions) (*UnifiedDiff, error) {
+	logger.Log.Info("Getting unified diff for repo: %s, from: %s, to: %s", options.RepoPath, options.FromRef, options.ToRef)
+
+	options = normalizeDiffOptions(options)
+	if err := validateDiffInputs(ctx, options); err != nil {
+		return nil, err
+	}
+
+	contextLines := defaultDiffContextLines
+	if options.ContextLines != nil && *options.ContextLines >= 0 {
+		contextLines = *options.ContextLines
+	}
+
+	args := []string{"-c", "core.quotePath=false", "diff", "--no-color", "--no-ext-diff", fmt.Sprintf("--unified=%d", contextLines)}
+	args = append(args, options.DiffSettings.gitArgs()...)
+	args = append(args, diffRevisionArgs(options)...)
+	args = append(args, "--")
+	args = append(args, options.Pathspecs...)
+
+	result, err := runGit(ctx, options.RepoPath, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get diff: %w", err)
+	}
+
+	files, err := parseUnifiedDiff(result.Stdout)
+	if err != nil {
+		return nil, err
+	}
+
+	for fileIndex := range files {
+		for hunkIndex := range files[fileIndex].Hunks {
+			computeIntralineChanges(&files[fileIndex].Hunks[hunkIndex])
+		}
+	}
+
+	return &UnifiedDiff{
+		FromRef: options.FromRef,
+		ToRef:   options.ToRef,
+		Files:   files,
+	}, nil
+}
+
+// normalizeDiffOptions resolves a single commit diff into a diff against one of the commit's parents
+func normalizeDiffOptions(options DiffOptions) DiffOptions {
+	if options.IsSingleCommitDiff {
+		options.RangeMode = DiffRangeTwoDot
+		if options.ParentIndex > 1 {
+			options.ToRef = fmt.Sprintf("%s^%d", options.FromRef, options.ParentIndex)
+		} else {
+			options.ToRef = fmt.Sprintf("%s^", options.FromRef)
+		}
+	}
+	return options
+}
+
+// diffRevisionArgs returns the revisions to pass to git, in (left, right) order. Like the difftool flow,
+// ToRef is the left side, and an empty ToRef diffs FromRef against the working tree
+func diffRevisionArgs(options DiffOptions) []string {
+	if options.ToRef == "" {
+		return []string{options.FromRef}
+	}
+	if options.RangeMode == DiffRangeThreeDot {
+		// git diffs the merge-base of both refs against FromRef
+		return []string{fmt.Sprintf("%s...%s", options.ToRef, options.FromRef)}
+	}
+	return []string{options.ToRef, options.FromRef}
+}
+
+// parseUnifiedDiff parses the output of `git diff` (without color or external diff drivers)
+func parseUnifiedDiff(output string) ([]DiffFile, error) {
+	files := []DiffFile{}
+	var currentFile *DiffFile
+	var currentHunk *DiffHunk
+	oldLineNumber, newLineNumber := 0, 0
+
+	lines := strings.Split(output, "\n")
+	// The output ends with a newline, which doesn't start a new line
+	if len(lines) > 0 && lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+
+	finishFile := func() {
+		if currentFile != nil {
+			files = append(files, *currentFile)
+		}
+		currentFile = nil
+		currentHunk = nil
+	}
+
+	for lineIndex, line := range lines {
+		if strings.HasPrefix(line, "diff --git ") {
+			finishFile()
+			currentFile = &DiffFile{Status: "M", Hunks: []DiffHunk{}}
+			currentFile.OldPath, currentFile.NewPath = parseDiffGitHeaderPaths(strings.TrimPrefix(line, "diff --git "))
+			continue
+		}
+
+		if currentFile == nil {
+			return nil, fmt.Errorf("unexpected diff output on line %d: %s", lineIndex+1, line)
+		}
+
+		// Inside a hunk, every line starts with a marker
+		if currentHunk != nil {
+			if handled := appendHunkLine(currentHunk, line, &oldLineNumber, &newLineNumber); handled {
+				continue
+			}
+			currentHunk = nil
+		}
+
+		if strings.HasPrefix(line, "@@ ") {
+			matches := hunkHeaderRegex.FindStringSubmatch(line)
+			if matches == nil {
+				return nil, fmt.Errorf("invalid hunk header on line %d: %s", lineIndex+1, line)
+			}
+
+			hunk := DiffHunk{
+				OldStart: atoiOrDefault(matches[1], 0),
+				OldLines: atoiOrDefault(matches[2], 1),
+				NewStart: atoiOrDefault(matches[3], 0),
+				NewLines: atoiOrDefault(matches[4], 1),
+				Section:  matches[5],
+				Lines:    []DiffLine{},
+			}
+			currentFile.Hunks = append(currentFile.Hunks, hunk)
+			currentHunk = &currentFile.Hunks[len(currentFile.Hunks)-1]
+			oldLineNumber, newLineNumber = hunk.OldStart, hunk.NewStart
+			continue
+		}
+
+		parseDiffExtendedHeader(currentFile, line)
+	}
+	finishFile()
+
+	return files, nil
+}
+
+// Adds a line to the hunk. Returns false when the line isn't part of the hunk
+func appendHunkLine(hunk *DiffHunk, line string, oldLineNumber, newLineNumber *int) bool {
+	if line == "" {
+		return false
+	}
+
+	switch line[0] {
+	case ' ':
+		hunk.Lines = append(hunk.Lines, DiffLine{Type: DiffLineContext, Content: line[1:], OldLineNumber: *oldLineNumber, NewLineNumber: *newLineNumber})
+		*oldLineNumber++
+		*newLineNumber++
+	case '-':
+		hunk.Lines = append(hunk.Lines, DiffLine{Type: DiffLineDeleted, Content: line[1:], OldLineNumber: *oldLineNumber})
+		*oldLineNumber++
+	case '+':
+		hunk.Lines = append(hunk.Lines, DiffLine{Type: DiffLineAdded, Content: line[1:], NewLineNumber: *newLineNumber})
+		*newLineNumber++
+	case '\\':
+		// "\ No newline at end of file" applies to the line right before it
+		if len(hunk.Lines) > 0 {
+			hunk.Lines[len(hunk.Lines)-1].NoNewlineAtEndOfFile = true
+		}
+	default:
+		return false
+	}
+
+	return true
+}
+
+// Parses the header lines between "diff --git" and the first hunk
+func parseDiffExtendedHeader(file *DiffFile, line string) {
+	switch {
+	case strings.HasPrefix(line, "new file mode "):
+		file.Status = "A"
+		file.NewMode = strings.TrimPrefix(line, "new file mode ")
+		file.OldPath = ""
+	case strings.HasPrefix(line, "deleted file mode "):
+		file.Status = "D"
+		file.OldMode = strings.TrimPrefix(line, "deleted file mode ")
+		file.NewPath = ""
+	case strings.HasPrefix(line, "old mode "):
+		file.OldMode = strings.TrimPrefix(line, "old mode ")
+	case strings.HasPrefix(line, "new mode "):
+		file.NewMode = strings.TrimPrefix(line, "new mode ")
+	case strings.HasPrefix(line, "rename from "):
+		file.Status = "R"
+		file.OldPath = unquoteGitPath(strings.TrimPrefix(line, "rename from "))
+	case strings.HasPrefix(line, "rename to "):
+		file.NewPath = unquoteGitPath(strings.TrimPrefix(line, "rename to "))
+	case strings.HasPrefix(line, "copy from "):
+		file.Status = "C"
+		file.OldPath = unquoteGitPath(strings.TrimPrefix(line, "copy from "))
+	case strings.HasPrefix(line, "copy to "):
+		file.NewPath = unquoteGitPath(strings.TrimPrefix(line, "copy to "))
+	case strings.HasPrefix(line, "similarity index "):
+		file.Similarity = atoiOrDefault(strings.TrimSuffix(strings.TrimPrefix(line, "similarity index "), "%"), 0)
+	case strings.HasPrefix(line, "index "):
+		// "index <old>..<new> <mode>"
+		if fields := strings.Fields(line); len(fields) == 3 && file.OldMode == "" && file.NewMode == "" {
+			file.OldMode = fields[2]
+			file.NewMode = fields[2]
+		}
+	case strings.HasPrefix(line, "Binary files ") || line == "GIT binary patch":
+		file.IsBinary = true
+	case strings.HasPrefix(line, "--- "):
+		if path := strings.TrimPrefix(line, "--- "); path != "/dev/null" {
+			file.OldPath = strings.TrimPrefix(unquoteGitPath(path), "a/")
+		}
+	case strings.HasPrefix(line, "+++ "):
+		if path := strings.TrimPrefix(line, "+++ "); path != "/dev/null" {
+			file.NewPath = strings.TrimPrefix(unquoteGitPath(path), "b/")
+		}
+	}
+}
+
+// Best effort split of "a/<old> b/<new>". The paths get overwritten by the more reliable
+// ---/+++ and rename headers, but binary and mode-only changes don't have those
+func parseDiffGitHeaderPaths(header string) (string, string) {
+	if strings.HasPrefix(header, "\"") {
+		if oldPath, rest, err := readQuotedGitPath(header); err == nil {
+			return strings.TrimPrefix(oldPath, "a/"), strings.TrimPrefix(unquoteGitPath(strings.TrimSpace(rest)), "b/")
+		}
+	}
+
+	// Without renames both paths are the same, so split the header in half
+	if len(header)%2 == 1 {
+		half := len(header) / 2
+		if header[half] == ' ' && strings.TrimPrefix(header[:half], "a/") == strings.TrimPrefix(header[half+1:], "b/") {
+			path := strings.TrimPrefix(header[:half], "a/")
+			return path, path
+		}
+	}
+
+	oldPath, newPath, _ := strings.Cut(header, " b/")
+	return strings.TrimPrefix(oldPath, "a/"), newPath
+}
+
+// Reads a C-style quoted path from the start of the string, and returns the rest of it
+func readQuotedGitPath(value string) (string, string, error) {
+	for i := 1; i < len(value); i++ {
+		if value[i] == '\\' {
+			i++
+			continue
+		}
+		if value[i] == '"' {
+			path, err := strconv.Unquote(value[:i+1])
+			return path, value[i+1:], err
+		}
+	}
+	return "", "", fmt.Errorf("unterminated quoted path: %s", value)
+}
+
+// git quotes paths with unusual characters using C-style escapes
+func unquoteGitPath(path string) string {
+	if !strings.HasPrefix(path, "\"") {
+		// Paths without spaces may have a trailing tab after them in the ---/+++ headers
+		return strings.TrimSuffix(path, "\t")
+	}
+
+	unquoted, _, err := readQuotedGitPath(path)
+	if err != nil {
+		return path
+	}
+	return unquoted
+}
+
+func atoiOrDefault(value string, defaultValue int) int {
+	if value == "" {
+		return defaultValue
+	}
+
+	parsed, err := strconv.Atoi(value)
+	if err != nil {
+		return defaultValue
+	}
+	return parsed
+}
@@ -0,0 +1,196 @@
+package git_operations
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseUnifiedDiff(t *testing.T) {
+	testCases := []struct {
+		name   string
+		output string
+		want   []DiffFile
+	}{
+		{
+			name: "line numbers",
+			output: `diff --git a/lines.txt b/lines.txt
+index 2019eda..9aca2fb 100644
+--- a/lines.txt
++++ b/lines.txt
+@@ -1,7 +1,9 @@
+ one
+-two
++2
+ three
+ four
+ five
+ six
+ seven
++eight
++nine
+`,
+			want: []DiffFile{{
+				OldPath: "lines.txt", NewPath: "lines.txt", Status: "M", OldMode: "100644", NewMode: "100644",
+				Hunks: []DiffHunk{{
+					OldStart: 1, OldLines: 7, NewStart: 1, NewLines: 9,
+					Lines: []DiffLine{
+						{Type: DiffLineContext, Content: "one", OldLineNumber: 1, NewLineNumber: 1},
+						{Type: DiffLineDeleted, Content: "two", OldLineNumber: 2},
+						{Type: DiffLineAdded, Content: "2", NewLineNumber: 2},
+						{Type: DiffLineContext, Content: "three", OldLineNumber: 3, NewLineNumber: 3},
+						{Type: DiffLineContext, Content: "four", OldLineNumber: 4, NewLineNumber: 4},
+						{Type: DiffLineContext, Content: "five", OldLineNumber: 5, NewLineNumber: 5},
+						{Type: DiffLineContext, Content: "six", OldLineNumber: 6, NewLineNumber: 6},
+						{Type: DiffLineContext, Content: "seven", OldLineNumber: 7, NewLineNumber: 7},
+						{Type: DiffLineAdded, Content: "eight", NewLineNumber: 8},
+						{Type: DiffLineAdded, Content: "nine", NewLineNumber: 9},
+					},
+				}},
+			}},
+		},
+		{
+			name: "no newline at end of file",
+			output: `diff --git a/eof.txt b/eof.txt
+index 0a207c0..817f660 100644
+--- a/eof.txt
++++ b/eof.txt
+@@ -1,2 +1,2 @@
+ a
+-b
+\ No newline at end of file
++c
+\ No newline at end of file
+`,
+			want: []DiffFile{{
+				OldPath: "eof.txt", NewPath: "eof.txt", Status: "M", OldMode: "100644", NewMode: "100644",
+				Hunks: []DiffHunk{{
+					OldStart: 1, OldLines: 2, NewStart: 1, NewLines: 2,
+					Lines: []DiffLine{
+						{Type: DiffLineContext, Content: "a", OldLineNumber: 1, NewLineNumber: 1},
+						{Type: DiffLineDeleted, Content: "b", OldLineNumber: 2, NoNewlineAtEndOfFile: true},
+						{Type: DiffLineAdded, Content: "c", NewLineNumber: 2, NoNewlineAtEndOfFile: true},
+					},
+				}},
+			}},
+		},
+		{
+			name: "renames",
+			output: `diff --git a/old_name.txt b/new_name.txt
+similarity index 68%
+rename from old_name.txt
+rename to new_name.txt
+index 7a28df3..ea1263c 100644
+--- a/old_name.txt
++++ b/new_name.txt
+@@ -3,2 +3,2 @@ beta
+ gamma
+-delta
++epsilon
+diff --git a/same.txt b/moved/same.txt
+similarity index 100%
+rename from same.txt
+rename to moved/same.txt
+`,
+			want: []DiffFile{
+				{
+					OldPath: "old_name.txt", NewPath: "new_name.txt", Status: "R", OldMode: "100644", NewMode: "100644", Similarity: 68,
+					Hunks: []DiffHunk{{
+						OldStart: 3, OldLines: 2, NewStart: 3, NewLines: 2, Section: "beta",
+						Lines: []DiffLine{
+							{Type: DiffLineContext, Content: "gamma", OldLineNumber: 3, NewLineNumber: 3},
+							{Type: DiffLineDeleted, Content: "delta", OldLineNumber: 4},
+							{Type: DiffLineAdded, Content: "epsilon", NewLineNumber: 4},
+						},
+					}},
+				},
+				{OldPath: "same.txt", NewPath: "moved/same.txt", Status: "R", Similarity: 100, Hunks: []DiffHunk{}},
+			},
+		},
+		{
+			name: "binary files",
+			output: `diff --git a/image.bin b/image.bin
+index 8352675..1592e5c 100644
+Binary files a/image.bin and b/image.bin differ
+diff --git a/new.bin b/new.bin
+new file mode 100644
+index 0000000..1592e5c
+Binary files /dev/null and b/new.bin differ
+`,
+			want: []DiffFile{
+				{OldPath: "image.bin", NewPath: "image.bin", Status: "M", OldMode: "100644", NewMode: "100644", IsBinary: true, Hunks: []DiffHunk{}},
+				{NewPath: "new.bin", Status: "A", NewMode: "100644", IsBinary: true, Hunks: []DiffHunk{}},
+			},
+		},
+		{
+			name: "zero context hunks",
+			output: `diff --git a/lines.txt b/lines.txt
+index 2019eda..9aca2fb 100644
+--- a/lines.txt
++++ b/lines.txt
+@@ -2 +2 @@ one
+-two
++2
+@@ -7,0 +8,2 @@ seven
++eight
++nine
+diff --git a/gone.txt b/gone.txt
+deleted file mode 100644
+index 2019eda..0000000
+--- a/gone.txt
++++ /dev/null
+@@ -1,2 +0,0 @@
+-one
+-two
+`,
+			want: []DiffFile{
+				{
+					OldPath: "lines.txt", NewPath: "lines.txt", Status: "M", OldMode: "100644", NewMode: "100644",
+					Hunks: []DiffHunk{
+						{
+							OldStart: 2, OldLines: 1, NewStart: 2, NewLines: 1, Section: "one",
+							Lines: []DiffLine{
+								{Type: DiffLineDeleted, Content: "two", OldLineNumber: 2},
+								{Type: DiffLineAdded, Content: "2", NewLineNumber: 2},
+							},
+						},
+						{
+							OldStart: 7, OldLines: 0, NewStart: 8, NewLines: 2, Section: "seven",
+							Lines: []DiffLine{
+								{Type: DiffLineAdded, Content: "eight", NewLineNumber: 8},
+								{Type: DiffLineAdded, Content: "nine", NewLineNumber: 9},
+							},
+						},
+					},
+				},
+				{
+					OldPath: "gone.txt", Status: "D", OldMode: "100644",
+					Hunks: []DiffHunk{{
+						OldStart: 1, OldLines: 2, NewStart: 0, NewLines: 0,
+						Lines: []DiffLine{
+							{Type: DiffLineDeleted, Content: "one", OldLineNumber: 1},
+							{Type: DiffLineDeleted, Content: "two", OldLineNumber: 2},
+						},
+					}},
+				},
+			},
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			files, err := parseUnifiedDiff(testCase.output)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(files, testCase.want) {
+				t.Fatalf("unexpected files:\nwant %+v\ngot  %+v", testCase.want, files)
+			}
+		})
+	}
+}
+
+func TestParseUnifiedDiffRejectsOutputBeforeTheFirstFile(t *testing.T) {
+	if _, err := parseUnifiedDiff("@@ -1 +1 @@\n-a\n+b\n"); err == nil {
+		t.Fatal("expected an error for a hunk without a file header")
+	}
+}
@@ -0,0 +1,148 @@
+package git_operations
+
+import (
+	"unicode"
+	"unicode/utf8"
+)
+
+// Lines with more tokens than this are marked as fully changed, to keep the LCS table small
+const maxIntralineTokens = 500
+
+// A word, a run of whitespace, or a single punctuation character, as byte offsets into its line
+type intralineToken struct {
+	start int
+	end   int
+}
+
+// computeIntralineChanges pairs up each block of deleted lines with the added lines that directly follow it,
+// and marks the words that differ between every paired deleted/added line
+func computeIntralineChanges(hunk *DiffHunk) {
+	lines := hunk.Lines
+	for i := 0; i < len(lines); {
+		if lines[i].Type != DiffLineDeleted {
+			i++
+			continue
+		}
+
+		deletedStart := i
+		for i < len(lines) && lines[i].Type == DiffLineDeleted {
+			i++
+		}
+		addedStart := i
+		for i < len(lines) && lines[i].Type == DiffLineAdded {
+			i++
+		}
+
+		deletedCount := addedStart - deletedStart
+		addedCount := i - addedStart
+		for pair := 0; pair < deletedCount && pair < addedCount; pair++ {
+			deletedLine := &lines[deletedStart+pair]
+			addedLine := &lines[addedStart+pair]
+			deletedLine.IntralineChanges, addedLine.IntralineChanges = diffLineWords(deletedLine.Content, addedLine.Content)
+		}
+	}
+}
+
+// diffLineWords returns the changed ranges of the old and new versions of a line
+func diffLineWords(oldLine, newLine string) ([]IntralineRange, []IntralineRange) {
+	oldTokens := tokenizeLine(oldLine)
+	newTokens := tokenizeLine(newLine)
+
+	if len(oldTokens) > maxIntralineTokens || len(newTokens) > maxIntralineTokens {
+		return wholeLineRange(oldLine), wholeLineRange(newLine)
+	}
+
+	// Standard LCS table over the tokens, filled from the end so we can walk it forwards
+	lcs := make([][]int, len(oldTokens)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(newTokens)+1)
+	}
+	for i := len(oldTokens) - 1; i >= 0; i-- {
+		for j := len(newTokens) - 1; j >= 0; j-- {
+			if tokenText(oldLine, oldTokens[i]) == tokenText(newLine, newTokens[j]) {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	oldChanged := make([]bool, len(oldTokens))
+	newChanged := make([]bool, len(newTokens))
+	i, j := 0, 0
+	for i < len(oldTokens) && j < len(newTokens) {
+		if tokenText(oldLine, oldTokens[i]) == tokenText(newLine, newTokens[j]) {
+			i++
+			j++
+		} else if lcs[i+1][j] >= lcs[i][j+1] {
+			oldChanged[i] = true
+			i++
+		} else {
+			newChanged[j] = true
+			j++
+		}
+	}
+	for ; i < len(oldTokens); i++ {
+		oldChanged[i] = true
+	}
+	for ; j < len(newTokens); j++ {
+		newChanged[j] = true
+	}
+
+	return mergeChangedTokens(oldTokens, oldChanged), mergeChangedTokens(newTokens, newChanged)
+}
+
+func tokenizeLine(line string) []intralineToken {
+	tokens := []intralineToken{}
+	for start := 0; start < len(line); {
+		firstRune, size := utf8.DecodeRuneInString(line[start:])
+		end := start + size
+
+		if isWordRune(firstRune) || unicode.IsSpace(firstRune) {
+			// Extend words and whitespace runs as far as they go
+			for end < len(line) {
+				nextRune, nextSize := utf8.DecodeRuneInString(line[end:])
+				if isWordRune(nextRune) != isWordRune(firstRune) || unicode.IsSpace(nextRune) != unicode.IsSpace(firstRune) {
+					break
+				}
+				end += nextSize
+			}
+		}
+
+		tokens = append(tokens, intralineToken{start: start, end: end})
+		start = end
+	}
+	return tokens
+}
+
+func isWordRune(r rune) bool {
+	return r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+func tokenText(line string, token intralineToken) string {
+	return line[token.start:token.end]
+}
+
+// Joins adjacent changed tokens into ranges
+func mergeChangedTokens(tokens []intralineToken, changed []bool) []IntralineRange {
+	ranges := []IntralineRange{}
+	for index, token := range tokens {
+		if !changed[index] {
+			continue
+		}
+
+		if len(ranges) > 0 && ranges[len(ranges)-1].End == token.start {
+			ranges[len(ranges)-1].End = token.end
+		} else {
+			ranges = append(ranges, IntralineRange{Start: token.start, End: token.end})
+		}
+	}
+	return ranges
+}
+
+func wholeLineRange(line string) []IntralineRange {
+	if line == "" {
+		return []IntralineRange{}
+	}
+	return []IntralineRange{{Start: 0, End: len(line)}}
+}
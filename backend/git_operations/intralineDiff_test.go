@@ -0,0 +1,106 @@
+package git_operations
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDiffLineWords(t *testing.T) {
+	testCases := []struct {
+		name    string
+		oldLine string
+		newLine string
+		wantOld []IntralineRange
+		wantNew []IntralineRange
+	}{
+		{
+			name:    "changed word",
+			oldLine: "return oldValue + 1",
+			newLine: "return newValue + 1",
+			wantOld: []IntralineRange{{Start: 7, End: 15}},
+			wantNew: []IntralineRange{{Start: 7, End: 15}},
+		},
+		{
+			name:    "added words",
+			oldLine: "foo(a)",
+			newLine: "foo(a, b)",
+			wantOld: []IntralineRange{},
+			wantNew: []IntralineRange{{Start: 5, End: 8}},
+		},
+		{
+			name:    "removed words",
+			oldLine: "if a && b {",
+			newLine: "if a {",
+			// Ties keep the earlier whitespace, so the range starts at the removed word
+			wantOld: []IntralineRange{{Start: 5, End: 10}},
+			wantNew: []IntralineRange{},
+		},
+		{
+			name:    "separate changes",
+			oldLine: "x := compute(first, last)",
+			newLine: "y := compute(first, next)",
+			wantOld: []IntralineRange{{Start: 0, End: 1}, {Start: 20, End: 24}},
+			wantNew: []IntralineRange{{Start: 0, End: 1}, {Start: 20, End: 24}},
+		},
+		{
+			name:    "whitespace",
+			oldLine: "a  b",
+			newLine: "a b",
+			wantOld: []IntralineRange{{Start: 1, End: 3}},
+			wantNew: []IntralineRange{{Start: 1, End: 2}},
+		},
+		{
+			name:    "multi-byte runes",
+			oldLine: "naïve café",
+			newLine: "naïve thé",
+			wantOld: []IntralineRange{{Start: 7, End: 12}},
+			wantNew: []IntralineRange{{Start: 7, End: 11}},
+		},
+		{
+			name:    "identical",
+			oldLine: "same line",
+			newLine: "same line",
+			wantOld: []IntralineRange{},
+			wantNew: []IntralineRange{},
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			oldRanges, newRanges := diffLineWords(testCase.oldLine, testCase.newLine)
+			if !reflect.DeepEqual(oldRanges, testCase.wantOld) {
+				t.Errorf("old ranges: want %v, got %v", testCase.wantOld, oldRanges)
+			}
+			if !reflect.DeepEqual(newRanges, testCase.wantNew) {
+				t.Errorf("new ranges: want %v, got %v", testCase.wantNew, newRanges)
+			}
+		})
+	}
+}
+
+func TestComputeIntralineChangesPairsDeletedAndAddedLines(t *testing.T) {
+	hunk := DiffHunk{Lines: []DiffLine{
+		{Type: DiffLineContext, Content: "start"},
+		{Type: DiffLineDeleted, Content: "one two"},
+		{Type: DiffLineDeleted, Content: "three"},
+		{Type: DiffLineAdded, Content: "one 2"},
+		{Type: DiffLineContext, Content: "middle"},
+		{Type: DiffLineAdded, Content: "only added"},
+	}}
+
+	computeIntralineChanges(&hunk)
+
+	want := [][]IntralineRange{
+		nil,
+		{{Start: 4, End: 7}},
+		nil, // Has no added line to pair with
+		{{Start: 4, End: 5}},
+		nil,
+		nil,
+	}
+	for index, line := range hunk.Lines {
+		if !reflect.DeepEqual(line.IntralineChanges, want[index]) {
+			t.Errorf("line %d (%q): want %v, got %v", index, line.Content, want[index], line.IntralineChanges)
+		}
+	}
+}
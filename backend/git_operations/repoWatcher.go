@@ -0,0 +1,418 @@
+package git_operations
+
+import (
+	"context"
+	"fmt"
+	"gitwhale/backend/logger"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/wailsapp/wails/v2/pkg/runtime"
+)
+
+type RepoWatchEventType string
+
+const (
+	RepoStatusChanged RepoWatchEventType = "statusChanged" // The work tree or the index changed
+	RepoHeadMoved     RepoWatchEventType = "headMoved"     // HEAD points to a different commit or branch
+	RepoRefsChanged   RepoWatchEventType = "refsChanged"   // Branches, tags or remote refs were added, moved or deleted
+)
+
+const (
+	// Changes are batched until the repo has been quiet for this long
+	repoWatchDebounce = 300 * time.Millisecond
+
+	// Long running operations (like a build writing into the work tree) still send updates this often
+	repoWatchMaxDelay = 2 * time.Second
+
+	// The most changed paths sent with a statusChanged event
+	maxRepoWatchEventPaths = 100
+)
+
+// RepoWatchEvent is emitted on the GetRepoWatchTopic topic whenever the repo changes outside of GitWhale
+type RepoWatchEvent struct {
+	RepoPath string             `json:"repoPath"`
+	Type     RepoWatchEventType `json:"type"`
+
+	// The work tree paths (relative to the repo root) that triggered a statusChanged event, when known
+	Paths []string `json:"paths"`
+}
+
+// GetRepoWatchTopic returns the runtime event topic that RepoWatchEvents for a repo are sent to
+func GetRepoWatchTopic(repoPath string) string {
+	return fmt.Sprintf("onRepoChanged://%v", repoPath)
+}
+
+// RepoWatcherManager keeps a file system watcher running for every open repo
+type RepoWatcherManager struct {
+	ctx      context.Context
+	mutex    sync.Mutex
+	watchers map[string]*repoWatcher
+}
+
+func NewRepoWatcherManager(ctx context.Context) *RepoWatcherManager {
+	return &RepoWatcherManager{
+		ctx:      ctx,
+		watchers: make(map[string]*repoWatcher),
+	}
+}
+
+// Watch starts watching a repo. Watching a repo that's already being watched does nothing
+func (manager *RepoWatcherManager) Watch(repoPath string) error {
+	manager.mutex.Lock()
+	defer manager.mutex.Unlock()
+
+	if _, exists := manager.watchers[repoPath]; exists {
+		return nil
+	}
+
+	watcher, err := startRepoWatcher(manager.ctx, repoPath)
+	if err != nil {
+		return err
+	}
+
+	manager.watchers[repoPath] = watcher
+	return nil
+}
+
+func (manager *RepoWatcherManager) Unwatch(repoPath string) {
+	manager.mutex.Lock()
+	defer manager.mutex.Unlock()
+
+	if watcher, exists := manager.watchers[repoPath]; exists {
+		watcher.close()
+		delete(manager.watchers, repoPath)
+	}
+}
+
+func (manager *RepoWatcherManager) StopAll() {
+	manager.mutex.Lock()
+	defer manager.mutex.Unlock()
+
+	for repoPath, watcher := range manager.watchers {
+		watcher.close()
+		delete(manager.watchers, repoPath)
+	}
+}
+
+type repoWatcher struct {
+	ctx       context.Context
+	cancel    context.CancelFunc
+	repoPath  string
+	repoRoot  string
+	gitDir    string // The git directory of this work tree, which has HEAD and the index
+	commonDir string // Shared between linked work trees, and has the refs
+	watcher   *fsnotify.Watcher
+
+	// Only touched by the event loop goroutine
+	pendingPaths   map[string]bool
+	headTouched    bool
+	indexTouched   bool
+	refsTouched    bool
+	firstPendingAt time.Time
+	lastHead       string
+}
+
+func startRepoWatcher(ctx context.Context, repoPath string) (*repoWatcher, error) {
+	logger.Log.Info("Starting the file system watcher for repo: %s", repoPath)
+
+	repoRoot, err := FindRepoRoot(ctx, repoPath)
+	if err != nil {
+		return nil, err
+	}
+
+	gitDir, err := revParsePath(ctx, repoPath, "--absolute-git-dir")
+	if err != nil {
+		return nil, err
+	}
+
+	commonDir, err := revParsePath(ctx, repoPath, "--git-common-dir")
+	if err != nil {
+		return nil, err
+	}
+
+	fsWatcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, fmt.Errorf("could not create file watcher: %w", err)
+	}
+
+	watcherCtx, cancel := context.WithCancel(ctx)
+	watcher := &repoWatcher{
+		ctx:          watcherCtx,
+		cancel:       cancel,
+		repoPath:     repoPath,
+		repoRoot:     repoRoot,
+		gitDir:       gitDir,
+		commonDir:    commonDir,
+		watcher:      fsWatcher,
+		pendingPaths: make(map[string]bool),
+		lastHead:     readHeadState(ctx, repoPath),
+	}
+
+	// HEAD and the index live directly in the git dir. fsnotify isn't recursive, so every ref folder is added
+	gitPaths := []string{gitDir, commonDir}
+	for _, path := range gitPaths {
+		if err := fsWatcher.Add(path); err != nil {
+			watcher.close()
+			return nil, fmt.Errorf("could not watch %s: %w", path, err)
+		}
+	}
+	watcher.addDirectoryTree(filepath.Join(commonDir, "refs"), nil)
+
+	ignoredDirs := listIgnoredDirectories(ctx, repoRoot)
+	watcher.addDirectoryTree(repoRoot, ignoredDirs)
+
+	go watcher.run()
+	return watcher, nil
+}
+
+func (watcher *repoWatcher) close() {
+	logger.Log.Info("Stopping the file system watcher for repo: %s", watcher.repoPath)
+	watcher.cancel()
+	watcher.watcher.Close()
+}
+
+func (watcher *repoWatcher) run() {
+	// A stopped timer, that gets reset every time a change comes in
+	debounceTimer := time.NewTimer(time.Hour)
+	debounceTimer.Stop()
+	defer debounceTimer.Stop()
+
+	for {
+		select {
+		case event, ok := <-watcher.watcher.Events:
+			if !ok {
+				return
+			}
+			if !watcher.recordEvent(event) {
+				continue
+			}
+
+			if watcher.firstPendingAt.IsZero() {
+				watcher.firstPendingAt = time.Now()
+			}
+
+			if time.Since(watcher.firstPendingAt) >= repoWatchMaxDelay {
+				watcher.flush()
+				continue
+			}
+			debounceTimer.Reset(repoWatchDebounce)
+		case <-debounceTimer.C:
+			watcher.flush()
+		case err, ok := <-watcher.watcher.Errors:
+			if !ok {
+				return
+			}
+			logger.Log.Error("Repo watcher error for %s: %v", watcher.repoPath, err)
+		case <-watcher.ctx.Done():
+			return
+		}
+	}
+}
+
+// Sorts the event into the changes that are waiting to be sent. Returns false when the event doesn't matter
+func (watcher *repoWatcher) recordEvent(event fsnotify.Event) bool {
+	path := filepath.Clean(event.Name)
+
+	// Git writes files through .lock files, and then renames them. Only the final rename matters
+	if strings.HasSuffix(path, ".lock") || event.Op == fsnotify.Chmod {
+		return false
+	}
+
+	if path == filepath.Join(watcher.gitDir, "HEAD") {
+		watcher.headTouched = true
+		return true
+	}
+
+	if path == filepath.Join(watcher.gitDir, "index") {
+		watcher.indexTouched = true
+		return true
+	}
+
+	refsDir := filepath.Join(watcher.commonDir, "refs")
+	if path == filepath.Join(watcher.commonDir, "packed-refs") || isInsideDirectory(path, refsDir) {
+		if event.Has(fsnotify.Create) && isDirectory(path) {
+			watcher.addDirectoryTree(path, nil)
+		}
+		watcher.refsTouched = true
+		return true
+	}
+
+	// Everything else in the git dir (objects, logs, hooks...) doesn't change the status or refs on its own
+	if isInsideDirectory(path, watcher.gitDir) || isInsideDirectory(path, watcher.commonDir) {
+		return false
+	}
+
+	relativePath, err := filepath.Rel(watcher.repoRoot, path)
+	if err != nil || strings.HasPrefix(relativePath, "..") {
+		return false
+	}
+
+	if event.Has(fsnotify.Create) && isDirectory(path) {
+		if !isPathIgnored(watcher.ctx, watcher.repoRoot, relativePath) {
+			watcher.addDirectoryTree(path, nil)
+		}
+	}
+
+	watcher.pendingPaths[filepath.ToSlash(relativePath)] = true
+	return true
+}
+
+// Sends the events for everything that changed since the last flush
+func (watcher *repoWatcher) flush() {
+	defer func() {
+		watcher.pendingPaths = make(map[string]bool)
+		watcher.headTouched = false
+		watcher.indexTouched = false
+		watcher.refsTouched = false
+		watcher.firstPendingAt = time.Time{}
+	}()
+
+	changedPaths := filterIgnoredPaths(watcher.ctx, watcher.repoRoot, watcher.pendingPaths)
+
+	if watcher.headTouched || watcher.refsTouched {
+		currentHead := readHeadState(watcher.ctx, watcher.repoPath)
+		if currentHead != watcher.lastHead {
+			watcher.lastHead = currentHead
+			watcher.emit(RepoHeadMoved, nil)
+
+			// Moving HEAD changes what the work tree is compared against
+			watcher.indexTouched = true
+		}
+	}
+
+	if watcher.refsTouched {
+		watcher.emit(RepoRefsChanged, nil)
+	}
+
+	if watcher.indexTouched || len(changedPaths) > 0 {
+		watcher.emit(RepoStatusChanged, changedPaths)
+	}
+}
+
+func (watcher *repoWatcher) emit(eventType RepoWatchEventType, paths []string) {
+	if paths == nil {
+		paths = []string{}
+	}
+
+	logger.Log.Debug("Repo watcher event for %s: %s (%d paths)", watcher.repoPath, eventType, len(paths))
+	runtime.EventsEmit(watcher.ctx, GetRepoWatchTopic(watcher.repoPath), RepoWatchEvent{
+		RepoPath: watcher.repoPath,
+		Type:     eventType,
+		Paths:    paths,
+	})
+}
+
+// Adds a directory and all of its subdirectories, except for the git dir and the ignored directories
+func (watcher *repoWatcher) addDirectoryTree(root string, ignoredDirs map[string]bool) {
+	filepath.WalkDir(root, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil || !entry.IsDir() {
+			return nil
+		}
+
+		if entry.Name() == ".git" || path == watcher.gitDir {
+			return filepath.SkipDir
+		}
+
+		if relativePath, err := filepath.Rel(watcher.repoRoot, path); err == nil && ignoredDirs[filepath.ToSlash(relativePath)] {
+			return filepath.SkipDir
+		}
+
+		if err := watcher.watcher.Add(path); err != nil {
+			// Usually the OS limit on the number of watches. The rest of the repo is still watched
+			logger.Log.Warning("Could not watch %s: %v", path, err)
+			return filepath.SkipDir
+		}
+		return nil
+	})
+}
+
+// The commit HEAD resolves to, and the branch it points to. Empty when HEAD can't be resolved, like in a repo
+// without any commits yet
+func readHeadState(ctx context.Context, repoPath string) string {
+	result, err := runGit(ctx, repoPath, "rev-parse", "HEAD", "--symbolic-full-name", "HEAD")
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(result.Stdout)
+}
+
+func revParsePath(ctx context.Context, repoPath, flag string) (string, error) {
+	result, err := runGit(ctx, repoPath, "rev-parse", "--path-format=absolute", flag)
+	if err != nil {
+		return "", fmt.Errorf("failed to run git rev-parse %s: %w", flag, err)
+	}
+	return filepath.Clean(strings.TrimSpace(result.Stdout)), nil
+}
+
+// Lists the ignored directories (relative to the repo root, without the trailing slash), so they aren't watched
+func listIgnoredDirectories(ctx context.Context, repoRoot string) map[string]bool {
+	result, err := runGit(ctx, repoRoot, "ls-files", "--others", "--ignored", "--exclude-standard", "--directory", "-z")
+
+	ignoredDirs := make(map[string]bool)
+	if err != nil {
+		logger.Log.Warning("Could not list the ignored directories of %s, they will be watched too: %v", repoRoot, err)
+		return ignoredDirs
+	}
+
+	for _, path := range strings.Split(result.Stdout, "\x00") {
+		if strings.HasSuffix(path, "/") {
+			ignoredDirs[strings.TrimSuffix(path, "/")] = true
+		}
+	}
+	return ignoredDirs
+}
+
+// Removes the paths that .gitignore excludes, and returns the rest sorted
+func filterIgnoredPaths(ctx context.Context, repoRoot string, paths map[string]bool) []string {
+	changedPaths := []string{}
+	if len(paths) == 0 {
+		return changedPaths
+	}
+
+	input := strings.Builder{}
+	for path := range paths {
+		input.WriteString(path)
+		input.WriteByte(0)
+	}
+
+	// Tracked files are never reported as ignored, even when they match a pattern, so their changes still count
+	result, err := runGitWithStdin(ctx, repoRoot, strings.NewReader(input.String()), "check-ignore", "-z", "--stdin")
+
+	// check-ignore exits with 1 when none of the paths are ignored
+	ignoredPaths := make(map[string]bool)
+	if err == nil || result.ExitCode == 1 {
+		for _, path := range strings.Split(result.Stdout, "\x00") {
+			ignoredPaths[path] = true
+		}
+	}
+
+	for path := range paths {
+		if !ignoredPaths[path] {
+			changedPaths = append(changedPaths, path)
+		}
+	}
+
+	slices.Sort(changedPaths)
+	return changedPaths[:min(len(changedPaths), maxRepoWatchEventPaths)]
+}
+
+func isPathIgnored(ctx context.Context, repoRoot, relativePath string) bool {
+	_, err := runGit(ctx, repoRoot, "check-ignore", "-q", filepath.ToSlash(relativePath))
+	return err == nil
+}
+
+func isInsideDirectory(path, directory string) bool {
+	return path == directory || strings.HasPrefix(path, directory+string(filepath.Separator))
+}
+
+func isDirectory(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && info.IsDir()
+}
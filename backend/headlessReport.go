@@ -0,0 +1,338 @@
+package backend
+
+import (
+	"bytes"
+	"context"
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"gitwhale/backend/git_operations"
+	"gitwhale/backend/logger"
+	"html/template"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type ReportKind string
+
+const (
+	ReportKindDiff   ReportKind = "diff"
+	ReportKindCommit ReportKind = "commit" // The commit's details, and its diff against its first parent
+)
+
+type ReportFormat string
+
+const (
+	ReportFormatHTML     ReportFormat = "html"
+	ReportFormatMarkdown ReportFormat = "markdown"
+	ReportFormatJSON     ReportFormat = "json"
+)
+
+var reportFormats = []ReportFormat{ReportFormatHTML, ReportFormatMarkdown, ReportFormatJSON}
+
+// ReportOptions describes a report written by `gitwhale report`, without opening a window
+type ReportOptions struct {
+	Kind        ReportKind
+	Format      ReportFormat
+	OutputPath  string // stdout when empty
+	DiffOptions *git_operations.DiffOptions
+}
+
+// Report is what gets rendered. The json format writes it as is
+type Report struct {
+	Title       string                             `json:"title"`
+	RepoPath    string                             `json:"repoPath"`
+	GeneratedAt time.Time                          `json:"generatedAt"`
+	Commit      *git_operations.DetailedCommitInfo `json:"commit,omitempty"`
+	Diff        *git_operations.UnifiedDiff        `json:"diff"`
+}
+
+//go:embed reportTemplate.html
+var reportTemplateSource string
+
+// RunHeadlessReport writes the report and returns the process's exit code
+func RunHeadlessReport(options *ReportOptions) int {
+	logger.Log.SetHeadless(logger.Warning)
+
+	if err := writeReport(context.Background(), options); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	return 0
+}
+
+func writeReport(ctx context.Context, options *ReportOptions) error {
+	// The same settings as the app, so the report shows the diff the way reviewers see it there
+	appConfig, err := LoadAppConfig()
+	if err != nil || appConfig == nil {
+		logger.Log.Warning("Using the default settings, since the saved ones couldn't be loaded: %v", err)
+		appConfig = &AppConfig{}
+	}
+	git_operations.SetGitBackend(appConfig.Settings.Git.Backend)
+
+	diffOptions := *options.DiffOptions
+	if diffOptions.DiffSettings == nil {
+		diffOptions.DiffSettings = &appConfig.Settings.Git.DiffSettings
+	}
+
+	report, err := buildReport(ctx, options.Kind, diffOptions)
+	if err != nil {
+		return err
+	}
+
+	var output bytes.Buffer
+	switch options.Format {
+	case ReportFormatHTML:
+		err = renderHTMLReport(&output, report)
+	case ReportFormatMarkdown:
+		err = renderMarkdownReport(&output, report)
+	case ReportFormatJSON:
+		encoder := json.NewEncoder(&output)
+		encoder.SetIndent("", "  ")
+		err = encoder.Encode(report)
+	default:
+		err = fmt.Errorf("unknown report format: %s", options.Format)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to render the report: %w", err)
+	}
+
+	if options.OutputPath == "" {
+		_, err = os.Stdout.Write(output.Bytes())
+		return err
+	}
+	if err := os.WriteFile(options.OutputPath, output.Bytes(), 0644); err != nil {
+		return fmt.Errorf("failed to write the report to %s: %w", options.OutputPath, err)
+	}
+	return nil
+}
+
+func buildReport(ctx context.Context, kind ReportKind, options git_operations.DiffOptions) (*Report, error) {
+	report := &Report{
+		Title:       git_operations.DiffTitle(options),
+		RepoPath:    options.RepoPath,
+		GeneratedAt: time.Now(),
+	}
+
+	if kind == ReportKindCommit {
+		commit, err := git_operations.GetDetailedCommitInfo(ctx, options.RepoPath, options.FromRef, options.DiffSettings)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read the commit %s: %w", options.FromRef, err)
+		}
+		report.Commit = commit
+
+		// The commit might have been given as a branch or a tag, the hash doesn't move
+		options.FromRef = commit.CommitHash
+		report.Title = strings.TrimSpace(strings.Join(commit.CommitMessage, "\n"))
+		report.Title, _, _ = strings.Cut(report.Title, "\n")
+	}
+
+	diff, err := git_operations.GetUnifiedDiff(ctx, options)
+	if err != nil {
+		return nil, err
+	}
+	report.Diff = diff
+	return report, nil
+}
+
+// MARK: Rendering helpers
+
+type reportFileStats struct {
+	Added   int
+	Deleted int
+}
+
+func getReportFileStats(file git_operations.DiffFile) reportFileStats {
+	stats := reportFileStats{}
+	for _, hunk := range file.Hunks {
+		for _, line := range hunk.Lines {
+			switch line.Type {
+			case git_operations.DiffLineAdded:
+				stats.Added++
+			case git_operations.DiffLineDeleted:
+				stats.Deleted++
+			}
+		}
+	}
+	return stats
+}
+
+func getReportTotalStats(diff *git_operations.UnifiedDiff) reportFileStats {
+	total := reportFileStats{}
+	for _, file := range diff.Files {
+		stats := getReportFileStats(file)
+		total.Added += stats.Added
+		total.Deleted += stats.Deleted
+	}
+	return total
+}
+
+// Renames and copies show both paths, added and deleted files only have one
+func getReportFilePath(file git_operations.DiffFile) string {
+	switch {
+	case file.NewPath == "":
+		return file.OldPath
+	case file.OldPath == "" || file.OldPath == file.NewPath:
+		return file.NewPath
+	default:
+		return fmt.Sprintf("%s → %s", file.OldPath, file.NewPath)
+	}
+}
+
+func getReportFileStatus(status string) string {
+	switch status {
+	case "A":
+		return "Added"
+	case "D":
+		return "Deleted"
+	case "R":
+		return "Renamed"
+	case "C":
+		return "Copied"
+	case "T":
+		return "Type changed"
+	default:
+		return "Modified"
+	}
+}
+
+func formatReportTimestamp(unixTimestamp string) string {
+	seconds, err := strconv.ParseInt(unixTimestamp, 10, 64)
+	if err != nil {
+		return unixTimestamp
+	}
+	return time.Unix(seconds, 0).Format("2006-01-02 15:04:05 -0700")
+}
+
+// The header git prints above a hunk, e.g. "@@ -12,7 +12,9 @@ func main() {"
+func formatHunkHeader(hunk git_operations.DiffHunk) string {
+	header := fmt.Sprintf("@@ -%d,%d +%d,%d @@", hunk.OldStart, hunk.OldLines, hunk.NewStart, hunk.NewLines)
+	if hunk.Section != "" {
+		header += " " + hunk.Section
+	}
+	return header
+}
+
+type reportLineSegment struct {
+	Text    string
+	Changed bool
+}
+
+// Splits the line into the parts that changed compared to its paired line and the parts that didn't, like the
+// word highlights of the app's diff view
+func getReportLineSegments(line git_operations.DiffLine) []reportLineSegment {
+	segments := []reportLineSegment{}
+	position := 0
+	for _, change := range line.IntralineChanges {
+		start := max(change.Start, position)
+		end := min(change.End, len(line.Content))
+		if start >= end {
+			continue
+		}
+
+		if start > position {
+			segments = append(segments, reportLineSegment{Text: line.Content[position:start]})
+		}
+		segments = append(segments, reportLineSegment{Text: line.Content[start:end], Changed: true})
+		position = end
+	}
+
+	if position < len(line.Content) {
+		segments = append(segments, reportLineSegment{Text: line.Content[position:]})
+	}
+	return segments
+}
+
+func renderHTMLReport(output io.Writer, report *Report) error {
+	reportTemplate, err := template.New("report").Funcs(template.FuncMap{
+		"fileStats":  getReportFileStats,
+		"totalStats": getReportTotalStats,
+		"filePath":   getReportFilePath,
+		"fileStatus": getReportFileStatus,
+		"timestamp":  formatReportTimestamp,
+		"hunkHeader": formatHunkHeader,
+		"segments":   getReportLineSegments,
+		"shortHash": func(hash string) string {
+			return hash[:min(len(hash), 7)]
+		},
+	}).Parse(reportTemplateSource)
+	if err != nil {
+		return err
+	}
+	return reportTemplate.Execute(output, report)
+}
+
+func renderMarkdownReport(output io.Writer, report *Report) error {
+	var builder strings.Builder
+
+	fmt.Fprintf(&builder, "# %s\n\n", report.Title)
+	fmt.Fprintf(&builder, "Repo: `%s` · Generated: %s\n\n", report.RepoPath, report.GeneratedAt.Format("2006-01-02 15:04:05 -0700"))
+
+	if commit := report.Commit; commit != nil {
+		fmt.Fprintf(&builder, "**Commit** `%s`  \n", commit.CommitHash)
+		fmt.Fprintf(&builder, "**Author** %s <%s>  \n", commit.Username, commit.UserEmail)
+		fmt.Fprintf(&builder, "**Date** %s\n\n", formatReportTimestamp(commit.AuthoredTimeStamp))
+
+		message := strings.Join(commit.CommitMessage, "\n")
+		fence := getMarkdownFence(message)
+		fmt.Fprintf(&builder, "%s\n%s\n%s\n\n", fence, strings.TrimRight(message, "\n"), fence)
+	}
+
+	total := getReportTotalStats(report.Diff)
+	fmt.Fprintf(&builder, "## %d files changed, +%d −%d\n\n", len(report.Diff.Files), total.Added, total.Deleted)
+
+	for _, file := range report.Diff.Files {
+		stats := getReportFileStats(file)
+		fmt.Fprintf(&builder, "### `%s` (%s, +%d −%d)\n\n", getReportFilePath(file), getReportFileStatus(file.Status), stats.Added, stats.Deleted)
+
+		if file.IsBinary {
+			builder.WriteString("Binary file\n\n")
+			continue
+		}
+		if len(file.Hunks) == 0 {
+			continue
+		}
+
+		var patch strings.Builder
+		for _, hunk := range file.Hunks {
+			patch.WriteString(formatHunkHeader(hunk) + "\n")
+			for _, line := range hunk.Lines {
+				switch line.Type {
+				case git_operations.DiffLineAdded:
+					patch.WriteString("+")
+				case git_operations.DiffLineDeleted:
+					patch.WriteString("-")
+				default:
+					patch.WriteString(" ")
+				}
+				patch.WriteString(line.Content + "\n")
+				if line.NoNewlineAtEndOfFile {
+					patch.WriteString("\\ No newline at end of file\n")
+				}
+			}
+		}
+
+		fence := getMarkdownFence(patch.String())
+		fmt.Fprintf(&builder, "%sdiff\n%s%s\n\n", fence, patch.String(), fence)
+	}
+
+	_, err := io.WriteString(output, builder.String())
+	return err
+}
+
+// A code fence has to be longer than any run of backticks inside of it
+func getMarkdownFence(content string) string {
+	longestRun, currentRun := 0, 0
+	for _, char := range content {
+		if char == '`' {
+			currentRun++
+			longestRun = max(longestRun, currentRun)
+		} else {
+			currentRun = 0
+		}
+	}
+	return strings.Repeat("`", max(3, longestRun+1))
+}
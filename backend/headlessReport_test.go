@@ -0,0 +1,88 @@
+package backend
+
+import (
+	"encoding/json"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+)
+
+func runReportTestGit(t *testing.T, repoPath string, args ...string) {
+	t.Helper()
+
+	cmd := exec.Command("git", args...)
+	cmd.Dir = repoPath
+	cmd.Env = append(os.Environ(),
+		"GIT_AUTHOR_NAME=Test", "GIT_AUTHOR_EMAIL=test@example.com",
+		"GIT_COMMITTER_NAME=Test", "GIT_COMMITTER_EMAIL=test@example.com",
+	)
+	if output, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("git %v: %v\n%s", args, err, output)
+	}
+}
+
+// Everything the report command writes to stdout, from parsing the command line on, has to be the report itself
+func TestReportToStdoutIsOnlyJSON(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	repoPath := t.TempDir()
+	runReportTestGit(t, repoPath, "init", "--quiet")
+	if err := os.WriteFile(filepath.Join(repoPath, "file.txt"), []byte("first\nsecond\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	runReportTestGit(t, repoPath, "add", "file.txt")
+	runReportTestGit(t, repoPath, "commit", "--quiet", "-m", "Add a file")
+	if err := os.WriteFile(filepath.Join(repoPath, "file.txt"), []byte("first\nchanged\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	runReportTestGit(t, repoPath, "commit", "--quiet", "-am", "Change the file")
+
+	workingDir, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(repoPath); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Chdir(workingDir) })
+
+	stdoutReader, stdoutWriter, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	originalStdout := os.Stdout
+	os.Stdout = stdoutWriter
+	t.Cleanup(func() { os.Stdout = originalStdout })
+
+	output := make(chan []byte)
+	go func() {
+		data, _ := io.ReadAll(stdoutReader)
+		output <- data
+	}()
+
+	state, err := ParseCommandLine([]string{"report", "commit", "HEAD", "--format", "json"})
+	exitCode := -1
+	if err == nil {
+		exitCode = RunHeadlessReport(state.Report)
+	}
+	stdoutWriter.Close()
+	os.Stdout = originalStdout
+	stdout := <-output
+
+	if err != nil {
+		t.Fatalf("failed to parse the command line: %v", err)
+	}
+	if exitCode != 0 {
+		t.Fatalf("the report exited with %d", exitCode)
+	}
+
+	var report Report
+	if err := json.Unmarshal(stdout, &report); err != nil {
+		t.Fatalf("stdout isn't only the JSON report: %v\n%s", err, stdout)
+	}
+	if report.Commit == nil || report.Diff == nil {
+		t.Errorf("the report is missing the commit or its diff: %s", stdout)
+	}
+}
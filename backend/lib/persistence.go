@@ -4,10 +4,14 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strconv"
 )
 
 var APP_NAME = "GitWhale"
 
+// Set by release builds with -ldflags "-X gitwhale/backend/lib.APP_VERSION=<version>"
+var APP_VERSION = "dev"
+
 func GetAppConfigFilePath() (string, error) {
 	appConfigFolder, err := GetAppFolderPath()
 	if err != nil {
@@ -18,34 +22,72 @@ func GetAppConfigFilePath() (string, error) {
 	return appConfigFile, nil
 }
 
-func GetFileDiffNotificationsFolderPath() (string, error) {
-	notifFolderPath, err := GetAppFolderPath()
+// Returns the folder where the manifests of open diff sessions are saved
+func GetDiffSessionsFolderPath() (string, error) {
+	diffSessionsFolderPath, err := GetAppFolderPath()
+	if err != nil {
+		return diffSessionsFolderPath, err
+	}
+
+	diffSessionsFolderPath = filepath.Join(diffSessionsFolderPath, "DiffSessions")
+	CreateDirIfNeeded(diffSessionsFolderPath)
+	return diffSessionsFolderPath, nil
+}
+
+// Returns the path of the script that git runs to ask GitWhale for credentials
+func GetAskPassScriptPath() (string, error) {
+	appFolderPath, err := GetAppFolderPath()
 	if err != nil {
-		return notifFolderPath, err
+		return appFolderPath, err
 	}
 
-	notifFolderPath = filepath.Join(notifFolderPath, "FileDiffs")
-	CreateDirIfNeeded(notifFolderPath)
-	return notifFolderPath, nil
+	return filepath.Join(appFolderPath, "askpass.sh"), nil
 }
 
-func GetFileDiffNotificationLockFilePath() (string, error) {
-	fileDiffsFolder, err := GetFileDiffNotificationsFolderPath()
+// Returns the path of the file with the automation server's socket and token, while it's running
+func GetAutomationInfoFilePath() (string, error) {
+	appFolderPath, err := GetAppFolderPath()
 	if err != nil {
-		return fileDiffsFolder, err
+		return appFolderPath, err
 	}
 
-	lockFile := filepath.Join(fileDiffsFolder, "running.lock")
-	return lockFile, nil
+	return filepath.Join(appFolderPath, "Automation.json"), nil
 }
 
-func IsFileDiffNotificationLockFileExists() (bool, error) {
-	lockFilePath, err := GetFileDiffNotificationLockFilePath()
+// Returns a folder for GitWhale's sockets that only the current user can open: $XDG_RUNTIME_DIR/gitwhale when it's
+// set, or a gitwhale-<user id> folder in the temp folder otherwise
+func GetSocketFolderPath() (string, error) {
+	var socketFolderPath string
+	if runtimeDir := os.Getenv("XDG_RUNTIME_DIR"); runtimeDir != "" {
+		socketFolderPath = filepath.Join(runtimeDir, "gitwhale")
+	} else {
+		userID := strconv.Itoa(os.Getuid())
+		if os.Getuid() == -1 {
+			// There are no user IDs on Windows, where the temp folder is per user anyway
+			homePath, _ := os.UserHomeDir()
+			userID = strconv.FormatUint(uint64(HashString(homePath)), 10)
+		}
+		socketFolderPath = filepath.Join(os.TempDir(), "gitwhale-"+userID)
+	}
+
+	if err := os.MkdirAll(socketFolderPath, 0700); err != nil {
+		return "", fmt.Errorf("failed to create the socket folder %s: %w", socketFolderPath, err)
+	}
+
+	// The folder could have been created by someone else, since the temp folder is shared. Only its owner can
+	// change its permissions, and a symlink isn't followed
+	info, err := os.Lstat(socketFolderPath)
 	if err != nil {
-		return false, err
+		return "", fmt.Errorf("failed to check the socket folder %s: %w", socketFolderPath, err)
+	}
+	if !info.IsDir() {
+		return "", fmt.Errorf("the socket folder %s isn't a folder", socketFolderPath)
+	}
+	if err := os.Chmod(socketFolderPath, 0700); err != nil {
+		return "", fmt.Errorf("the socket folder %s belongs to another user: %w", socketFolderPath, err)
 	}
 
-	return FileExists(lockFilePath), nil
+	return socketFolderPath, nil
 }
 
 // Returns the ~/Documents/GitWhale/ directory path
@@ -88,7 +88,10 @@ func CreateDirIfNeeded(path string) {
 
 func IsDir(path string) (bool, error) {
 	stat, err := os.Stat(path)
-	return stat.IsDir(), err
+	if err != nil {
+		return false, err
+	}
+	return stat.IsDir(), nil
 }
 
 func DirExists(dirPath string) bool {
@@ -3,6 +3,7 @@ package logger
 import (
 	"context"
 	"fmt"
+	"os"
 	"time"
 
 	"github.com/wailsapp/wails/v2/pkg/runtime"
@@ -54,6 +55,10 @@ func levelToString(level LogLevel) string {
 
 type Logger struct {
 	ctx context.Context
+
+	// Without a window (e.g. generating a report), messages go to stderr, so they don't end up in the output
+	headless         bool
+	headlessMinLevel LogLevel
 }
 
 var Log = Logger{}
@@ -67,6 +72,12 @@ func (logger *Logger) SetContext(context context.Context) {
 	logger.ctx = context
 }
 
+// SetHeadless prints the messages at or above minLevel to stderr, for when there's no window to send them to
+func (logger *Logger) SetHeadless(minLevel LogLevel) {
+	logger.headless = true
+	logger.headlessMinLevel = minLevel
+}
+
 func (logger *Logger) GetCachedLogEntries() []LogEntry {
 	entries := logBuffer.entries
 	return entries
@@ -92,9 +103,14 @@ func (logger *Logger) Log(level LogLevel, message string, args ...interface{}) {
 
 	if logger.ctx != nil {
 		runtime.EventsEmit(logger.ctx, "log:entry", entry)
+	} else if logger.headless {
+		if level >= logger.headlessMinLevel {
+			fmt.Fprintf(os.Stderr, "[%s] %s\n", entry.Level, formattedMessage)
+		}
+		return
 	} else {
-		// Continue with existing Wails logging
-		fmt.Printf("[NO CTX DEFINED IN LOGGER]: %v\n", formattedMessage)
+		// Before the window exists, e.g. while parsing the command line. stdout is left to the commands' output
+		fmt.Fprintf(os.Stderr, "[NO CTX DEFINED IN LOGGER]: %v\n", formattedMessage)
 		return
 	}
 
@@ -0,0 +1,159 @@
+package backend
+
+import (
+	"fmt"
+	"gitwhale/backend/logger"
+	"os"
+	"sync"
+
+	"github.com/google/uuid"
+	"github.com/wailsapp/wails/v2/pkg/runtime"
+)
+
+// The running instance asks its frontend to show a merge forwarded by another process through this topic, with a
+// MergeToolRequest
+const OpenMergeToolTopic = "onOpenMergeTool"
+
+// MergeToolArgs are the files that git's mergetool passes: both sides of the conflict, their common ancestor and the
+// file the result is written to
+type MergeToolArgs struct {
+	LocalPath  string `json:"localPath"`
+	RemotePath string `json:"remotePath"`
+	BasePath   string `json:"basePath"` // Doesn't exist when both sides added the file
+	MergedPath string `json:"mergedPath"`
+}
+
+// MergeToolRequest is a merge waiting for the user in the frontend
+type MergeToolRequest struct {
+	ID   string        `json:"id"`
+	Args MergeToolArgs `json:"args"`
+}
+
+// mergeToolSessions tracks the merges shown in the frontend. Each one reports whether the user resolved it to the
+// git mergetool that is waiting for it, through its channel
+type mergeToolSessions struct {
+	mutex  sync.Mutex
+	merges map[string]*pendingMerge
+}
+
+type pendingMerge struct {
+	request  MergeToolRequest
+	resolved chan bool
+}
+
+func newMergeToolSessions() *mergeToolSessions {
+	return &mergeToolSessions{merges: make(map[string]*pendingMerge)}
+}
+
+// Start registers a merge. The channel receives the result once the user resolves or aborts it
+func (sessions *mergeToolSessions) Start(args MergeToolArgs) (MergeToolRequest, <-chan bool) {
+	merge := &pendingMerge{
+		request:  MergeToolRequest{ID: uuid.New().String(), Args: args},
+		resolved: make(chan bool, 1),
+	}
+
+	sessions.mutex.Lock()
+	defer sessions.mutex.Unlock()
+	sessions.merges[merge.request.ID] = merge
+
+	logger.Log.Info("Started merge %s of %s", merge.request.ID, args.MergedPath)
+	return merge.request, merge.resolved
+}
+
+// Finish writes the merged content (when it's resolved) and reports the result to whoever is waiting for the merge
+func (sessions *mergeToolSessions) Finish(mergeID, mergedContent string, resolved bool) error {
+	sessions.mutex.Lock()
+	merge, exists := sessions.merges[mergeID]
+	delete(sessions.merges, mergeID)
+	sessions.mutex.Unlock()
+
+	if !exists {
+		return fmt.Errorf("merge %s doesn't exist", mergeID)
+	}
+
+	if resolved {
+		if err := writeMergedFile(merge.request.Args.MergedPath, mergedContent); err != nil {
+			// Still waiting for the user, who can try again
+			sessions.mutex.Lock()
+			sessions.merges[mergeID] = merge
+			sessions.mutex.Unlock()
+			return err
+		}
+	}
+
+	logger.Log.Info("Finished merge %s of %s (resolved: %v)", mergeID, merge.request.Args.MergedPath, resolved)
+	merge.resolved <- resolved
+	return nil
+}
+
+// CancelAll reports every merge that is still open as unresolved, e.g. when the app is closing
+func (sessions *mergeToolSessions) CancelAll() {
+	sessions.mutex.Lock()
+	defer sessions.mutex.Unlock()
+
+	for mergeID, merge := range sessions.merges {
+		merge.resolved <- false
+		delete(sessions.merges, mergeID)
+	}
+}
+
+// Keeps the permissions of the file git wrote the conflict markers to
+func writeMergedFile(mergedPath, content string) error {
+	mode := os.FileMode(0644)
+	if info, err := os.Stat(mergedPath); err == nil {
+		mode = info.Mode().Perm()
+	}
+
+	if err := os.WriteFile(mergedPath, []byte(content), mode); err != nil {
+		return fmt.Errorf("failed to write the merged file %s: %w", mergedPath, err)
+	}
+	return nil
+}
+
+// Shows a merge that another GitWhale process forwarded, and returns the channel its result arrives on
+func (app *App) openMergeTool(args MergeToolArgs) <-chan bool {
+	request, resolved := app.mergeTools.Start(args)
+	runtime.EventsEmit(app.ctx, OpenMergeToolTopic, request)
+	app.showWindow()
+	return resolved
+}
+
+// Registers the merge this window was started for. Closing the window without resolving it exits with an error,
+// so git doesn't take the file as merged
+func (app *App) startStartupMergeTool(args MergeToolArgs) {
+	request, _ := app.mergeTools.Start(args)
+	app.startupMerge = &request
+	app.exitCode.Store(int32(exitCodeForMerge(false)))
+}
+
+// GetStartupMergeTool returns the merge this window was started for, or nil
+func (app *App) GetStartupMergeTool() *MergeToolRequest {
+	return app.startupMerge
+}
+
+// ExitCode is what the process exits with once the window is closed
+func (app *App) ExitCode() int {
+	return int(app.exitCode.Load())
+}
+
+// FinishMergeTool writes the merged file when the user resolved the conflict, and lets git's mergetool continue.
+// A window that was started for the merge closes, with the exit code git expects
+func (app *App) FinishMergeTool(mergeID, mergedContent string, resolved bool) error {
+	if err := app.mergeTools.Finish(mergeID, mergedContent, resolved); err != nil {
+		return err
+	}
+
+	if app.startupMerge != nil && app.startupMerge.ID == mergeID {
+		app.exitCode.Store(int32(exitCodeForMerge(resolved)))
+		runtime.Quit(app.ctx)
+	}
+	return nil
+}
+
+// git's mergetool (with trustExitCode) treats any other exit code than 0 as an unresolved conflict
+func exitCodeForMerge(resolved bool) int {
+	if resolved {
+		return 0
+	}
+	return 1
+}
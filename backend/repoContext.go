@@ -1,6 +1,9 @@
 package backend
 
-import "gitwhale/backend/git_operations"
+import (
+	"context"
+	"gitwhale/backend/git_operations"
+)
 
 type RepoContext struct {
 	// The file path where the AppConfig struct lives
@@ -8,8 +11,8 @@ type RepoContext struct {
 }
 
 // Called when a repo is first opened by the user
-func CreateContext(repoPath string) *RepoContext {
+func CreateContext(ctx context.Context, repoPath string) *RepoContext {
 	return &RepoContext{
-		CurrentBranchName: git_operations.GetCurrentBranchName(repoPath),
+		CurrentBranchName: git_operations.GetCurrentBranchName(ctx, repoPath),
 	}
 }
@@ -0,0 +1,320 @@
+package backend
+
+import (
+	"bufio"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"gitwhale/backend/git_operations"
+	"gitwhale/backend/lib"
+	"gitwhale/backend/logger"
+	"net"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/wailsapp/wails/v2/pkg/runtime"
+)
+
+// The running instance asks its frontend to show a forwarded file diff through this topic, with a FileInfo
+const OpenFileDiffTopic = "onOpenNewFileDiff"
+
+// The running instance asks its frontend to show a forwarded directory diff through this topic, with a
+// ForwardedDirDiff
+const OpenDirDiffTopic = "onOpenDirDiff"
+
+// How long a new process waits for the running instance to acknowledge a request, before opening its own window
+const instanceAckTimeout = 10 * time.Second
+
+type InstanceRequestType string
+
+const (
+	InstanceRequestFileDiff  InstanceRequestType = "fileDiff"
+	InstanceRequestDirDiff   InstanceRequestType = "dirDiff"
+	InstanceRequestMergeTool InstanceRequestType = "mergeTool"
+	InstanceRequestCommand   InstanceRequestType = "command"
+)
+
+// InstanceRequest is what a new GitWhale process asks the running one to show, instead of opening another window
+type InstanceRequest struct {
+	Type      InstanceRequestType `json:"type"`
+	LeftPath  string              `json:"leftPath,omitempty"`  // fileDiff and dirDiff
+	RightPath string              `json:"rightPath,omitempty"` // fileDiff and dirDiff
+	MergeTool *MergeToolArgs      `json:"mergeTool,omitempty"` // mergeTool
+	Command   *StartupState       `json:"command,omitempty"`   // command: open, diff, log or blame
+
+	// Read from the running instance's token file, so only processes of the same user are listened to
+	Token string `json:"token"`
+}
+
+// InstanceAck is the running instance's answer. When it isn't accepted, the new process opens its own window
+type InstanceAck struct {
+	Accepted bool   `json:"accepted"`
+	Error    string `json:"error,omitempty"`
+}
+
+// InstanceResult is sent after the ack of a dirDiff or mergeTool request, once the user closed the diff or finished
+// the merge. git deletes the files it passed to the tool as soon as the forwarding process exits
+type InstanceResult struct {
+	Resolved bool `json:"resolved"` // Whether the merge was resolved, always true for a dirDiff
+}
+
+// ForwardedDirDiff is a directory diff shown for another process, which waits until CloseForwardedDirDiff
+type ForwardedDirDiff struct {
+	ID        string `json:"id"`
+	LeftPath  string `json:"leftFolderPath"`
+	RightPath string `json:"rightFolderPath"`
+}
+
+// The socket and the token are in a folder that only the current user can open
+func getInstancePaths() (socketPath, tokenPath string, err error) {
+	socketFolderPath, err := lib.GetSocketFolderPath()
+	if err != nil {
+		return "", "", err
+	}
+	return filepath.Join(socketFolderPath, "instance.sock"), filepath.Join(socketFolderPath, "instance.token"), nil
+}
+
+// ForwardToRunningInstance sends the request to the running GitWhale and waits for its acknowledgement. It returns
+// false when no instance is running, or when it didn't accept the request. Directory diffs and merges are waited
+// for until the user is done with them, and the exit code tells git's mergetool whether the merge was resolved
+func ForwardToRunningInstance(request InstanceRequest) (forwarded bool, exitCode int, err error) {
+	socketPath, tokenPath, err := getInstancePaths()
+	if err != nil {
+		return false, 0, err
+	}
+
+	conn, err := net.DialTimeout("unix", socketPath, time.Second)
+	if err != nil {
+		// Nothing is listening, or the socket was left behind by a process that crashed
+		return false, 0, nil
+	}
+	defer conn.Close()
+
+	token, err := os.ReadFile(tokenPath)
+	if err != nil {
+		return false, 0, fmt.Errorf("failed to read the running instance's token: %w", err)
+	}
+	request.Token = string(token)
+
+	conn.SetDeadline(time.Now().Add(instanceAckTimeout))
+	if err := json.NewEncoder(conn).Encode(request); err != nil {
+		return false, 0, fmt.Errorf("failed to send the request to the running instance: %w", err)
+	}
+
+	decoder := json.NewDecoder(conn)
+	var ack InstanceAck
+	if err := decoder.Decode(&ack); err != nil {
+		return false, 0, fmt.Errorf("the running instance didn't acknowledge the request: %w", err)
+	}
+	if !ack.Accepted {
+		return false, 0, fmt.Errorf("the running instance refused the request: %s", ack.Error)
+	}
+
+	if request.Type != InstanceRequestDirDiff && request.Type != InstanceRequestMergeTool {
+		return true, 0, nil
+	}
+
+	// It's in the running instance's window now, so it isn't opened here even if the result never arrives
+	conn.SetDeadline(time.Time{})
+	var result InstanceResult
+	if err := decoder.Decode(&result); err != nil {
+		return true, exitCodeForMerge(false), fmt.Errorf("the running instance closed before the %s was finished: %w", request.Type, err)
+	}
+	return true, exitCodeForMerge(result.Resolved), nil
+}
+
+// InstanceServer makes this process the running instance, which the next GitWhale processes forward their
+// requests to
+type InstanceServer struct {
+	app       *App
+	listener  net.Listener
+	token     string
+	tokenPath string
+}
+
+// StartInstanceServer listens for requests from new processes. It fails when another instance is alive, in which
+// case this process just keeps its own window
+func StartInstanceServer(app *App) (*InstanceServer, error) {
+	socketPath, tokenPath, err := getInstancePaths()
+	if err != nil {
+		return nil, err
+	}
+
+	listener, err := net.Listen("unix", socketPath)
+	if err != nil {
+		// The socket file outlives a process that crashed. It's only in use if something still answers on it
+		if conn, dialErr := net.DialTimeout("unix", socketPath, time.Second); dialErr == nil {
+			conn.Close()
+			return nil, fmt.Errorf("another instance is already running")
+		}
+
+		logger.Log.Info("Removing the stale instance socket at %s", socketPath)
+		_ = os.Remove(socketPath)
+		listener, err = net.Listen("unix", socketPath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to listen on %s: %w", socketPath, err)
+		}
+	}
+
+	// The token is only written once this process owns the socket, so it can't replace the one of a running instance
+	server := &InstanceServer{app: app, listener: listener, token: uuid.New().String(), tokenPath: tokenPath}
+	if err := os.Chmod(socketPath, 0600); err != nil {
+		listener.Close()
+		return nil, fmt.Errorf("failed to restrict the permissions of %s: %w", socketPath, err)
+	}
+	if err := os.WriteFile(tokenPath, []byte(server.token), 0600); err != nil {
+		listener.Close()
+		return nil, fmt.Errorf("failed to write the instance token to %s: %w", tokenPath, err)
+	}
+	go server.acceptConnections()
+
+	logger.Log.Info("Listening for requests from other GitWhale processes on %s", socketPath)
+	return server, nil
+}
+
+// Stop closes the socket, so the next process becomes the running instance
+func (server *InstanceServer) Stop() {
+	server.listener.Close()
+	_ = os.Remove(server.tokenPath)
+}
+
+func (server *InstanceServer) acceptConnections() {
+	for {
+		conn, err := server.listener.Accept()
+		if err != nil {
+			if !errors.Is(err, net.ErrClosed) {
+				logger.Log.Error("Instance server stopped accepting connections: %v", err)
+			}
+			return
+		}
+		go server.handleConnection(conn)
+	}
+}
+
+func (server *InstanceServer) handleConnection(conn net.Conn) {
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(instanceAckTimeout))
+
+	var request InstanceRequest
+	if err := json.NewDecoder(bufio.NewReader(conn)).Decode(&request); err != nil {
+		logger.Log.Error("Received a malformed instance request: %v", err)
+		return
+	}
+
+	encoder := json.NewEncoder(conn)
+	if subtle.ConstantTimeCompare([]byte(request.Token), []byte(server.token)) != 1 {
+		logger.Log.Warning("Refused a %s request with an invalid token", request.Type)
+		_ = encoder.Encode(InstanceAck{Error: "invalid token"})
+		return
+	}
+
+	logger.Log.Info("Received a %s request from another GitWhale process", request.Type)
+	finished, err := server.handleRequest(request)
+	ack := InstanceAck{Accepted: true}
+	if err != nil {
+		logger.Log.Error("Failed to handle the %s request: %v", request.Type, err)
+		ack = InstanceAck{Error: err.Error()}
+	}
+
+	if err := encoder.Encode(ack); err != nil {
+		logger.Log.Warning("Failed to acknowledge the %s request: %v", request.Type, err)
+		return
+	}
+	if finished == nil {
+		return
+	}
+
+	// The forwarding process waits as long as the user needs
+	conn.SetDeadline(time.Time{})
+	if err := encoder.Encode(InstanceResult{Resolved: <-finished}); err != nil {
+		logger.Log.Warning("Failed to send the result of the %s request: %v", request.Type, err)
+	}
+}
+
+// Returns a channel for the requests that the forwarding process waits for, which receives the result once the user
+// is done with them
+func (server *InstanceServer) handleRequest(request InstanceRequest) (<-chan bool, error) {
+	switch request.Type {
+	case InstanceRequestFileDiff:
+		return nil, server.openFileDiff(request.LeftPath, request.RightPath)
+	case InstanceRequestDirDiff:
+		return server.app.openDirDiff(request.LeftPath, request.RightPath)
+	case InstanceRequestMergeTool:
+		if request.MergeTool == nil || !lib.FileExists(request.MergeTool.MergedPath) {
+			return nil, fmt.Errorf("the merged file doesn't exist")
+		}
+		return server.app.openMergeTool(*request.MergeTool), nil
+	case InstanceRequestCommand:
+		if request.Command == nil || !lib.DirExists(request.Command.RepoPath) {
+			return nil, fmt.Errorf("the command doesn't have a repo that exists")
+		}
+		server.app.runStartupCommand(request.Command)
+		return nil, nil
+	default:
+		return nil, fmt.Errorf("unknown request type: %s", request.Type)
+	}
+}
+
+// The paths were made absolute by the process that forwarded them, since its working directory can be different
+func (server *InstanceServer) openFileDiff(leftPath, rightPath string) error {
+	if !lib.FileExists(leftPath) && !lib.FileExists(rightPath) {
+		return fmt.Errorf("neither %s nor %s exist", leftPath, rightPath)
+	}
+
+	newFileNode := git_operations.FileInfo{
+		Path:            "",
+		Name:            filepath.Base(leftPath),
+		Extension:       lib.RemoveLeadingPeriod(filepath.Ext(leftPath)),
+		LeftDirAbsPath:  leftPath,
+		RightDirAbsPath: rightPath,
+	}
+
+	logger.Log.Info("Opening new diff: %v", lib.PrettyPrint(newFileNode))
+	runtime.EventsEmit(server.app.ctx, OpenFileDiffTopic, newFileNode)
+	server.app.showWindow()
+	return nil
+}
+
+// Shows a directory diff that another GitWhale process forwarded. The channel receives true once the user closes it
+func (app *App) openDirDiff(leftPath, rightPath string) (<-chan bool, error) {
+	if !lib.DirExists(leftPath) || !lib.DirExists(rightPath) {
+		return nil, fmt.Errorf("%s or %s isn't a folder", leftPath, rightPath)
+	}
+
+	dirDiff := ForwardedDirDiff{ID: uuid.New().String(), LeftPath: leftPath, RightPath: rightPath}
+	closed := make(chan bool, 1)
+
+	app.forwardedDirDiffsMutex.Lock()
+	app.forwardedDirDiffs[dirDiff.ID] = closed
+	app.forwardedDirDiffsMutex.Unlock()
+
+	logger.Log.Info("Opening new directory diff of %s and %s", leftPath, rightPath)
+	runtime.EventsEmit(app.ctx, OpenDirDiffTopic, dirDiff)
+	app.showWindow()
+	return closed, nil
+}
+
+// CloseForwardedDirDiff lets the process that forwarded the directory diff exit, after the user closed it
+func (app *App) CloseForwardedDirDiff(dirDiffID string) {
+	app.forwardedDirDiffsMutex.Lock()
+	defer app.forwardedDirDiffsMutex.Unlock()
+
+	if closed, exists := app.forwardedDirDiffs[dirDiffID]; exists {
+		closed <- true
+		delete(app.forwardedDirDiffs, dirDiffID)
+	}
+}
+
+// Lets every process that forwarded a directory diff exit, e.g. when the app is closing
+func (app *App) closeForwardedDirDiffs() {
+	app.forwardedDirDiffsMutex.Lock()
+	defer app.forwardedDirDiffsMutex.Unlock()
+
+	for dirDiffID, closed := range app.forwardedDirDiffs {
+		closed <- true
+		delete(app.forwardedDirDiffs, dirDiffID)
+	}
+}
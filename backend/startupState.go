@@ -1,82 +1,442 @@
 package backend
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"gitwhale/backend/git_operations"
 	"gitwhale/backend/lib"
-	"gitwhale/backend/logger"
 	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// The running instance asks its frontend to show a command forwarded by another process through this topic, with
+// a StartupState
+const StartupCommandTopic = "onStartupCommand"
 
-	"github.com/fsnotify/fsnotify"
+// StartupCommand is what GitWhale was asked to show on the command line. The frontend routes on it
+type StartupCommand string
+
+const (
+	StartupCommandNone     StartupCommand = "" // Just open the app
+	StartupCommandOpen     StartupCommand = "open"
+	StartupCommandDiff     StartupCommand = "diff"
+	StartupCommandLog      StartupCommand = "log"
+	StartupCommandBlame    StartupCommand = "blame"
+	StartupCommandDiffTool StartupCommand = "difftool"
+	StartupCommandMerge    StartupCommand = "mergetool"
+	StartupCommandReport   StartupCommand = "report" // Runs without a window
 )
 
 type StartupState struct {
-	fileDiffWatcher   *fsnotify.Watcher
-	DirectoryDiffArgs *StartupDirectoryDiffArgs `json:"directoryDiffArgs"`
+	Command StartupCommand `json:"command"`
+
+	// The root of the repo for open, diff, log and blame
+	RepoPath string `json:"repoPath,omitempty"`
+
+	// Relative to RepoPath, with forward slashes. The file to blame, or what to limit the log to ("" for the whole repo)
+	FilePath string `json:"filePath,omitempty"`
+
+	DiffOptions       *git_operations.DiffOptions `json:"diffOptions,omitempty"`
+	DirectoryDiffArgs *StartupDirectoryDiffArgs   `json:"directoryDiffArgs"`
+	MergeToolArgs     *MergeToolArgs              `json:"mergeToolArgs,omitempty"`
+
+	// Never reaches the frontend, the report is written before a window would be created
+	Report *ReportOptions `json:"-"`
 }
 
 type StartupDirectoryDiffArgs struct {
-	LeftPath               string `json:"leftFolderPath"`
-	RightPath              string `json:"rightFolderPath"`
-	IsFileDiff             bool   // TODO: unsupported flag for now
-	ShouldSendNotification bool   // True means that there's another active GitWhale process showing file diffs
-	ShouldStartFileWatcher bool   // Mutually exclusive with ShouldSendNotification
+	LeftPath   string `json:"leftFolderPath"`
+	RightPath  string `json:"rightFolderPath"`
+	IsFileDiff bool   // TODO: unsupported flag for now
 }
 
-func GetStartupState() *StartupState {
+// ParseCommandLine returns these instead of a state when the process should print something and exit
+var (
+	ErrHelpRequested    = errors.New("help requested")
+	ErrVersionRequested = errors.New("version requested")
+)
 
-	// args := []string{
-	// 	"/Users/yousufjazzar/Desktop/gitwhale/build/bin/gitwhale.app/Contents/MacOS/gitwhale  ",
-	// 	"--dir-diff",
-	// 	"/var/folders/4x/3dxp61h50d3bt6jvwvb2bz4m0000gn/T/git-difftool.Znmni5/left/",
-	// 	"/var/folders/4x/3dxp61h50d3bt6jvwvb2bz4m0000gn/T/git-difftool.Znmni5/right/",
-	// }
+// CommandLineUsage is printed by --help, and after a command line that couldn't be parsed
+const CommandLineUsage = `Usage:
+  gitwhale                              Open GitWhale
+  gitwhale open <repo>                  Open a repo
+  gitwhale diff <ref> [<ref>] [-- <path>...]
+                                        Diff a ref against the working tree, or two refs. <ref> can also be a
+                                        range like main..feature or main...feature
+  gitwhale log <path>                   Show the log of a repo, or of a file or folder inside of it
+  gitwhale blame <file>                 Show who last changed each line of a file
+  gitwhale difftool <left> <right>      Diff two files or folders, e.g. as git's difftool
+  gitwhale mergetool <local> <remote> <base> <merged>
+                                        Resolve a conflict, e.g. as git's mergetool. Exits with 0 once it's
+                                        resolved, and with 1 when it was aborted
+  gitwhale report diff <ref> [<ref>] [--format <format>] [-o <file>] [-- <path>...]
+  gitwhale report commit <ref> [--format <format>] [-o <file>]
+                                        Write a diff or a commit without opening a window. <format> is html
+                                        (the default), markdown or json. Without -o it goes to stdout
+  gitwhale --help                       Show this help
+  gitwhale --version                    Show the version
 
-	args := os.Args
+diff and report work in the repo of the current folder. When GitWhale is already running, open, diff, log, blame, difftool
+and mergetool are shown in its window instead of a new one.
+`
 
-	if len(args) != 4 {
-		// test code
-		// return &StartupState{
-		// 	DirectoryDiff: &StartupDirectoryDiffArgs{
-		// 		LeftFolderPath:  "/var/folders/4x/3dxp61h50d3bt6jvwvb2bz4m0000gn/T/git-difftool.F12WVC/left/",
-		// 		RightFolderPath: "/var/folders/4x/3dxp61h50d3bt6jvwvb2bz4m0000gn/T/git-difftool.F12WVC/right/",
-		// 	},
-		// }
+// ParseCommandLine turns the arguments (without the executable) into the state the app starts with. Paths are made
+// absolute, since the state can be forwarded to a running instance with a different working directory
+func ParseCommandLine(args []string) (*StartupState, error) {
+	// macOS passes a process serial number to apps opened from Finder
+	args = slices.DeleteFunc(args, func(arg string) bool { return strings.HasPrefix(arg, "-psn_") })
 
-		logger.Log.Info("Returning default state from getStartupState() because the length was incorrect: %v", len(args))
-		return &StartupState{}
+	if len(args) == 0 {
+		return &StartupState{}, nil
 	}
 
-	if args[1] != "--diff-tool" {
-		logger.Log.Error("Returning default state from getStartupState() because the first flag was incorrect: %v", args[1])
-		return &StartupState{}
+	command, commandArgs := args[0], args[1:]
+	switch command {
+	case "-h", "--help", "help":
+		return nil, ErrHelpRequested
+	case "-v", "--version", "version":
+		return nil, ErrVersionRequested
+	case "open":
+		return parseOpenCommand(commandArgs)
+	case "diff":
+		return parseDiffCommand(commandArgs)
+	case "log":
+		return parseLogCommand(commandArgs)
+	case "blame":
+		return parseBlameCommand(commandArgs)
+	case "difftool", "--diff-tool", "--dir-diff": // The flags are how git's difftool was configured before
+		return parseDiffToolCommand(commandArgs)
+	case "mergetool":
+		return parseMergeToolCommand(commandArgs)
+	case "report":
+		return parseReportCommand(commandArgs)
+	default:
+		return nil, fmt.Errorf("unknown command: %s", command)
 	}
+}
 
-	logger.Log.Debug("Returning a valid startup state from getStartupState() ")
+func parseOpenCommand(args []string) (*StartupState, error) {
+	if len(args) != 1 {
+		return nil, fmt.Errorf("open takes exactly one repo, got %d arguments", len(args))
+	}
+	if !lib.DirExists(args[0]) {
+		return nil, fmt.Errorf("the folder doesn't exist: %s", args[0])
+	}
 
-	isLeftDir, err := lib.IsDir(args[2])
+	repoPath, err := findRepoRoot(args[0])
 	if err != nil {
-		logger.Log.Error("Ran into the following error while testing if '%v' is a directory: %v", args[2], err)
-		return &StartupState{}
+		return nil, err
 	}
+	return &StartupState{Command: StartupCommandOpen, RepoPath: repoPath}, nil
+}
 
-	isRightDir, err := lib.IsDir(args[3])
+func parseDiffCommand(args []string) (*StartupState, error) {
+	options, err := parseDiffOptions(args)
 	if err != nil {
-		logger.Log.Error("Ran into the following error while testing if '%v' is a directory: %v", args[3], err)
-		return &StartupState{}
+		return nil, err
+	}
+	return &StartupState{Command: StartupCommandDiff, RepoPath: options.RepoPath, DiffOptions: options}, nil
+}
+
+// Parses "<ref> [<ref>] [-- <path>...]" like `git diff`, in the repo of the current folder
+func parseDiffOptions(args []string) (*git_operations.DiffOptions, error) {
+	refs, paths := args, []string{}
+	if separator := slices.Index(args, "--"); separator >= 0 {
+		refs, paths = args[:separator], args[separator+1:]
 	}
 
-	shouldSendNotification, err := lib.IsFileDiffNotificationLockFileExists()
+	workingDir, err := os.Getwd()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get the current folder: %w", err)
+	}
+	repoPath, err := findRepoRoot(workingDir)
 	if err != nil {
-		logger.Log.Error("Error while checking file diff lock file: %v", err)
-		return &StartupState{}
+		return nil, err
+	}
+
+	// Same order as `git diff`: the first ref is the old side. DiffOptions names them the other way around
+	options := &git_operations.DiffOptions{RepoPath: repoPath}
+	isRange := false
+	switch {
+	case len(refs) == 1 && strings.Contains(refs[0], "..."):
+		options.ToRef, options.FromRef, _ = strings.Cut(refs[0], "...")
+		options.RangeMode = git_operations.DiffRangeThreeDot
+		isRange = true
+	case len(refs) == 1 && strings.Contains(refs[0], ".."):
+		options.ToRef, options.FromRef, _ = strings.Cut(refs[0], "..")
+		isRange = true
+	case len(refs) == 1:
+		options.FromRef = refs[0]
+	case len(refs) == 2:
+		options.ToRef, options.FromRef = refs[0], refs[1]
+	default:
+		return nil, fmt.Errorf("diff takes one or two refs, got %d", len(refs))
+	}
+
+	// A range with a missing side means HEAD, like in git
+	if isRange && options.FromRef == "" {
+		options.FromRef = "HEAD"
+	}
+	if isRange && options.ToRef == "" {
+		options.ToRef = "HEAD"
+	}
+
+	// The paths are relative to the current folder, like in git, but the diff runs from the repo's root
+	for _, path := range paths {
+		relativePath, err := relativeToRepo(repoPath, path)
+		if err != nil {
+			return nil, err
+		}
+		options.Pathspecs = append(options.Pathspecs, relativePath)
+	}
+
+	return options, nil
+}
+
+func parseLogCommand(args []string) (*StartupState, error) {
+	if len(args) != 1 {
+		return nil, fmt.Errorf("log takes exactly one path, got %d arguments", len(args))
+	}
+
+	isDir, err := lib.IsDir(args[0])
+	if err != nil {
+		return nil, fmt.Errorf("the path doesn't exist: %s", args[0])
+	}
+
+	folderPath := args[0]
+	if !isDir {
+		folderPath = filepath.Dir(args[0])
+	}
+	repoPath, err := findRepoRoot(folderPath)
+	if err != nil {
+		return nil, err
+	}
+
+	filePath, err := relativeToRepo(repoPath, args[0])
+	if err != nil {
+		return nil, err
+	}
+	if filePath == "." {
+		filePath = ""
+	}
+
+	return &StartupState{Command: StartupCommandLog, RepoPath: repoPath, FilePath: filePath}, nil
+}
+
+func parseBlameCommand(args []string) (*StartupState, error) {
+	if len(args) != 1 {
+		return nil, fmt.Errorf("blame takes exactly one file, got %d arguments", len(args))
+	}
+	if !lib.FileExists(args[0]) {
+		return nil, fmt.Errorf("the file doesn't exist: %s", args[0])
+	}
+
+	repoPath, err := findRepoRoot(filepath.Dir(args[0]))
+	if err != nil {
+		return nil, err
+	}
+
+	filePath, err := relativeToRepo(repoPath, args[0])
+	if err != nil {
+		return nil, err
+	}
+
+	return &StartupState{Command: StartupCommandBlame, RepoPath: repoPath, FilePath: filePath}, nil
+}
+
+func parseDiffToolCommand(args []string) (*StartupState, error) {
+	if len(args) != 2 {
+		return nil, fmt.Errorf("difftool takes a left and a right path, got %d arguments", len(args))
+	}
+
+	isLeftDir, err := lib.IsDir(args[0])
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", args[0], err)
+	}
+	isRightDir, err := lib.IsDir(args[1])
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", args[1], err)
+	}
+
+	leftPath, err := filepath.Abs(args[0])
+	if err != nil {
+		return nil, fmt.Errorf("could not get the absolute path to %v: %w", args[0], err)
+	}
+	rightPath, err := filepath.Abs(args[1])
+	if err != nil {
+		return nil, fmt.Errorf("could not get the absolute path to %v: %w", args[1], err)
 	}
 
 	return &StartupState{
+		Command: StartupCommandDiffTool,
 		DirectoryDiffArgs: &StartupDirectoryDiffArgs{
-			LeftPath:               args[2],
-			RightPath:              args[3],
-			IsFileDiff:             !isLeftDir && !isRightDir,
-			ShouldSendNotification: shouldSendNotification,
-			ShouldStartFileWatcher: !shouldSendNotification,
+			LeftPath:   leftPath,
+			RightPath:  rightPath,
+			IsFileDiff: !isLeftDir && !isRightDir,
 		},
+	}, nil
+}
+
+// Takes the files in the order of git's $LOCAL $REMOTE $BASE $MERGED
+func parseMergeToolCommand(args []string) (*StartupState, error) {
+	if len(args) != 4 {
+		return nil, fmt.Errorf("mergetool takes a local, remote, base and merged path, got %d arguments", len(args))
+	}
+
+	absolutePaths := make([]string, len(args))
+	for i, path := range args {
+		absolutePath, err := filepath.Abs(path)
+		if err != nil {
+			return nil, fmt.Errorf("could not get the absolute path to %v: %w", path, err)
+		}
+		absolutePaths[i] = absolutePath
+	}
+
+	// The base is missing when both sides added the file, so only the others have to exist
+	for _, path := range []string{absolutePaths[0], absolutePaths[1], absolutePaths[3]} {
+		if !lib.FileExists(path) {
+			return nil, fmt.Errorf("the file doesn't exist: %s", path)
+		}
+	}
+
+	return &StartupState{
+		Command: StartupCommandMerge,
+		MergeToolArgs: &MergeToolArgs{
+			LocalPath:  absolutePaths[0],
+			RemotePath: absolutePaths[1],
+			BasePath:   absolutePaths[2],
+			MergedPath: absolutePaths[3],
+		},
+	}, nil
+}
+
+func parseReportCommand(args []string) (*StartupState, error) {
+	if len(args) == 0 {
+		return nil, fmt.Errorf("report needs to know what to write: diff or commit")
+	}
+	report := &ReportOptions{Kind: ReportKind(args[0]), Format: ReportFormatHTML}
+
+	// The flags can be anywhere before "--", the other arguments are parsed by the kind of report
+	kindArgs := []string{}
+	args = args[1:]
+	for i := 0; i < len(args); i++ {
+		if args[i] == "--" {
+			kindArgs = append(kindArgs, args[i:]...)
+			break
+		}
+
+		name, value, hasValue := strings.Cut(args[i], "=")
+		if name != "--format" && name != "-o" && name != "--output" {
+			kindArgs = append(kindArgs, args[i])
+			continue
+		}
+
+		if !hasValue {
+			if i+1 >= len(args) {
+				return nil, fmt.Errorf("%s needs a value", name)
+			}
+			i++
+			value = args[i]
+		}
+		if name == "--format" {
+			report.Format = ReportFormat(value)
+		} else {
+			report.OutputPath = value
+		}
+	}
+
+	if !slices.Contains(reportFormats, report.Format) {
+		return nil, fmt.Errorf("unknown report format: %s", report.Format)
+	}
+
+	switch report.Kind {
+	case ReportKindDiff:
+		options, err := parseDiffOptions(kindArgs)
+		if err != nil {
+			return nil, err
+		}
+		report.DiffOptions = options
+	case ReportKindCommit:
+		if len(kindArgs) != 1 {
+			return nil, fmt.Errorf("report commit takes exactly one commit, got %d arguments", len(kindArgs))
+		}
+
+		workingDir, err := os.Getwd()
+		if err != nil {
+			return nil, fmt.Errorf("failed to get the current folder: %w", err)
+		}
+		repoPath, err := findRepoRoot(workingDir)
+		if err != nil {
+			return nil, err
+		}
+		report.DiffOptions = &git_operations.DiffOptions{RepoPath: repoPath, FromRef: kindArgs[0], IsSingleCommitDiff: true}
+	default:
+		return nil, fmt.Errorf("unknown kind of report: %s", report.Kind)
+	}
+
+	return &StartupState{Command: StartupCommandReport, RepoPath: report.DiffOptions.RepoPath, Report: report}, nil
+}
+
+// Symlinks are resolved, so the root and the paths inside of it can be compared (e.g. /var and /private/var on macOS)
+func findRepoRoot(path string) (string, error) {
+	absolutePath, err := filepath.Abs(path)
+	if err != nil {
+		return "", fmt.Errorf("could not get the absolute path to %v: %w", path, err)
+	}
+
+	repoPath, err := git_operations.FindRepoRoot(context.Background(), absolutePath)
+	if err != nil {
+		return "", err
+	}
+
+	if resolvedPath, err := filepath.EvalSymlinks(repoPath); err == nil {
+		repoPath = resolvedPath
+	}
+	return repoPath, nil
+}
+
+// Returns the path relative to the repo's root with forward slashes, which is how git expects it
+func relativeToRepo(repoPath, path string) (string, error) {
+	absolutePath, err := filepath.Abs(path)
+	if err != nil {
+		return "", fmt.Errorf("could not get the absolute path to %v: %w", path, err)
+	}
+	if resolvedPath, err := filepath.EvalSymlinks(absolutePath); err == nil {
+		absolutePath = resolvedPath
+	}
+
+	relativePath, err := filepath.Rel(repoPath, absolutePath)
+	if err != nil || relativePath == ".." || strings.HasPrefix(relativePath, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("%s is outside of the repo at %s", path, repoPath)
+	}
+	return filepath.ToSlash(relativePath), nil
+}
+
+// InstanceRequest returns what this process can hand off to a running GitWhale instead of opening a window, or nil
+func (state *StartupState) InstanceRequest() *InstanceRequest {
+	if state == nil {
+		return nil
+	}
+
+	switch state.Command {
+	case StartupCommandOpen, StartupCommandDiff, StartupCommandLog, StartupCommandBlame:
+		return &InstanceRequest{Type: InstanceRequestCommand, Command: state}
+	case StartupCommandDiffTool:
+		requestType := InstanceRequestDirDiff
+		if state.DirectoryDiffArgs.IsFileDiff {
+			requestType = InstanceRequestFileDiff
+		}
+		return &InstanceRequest{
+			Type:      requestType,
+			LeftPath:  state.DirectoryDiffArgs.LeftPath,
+			RightPath: state.DirectoryDiffArgs.RightPath,
+		}
+	case StartupCommandMerge:
+		return &InstanceRequest{Type: InstanceRequestMergeTool, MergeTool: state.MergeToolArgs}
+	default:
+		return nil
 	}
 }
@@ -3,7 +3,9 @@ import { useEffect } from 'react';
 import { QueryClient, QueryClientProvider } from 'react-query';
 import './App.css';
 import { CommandPalette } from './components/command-palette/CommandPalette';
+import { CredentialPromptDialog } from './components/credential-prompt-dialog';
 import { FileTabs } from './components/file-tabs/file-tabs';
+import { JobsIndicator } from './components/jobs-indicator';
 import LoadingSpinner from './components/loading-spinner';
 import { ThemeProvider } from './components/theme-provider';
 import { Toaster } from './components/ui/toaster';
@@ -15,9 +17,13 @@ import { UseIsDirDiffMode } from './hooks/use-is-dir-diff-mode';
 import { useKeyboardShortcut } from './hooks/utils/use-keyboard-shortcut';
 import DirDiffPage from './pages/DirDiffPage';
 import HomePage from './pages/HomePage';
+import MergeToolPage from './pages/MergeToolPage';
 import { CommandPaletteContextKey } from './types/command-palette';
 import { useRegisterRepoNavigationCommands } from './hooks/command-palette/commands/repo-navigation-commands';
 import { UseAppState } from './hooks/state/use-app-state';
+import { useAutomationEvents } from './hooks/utils/use-automation-events';
+import { useForwardedRequests } from './hooks/utils/use-forwarded-requests';
+import { useStartupCommand } from './hooks/utils/use-startup-command';
 import { useUserScriptCommand, UserDefinedCommandDefinition } from './hooks/command-palette/use-user-script-command';
 
 // Create a client
@@ -54,6 +60,9 @@ function App() {
 	useRegisterGitCommands();
 	useRegisterNavigationCommands();
 	useRegisterRepoNavigationCommands();
+	useAutomationEvents();
+	useForwardedRequests();
+	useStartupCommand();
 
 	useKeyboardShortcut('p', () => {
 		const dialogCurrentState = commandPaletteState.dialogVisualState.get() === 'opened';
@@ -93,6 +102,17 @@ function App() {
 		});
 	}
 
+	if (appState?.startupState?.mergeToolArgs) {
+		defaultTab = '$$mergeTool$$';
+		initialTabs.push({
+			tabKey: '$$mergeTool$$',
+			titleRender: () => <>Merge</>,
+			component: <MergeToolPage />,
+			isPermanentlyOpen: true,
+			preventUserClose: true,
+		});
+	}
+
 	if (isInDirDiffMode === undefined) {
 		return <LoadingSpinner />;
 	}
@@ -107,6 +127,8 @@ function App() {
 				/>
 				<Toaster />
 				<CommandPalette />
+				<CredentialPromptDialog />
+				<JobsIndicator />
 			</div>
 		</div>
 	);
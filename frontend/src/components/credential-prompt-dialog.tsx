@@ -0,0 +1,112 @@
+import { Button } from '@/components/ui/button';
+import { Checkbox } from '@/components/ui/checkbox';
+import { Dialog, DialogContent, DialogDescription, DialogFooter, DialogHeader, DialogTitle } from '@/components/ui/dialog';
+import { Input } from '@/components/ui/input';
+import { Label } from '@/components/ui/label';
+import { KeyRound } from 'lucide-react';
+import { useEffect, useState } from 'react';
+import { AnswerCredentialPrompt, CancelCredentialPrompt } from '../../wailsjs/go/backend/App';
+import { EventsOn } from '../../wailsjs/runtime/runtime';
+
+// Mirrors command_utils.CredentialPrompt, which the backend sends when git or ssh asks for credentials
+type CredentialPrompt = {
+	id: string;
+	prompt: string;
+	kind: 'username' | 'password' | 'passphrase' | 'confirmation';
+	secret: boolean;
+};
+
+const promptTitles: Record<CredentialPrompt['kind'], string> = {
+	username: 'Username Required',
+	password: 'Password Required',
+	passphrase: 'Passphrase Required',
+	confirmation: 'Confirmation Required',
+};
+
+// Shows the credential prompts one at a time, in the order git asked for them
+export function CredentialPromptDialog() {
+	const [prompts, setPrompts] = useState<CredentialPrompt[]>([]);
+	const [answer, setAnswer] = useState('');
+	const [remember, setRemember] = useState(false);
+
+	useEffect(() => {
+		const unsubscribePrompt = EventsOn('onCredentialPrompt', (prompt: CredentialPrompt) => {
+			setPrompts((prev) => [...prev, prompt]);
+		});
+		const unsubscribeClosed = EventsOn('onCredentialPromptClosed', (promptId: string) => {
+			setPrompts((prev) => prev.filter((prompt) => prompt.id !== promptId));
+		});
+
+		return () => {
+			unsubscribePrompt();
+			unsubscribeClosed();
+		};
+	}, []);
+
+	const currentPrompt = prompts[0];
+
+	// Every prompt starts out empty
+	useEffect(() => {
+		setAnswer('');
+		setRemember(false);
+	}, [currentPrompt?.id]);
+
+	if (!currentPrompt) {
+		return null;
+	}
+
+	const onSubmit = () => {
+		AnswerCredentialPrompt(currentPrompt.id, answer, remember);
+	};
+
+	const onCancel = () => {
+		CancelCredentialPrompt(currentPrompt.id);
+	};
+
+	return (
+		<Dialog open onOpenChange={(open) => !open && onCancel()}>
+			<DialogContent className="max-w-md">
+				<DialogHeader>
+					<DialogTitle className="flex items-center gap-2">
+						<KeyRound className="h-5 w-5" />
+						{promptTitles[currentPrompt.kind]}
+					</DialogTitle>
+					<DialogDescription className="break-all">{currentPrompt.prompt.trim()}</DialogDescription>
+				</DialogHeader>
+
+				<form
+					className="space-y-4"
+					onSubmit={(event) => {
+						event.preventDefault();
+						onSubmit();
+					}}
+				>
+					<Input
+						autoFocus
+						type={currentPrompt.secret ? 'password' : 'text'}
+						value={answer}
+						placeholder={currentPrompt.kind === 'confirmation' ? 'yes' : undefined}
+						onChange={(event) => setAnswer(event.target.value)}
+					/>
+					<div className="flex items-center gap-2">
+						<Checkbox
+							id="remember-credential"
+							checked={remember}
+							onCheckedChange={(checked) => setRemember(!!checked)}
+						/>
+						<Label htmlFor="remember-credential" className="text-sm">
+							Remember until GitWhale is closed
+						</Label>
+					</div>
+
+					<DialogFooter>
+						<Button type="button" variant="outline" onClick={onCancel}>
+							Cancel
+						</Button>
+						<Button type="submit">OK</Button>
+					</DialogFooter>
+				</form>
+			</DialogContent>
+		</Dialog>
+	);
+}
@@ -0,0 +1,80 @@
+import { EmptyState } from '@/components/empty-state';
+import { FileTabs } from '@/components/file-tabs/file-tabs';
+import { FileTree } from '@/components/git-diff/file-tree';
+import LoadingSpinner from '@/components/loading-spinner';
+import { ResizableHandle, ResizablePanel, ResizablePanelGroup } from '@/components/ui/resizable';
+import { FileTabsSessionKeyGenerator } from '@/hooks/state/useFileTabsHandlers';
+import { GitCompare } from 'lucide-react';
+import { useQuery } from 'react-query';
+import { GetDirDiffDirectory } from '../../wailsjs/go/backend/App';
+import { git_operations } from '../../wailsjs/go/models';
+
+type DirDiffLayoutProps = {
+	directoryData: git_operations.Directory | undefined;
+	fileTabsSessionKey: string;
+};
+
+// The file tree of two compared folders, with the diffs of the files opened from it next to it
+export function DirDiffLayout({ directoryData, fileTabsSessionKey }: DirDiffLayoutProps) {
+	if (!directoryData) {
+		return (
+			<EmptyState
+				title={() => {
+					return (
+						<>
+							<GitCompare className="w-5 h-5" />
+							No Directory Diff Data
+						</>
+					);
+				}}
+				message="No directory diff data is available for display."
+			/>
+		);
+	}
+
+	return (
+		<div className="w-full h-full flex flex-row min-h-0">
+			<ResizablePanelGroup direction="horizontal">
+				{/* Left pane that contains the file structure */}
+				<ResizablePanel id="file-tree-panel" defaultSize={25} minSize={3}>
+					<div className="border-r h-full overflow-y-auto overflow-x-hidden">
+						<FileTree fileTabsSessionKey={fileTabsSessionKey} directoryData={directoryData} />
+					</div>
+				</ResizablePanel>
+
+				<ResizableHandle withHandle />
+
+				{/* Right pane containing the actual diffs */}
+				<ResizablePanel id="diff-content-panel">
+					<div className="grow h-full flex flex-col min-h-0">
+						<FileTabs initialTabs={[]} fileTabManageSessionKey={fileTabsSessionKey} />
+					</div>
+				</ResizablePanel>
+			</ResizablePanelGroup>
+		</div>
+	);
+}
+
+type DirDiffViewProps = {
+	leftPath: string;
+	rightPath: string;
+};
+
+// A directory diff that another GitWhale process (e.g. `git difftool --dir-diff`) forwarded to this window
+export default function DirDiffView({ leftPath, rightPath }: DirDiffViewProps) {
+	const { data: directoryData, isLoading } = useQuery({
+		queryKey: ['GetDirDiffDirectory', leftPath, rightPath],
+		queryFn: () => GetDirDiffDirectory(leftPath, rightPath),
+	});
+
+	if (isLoading) {
+		return <LoadingSpinner />;
+	}
+
+	return (
+		<DirDiffLayout
+			directoryData={directoryData}
+			fileTabsSessionKey={FileTabsSessionKeyGenerator.dirDiffViewer(leftPath, rightPath)}
+		/>
+	);
+}
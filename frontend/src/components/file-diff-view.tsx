@@ -2,11 +2,22 @@ import { FileExtensionToLanguage } from '@/lib/monaco-utils';
 import * as monaco from 'monaco-editor';
 import { useCallback, useEffect, useRef, useState } from 'react';
 import { useQuery } from 'react-query';
-import { ReadFile } from '../../wailsjs/go/backend/App';
+import { GetDiffSessionFileContent, ReadFile } from '../../wailsjs/go/backend/App';
 import { git_operations } from '../../wailsjs/go/models';
 
 export type FileDiffViewProps = {
 	file: git_operations.FileInfo;
+
+	// When set, these are shown instead of reading the files from LeftDirAbsPath/RightDirAbsPath
+	contents?: FileDiffContents;
+
+	// Lazy diff sessions don't write their files to disk, so both sides are loaded through the session instead
+	lazyDiffSessionID?: string;
+};
+
+export type FileDiffContents = {
+	original: string;
+	modified: string;
 };
 
 type MonacoDiffModels = {
@@ -15,16 +26,39 @@ type MonacoDiffModels = {
 	modifiedModel: monaco.editor.ITextModel;
 };
 
-function useMonacoDiffModel(file: git_operations.FileInfo) {
+// Binary files and files that don't exist on a side are shown the same way a materialized session shows them
+async function readLazyDiffSide(sessionID: string, path: string, side: 'left' | 'right') {
+	const fileContent = await GetDiffSessionFileContent(sessionID, path, side);
+	if (fileContent.isBinary) {
+		return `Binary file (${fileContent.size} bytes)`;
+	}
+	return fileContent.content;
+}
+
+function useMonacoDiffModel(file: git_operations.FileInfo, contents?: FileDiffContents, lazyDiffSessionID?: string) {
 	const [monacoModel, setMonacoModel] = useState<MonacoDiffModels | undefined>(undefined);
 
 	useQuery({
-		queryKey: ['GetFileContentsForDiff', file],
+		queryKey: ['GetFileContentsForDiff', file, contents, lazyDiffSessionID],
 		queryFn: async () => {
-			const [originalFilePromise, modifiedFilePromise] = await Promise.allSettled([
-				ReadFile(file.LeftDirAbsPath),
-				ReadFile(file.RightDirAbsPath),
-			]);
+			if (contents) {
+				return {
+					fileExtension: file.Extension,
+					originalFilePath: file.LeftDirAbsPath,
+					originalFile: contents.original,
+					modifiedFilePath: file.RightDirAbsPath,
+					modifiedFile: contents.modified,
+				};
+			}
+
+			const [originalFilePromise, modifiedFilePromise] = await Promise.allSettled(
+				lazyDiffSessionID
+					? [
+							readLazyDiffSide(lazyDiffSessionID, file.OldPath || file.Path, 'left'),
+							readLazyDiffSide(lazyDiffSessionID, file.Path, 'right'),
+					  ]
+					: [ReadFile(file.LeftDirAbsPath), ReadFile(file.RightDirAbsPath)]
+			);
 
 			const fileData = {
 				fileExtension: file.Extension,
@@ -78,13 +112,13 @@ function useMonacoDiffModel(file: git_operations.FileInfo) {
 }
 
 export default function FileDiffView(props: FileDiffViewProps) {
-	const { file } = props;
+	const { file, contents, lazyDiffSessionID } = props;
 
 	const editorDivRef = useRef<HTMLDivElement>(null);
 	const [editor, setEditor] = useState<monaco.editor.IStandaloneDiffEditor | undefined>(undefined);
 	const [isVisible, setIsVisible] = useState(false);
 
-	const monacoModel = useMonacoDiffModel(file);
+	const monacoModel = useMonacoDiffModel(file, contents, lazyDiffSessionID);
 
 	// Function to trigger editor layout when component becomes visible
 	const triggerLayout = useCallback(() => {
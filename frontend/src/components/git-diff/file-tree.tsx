@@ -9,10 +9,13 @@ import FileDiffView from '../file-diff-view';
 import { useFileTabsState } from '@/hooks/state/useFileTabsState';
 import Logger from '@/utils/logger';
 import { DiffFileListItem } from './diff-file-list-item';
+import { useAutomationFocusFile } from '@/hooks/utils/use-automation-events';
 
 interface FileTreeProps {
 	directoryData: git_operations.Directory;
 	fileTabsSessionKey: string;
+	diffSessionID?: string; // Lets the automation server open files of this session
+	isLazySession?: boolean; // The files are loaded through the diff session, since they aren't on disk
 	className?: string;
 }
 
@@ -53,7 +56,13 @@ function usePersistentFileTreeViewMode(): [FileTreeViewMode, (mode: FileTreeView
 	return [viewMode, setViewMode];
 }
 
-export function FileTree({ directoryData, fileTabsSessionKey, className }: FileTreeProps) {
+export function FileTree({
+	directoryData,
+	fileTabsSessionKey,
+	diffSessionID,
+	isLazySession,
+	className,
+}: FileTreeProps) {
 	const fileTabsHandlers = useFileTabsHandlers(fileTabsSessionKey);
 	const fileTabsState = useFileTabsState(fileTabsSessionKey);
 	const [viewMode, setViewMode] = usePersistentFileTreeViewMode();
@@ -69,7 +78,9 @@ export function FileTree({ directoryData, fileTabsSessionKey, className }: FileT
 			tabKey: tabKey,
 			titleRender: () => <>{file.Name}</>,
 			tooltipContent: () => <>{file.Path || file.Name}</>,
-			component: <FileDiffView file={file} />,
+			component: (
+				<FileDiffView file={file} lazyDiffSessionID={isLazySession ? diffSessionID : undefined} />
+			),
 			isPermanentlyOpen: keepFileOpen,
 		};
 
@@ -79,6 +90,15 @@ export function FileTree({ directoryData, fileTabsSessionKey, className }: FileT
 		}
 	};
 
+	useAutomationFocusFile(diffSessionID, (path) => {
+		const file = flattenedFiles.find((file) => isSameFilePath(file, path));
+		if (!file) {
+			Logger.warning(`The automation server asked to open ${path}, which isn't part of the diff`, 'FileTree');
+			return;
+		}
+		onOpenFile(file, true);
+	});
+
 	const focusRelativeItem = (direction: 1 | -1) => {
 		const focusableElements = document.querySelectorAll('[data-tree-item]');
 		if (!focusableElements.length) {
@@ -186,6 +206,13 @@ const getFileKey = (file: git_operations.FileInfo) => {
 	return `${file.Path}/${file.Name}`;
 };
 
+// Depending on how the diff was read, Path is either the file's own path or its directory's
+const isSameFilePath = (file: git_operations.FileInfo, path: string) => {
+	const normalize = (value: string) => value.replace(/\\/g, '/').replace(/^(\.\/|\/)+/, '');
+	const normalizedPath = normalize(path);
+	return normalize(file.Path) === normalizedPath || normalize(getFileKey(file)) === normalizedPath;
+};
+
 const isRootDirectory = (directory: git_operations.Directory) => {
 	return directory.Path === './' || directory.Path === '.' || directory.Name === './' || directory.Name === '.';
 };
@@ -405,9 +432,10 @@ function FileNode({ file, onKeyDown, onFileClick, depth, activeFileOpen, viewMod
 		onFileClick(file, true);
 	};
 
-	// Determine file status and styling
+	// Determine file status and styling. Lazy sessions have no files on disk, so only their status tells them apart
 	const getFileStatus = () => {
-		if (file.LeftDirAbsPath && file.RightDirAbsPath) {
+		const isModified = file.Status ? file.Status !== 'added' && file.Status !== 'deleted' : false;
+		if (isModified || (file.LeftDirAbsPath && file.RightDirAbsPath)) {
 			return {
 				color: 'text-amber-600 dark:text-amber-400',
 				hoverBgColor: 'hover:bg-amber-50 dark:hover:bg-amber-950/30',
@@ -415,7 +443,7 @@ function FileNode({ file, onKeyDown, onFileClick, depth, activeFileOpen, viewMod
 				status: 'M',
 				tooltip: 'Modified file',
 			};
-		} else if (file.LeftDirAbsPath && !file.RightDirAbsPath) {
+		} else if (file.Status === 'deleted' || (file.LeftDirAbsPath && !file.RightDirAbsPath)) {
 			return {
 				color: 'text-red-600 dark:text-red-400',
 				hoverBgColor: 'hover:bg-red-50 dark:hover:bg-red-950/30',
@@ -423,7 +451,7 @@ function FileNode({ file, onKeyDown, onFileClick, depth, activeFileOpen, viewMod
 				status: 'D',
 				tooltip: 'Deleted file',
 			};
-		} else if (!file.LeftDirAbsPath && file.RightDirAbsPath) {
+		} else if (file.Status === 'added' || (!file.LeftDirAbsPath && file.RightDirAbsPath)) {
 			return {
 				color: 'text-green-600 dark:text-green-400',
 				hoverBgColor: 'hover:bg-green-50 dark:hover:bg-green-950/30',
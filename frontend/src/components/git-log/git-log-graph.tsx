@@ -2,6 +2,7 @@ import { D3GitGraph } from '@/components/git-log/d3-git-graph';
 import { ContextMenuProvider } from '@/components/ui/context-menu-provider';
 import { useRepoLogState } from '@/hooks/state/repo/use-git-log-state';
 import { useContextMenu, type ContextMenuAction } from '@/hooks/use-context-menu';
+import { useRepoWatcherEvents } from '@/hooks/utils/use-repo-watcher-events';
 import { cn } from '@/lib/utils';
 import { CommitSelectType } from '@/pages/repo/RepoLogView';
 import { Copy, Eye, GitBranch, Loader2 } from 'lucide-react';
@@ -34,6 +35,9 @@ export function GitLogGraph({ repoPath, onCommitClick, onCommitDoubleClick, clas
 		}
 	}, []);
 
+	// Commits, fetches and checkouts made outside of GitWhale move the refs
+	useRepoWatcherEvents(repoPath, ['refsChanged', 'headMoved'], () => refreshLogAndRefs());
+
 	// Intersection Observer for infinite scrolling (based on the article)
 	const observer = useRef<IntersectionObserver>();
 	const lastCommitElementRef = useCallback(
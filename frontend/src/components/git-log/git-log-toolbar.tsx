@@ -15,6 +15,7 @@ import Logger from '@/utils/logger';
 import {
 	ChevronDown,
 	Download,
+	FileClock,
 	Filter,
 	GitCompareArrows,
 	Loader2,
@@ -64,6 +65,8 @@ export function GitLogToolbar({ repoPath }: GitLogToolbarProps) {
 
 			<SearchSection repoPath={repoPath} />
 
+			<PathFilter repoPath={repoPath} />
+
 			<Separator orientation="vertical" className="h-6" />
 
 			<FetchButton repoPath={repoPath} />
@@ -217,6 +220,39 @@ function SearchSection({ repoPath }: { repoPath: string }) {
 	);
 }
 
+// Shows which paths the log is limited to (e.g. by `gitwhale log <file>`), and lets the user clear them
+function PathFilter({ repoPath }: { repoPath: string }) {
+	const { options, refreshLogAndRefs, isLoading } = useRepoLogState(repoPath);
+	const toolbarOptions = options.get();
+
+	const paths = toolbarOptions.paths ?? [];
+	if (paths.length === 0) {
+		return null;
+	}
+
+	const onClearPathFilter = () => {
+		options.set({ ...toolbarOptions, paths: undefined });
+		refreshLogAndRefs();
+	};
+
+	return (
+		<div className="flex items-center gap-1 rounded-md border px-2 h-8 text-sm" title={paths.join('\n')}>
+			<FileClock className="w-4 h-4 text-muted-foreground" />
+			<span className="max-w-64 truncate">{paths.join(', ')}</span>
+			<Button
+				variant="ghost"
+				size="sm"
+				onClick={onClearPathFilter}
+				disabled={isLoading}
+				className="h-6 w-6 p-0 hover:bg-muted-foreground/10 text-muted-foreground hover:text-foreground"
+				aria-label="Clear path filter"
+			>
+				<span className="text-sm">×</span>
+			</Button>
+		</div>
+	);
+}
+
 // View Options Dropdown Component
 function ViewOptionsDropdown({ repoPath }: { repoPath: string }) {
 	const { options, refreshLogAndRefs, isLoading } = useRepoLogState(repoPath);
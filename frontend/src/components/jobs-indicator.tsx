@@ -0,0 +1,119 @@
+import { Button } from '@/components/ui/button';
+import { Popover, PopoverContent, PopoverTrigger } from '@/components/ui/popover';
+import { Progress } from '@/components/ui/progress';
+import { Loader2, X } from 'lucide-react';
+import { useEffect, useState } from 'react';
+import { CancelJob, ListJobs } from '../../wailsjs/go/backend/App';
+import { command_utils } from '../../wailsjs/go/models';
+import { EventsOn } from '../../wailsjs/runtime/runtime';
+
+// Mirrors command_utils.ProgressEvent, which git operations publish on their job's progress topic
+type GitProgressEvent = {
+	phase: string;
+	percent: number;
+	current: number;
+	total: number;
+	transferred?: string;
+	throughput?: string;
+	done: boolean;
+};
+
+const isActive = (job: command_utils.JobInfo) => job.state === 'queued' || job.state === 'running';
+
+// Shows the background jobs that are queued or running, with their progress and a way to cancel them
+export function JobsIndicator() {
+	const [jobs, setJobs] = useState<Record<string, command_utils.JobInfo>>({});
+	const [gitProgress, setGitProgress] = useState<Record<string, GitProgressEvent>>({});
+
+	useEffect(() => {
+		// Finished jobs are dropped, so only the queued and running ones are kept
+		const unsubscribe = EventsOn('onJobChanged', (job: command_utils.JobInfo) => {
+			setJobs((prev) => {
+				const { [job.id]: _, ...others } = prev;
+				return isActive(job) ? { ...others, [job.id]: job } : others;
+			});
+			if (!isActive(job)) {
+				setGitProgress(({ [job.id]: _, ...others }) => others);
+			}
+		});
+
+		// Jobs started before the window loaded (or while it was reloading) only show up in the list
+		ListJobs().then((existingJobs) => {
+			setJobs((prev) => {
+				const merged = { ...prev };
+				for (const job of existingJobs.filter(isActive)) {
+					merged[job.id] ??= job;
+				}
+				return merged;
+			});
+		});
+
+		return () => {
+			unsubscribe();
+		};
+	}, []);
+
+	const activeJobs = Object.values(jobs).sort((a, b) => String(a.createdAt).localeCompare(String(b.createdAt)));
+	const activeJobIdsKey = activeJobs.map((job) => job.id).join(',');
+
+	// Only the running jobs are subscribed to, since finished ones don't report progress anymore
+	useEffect(() => {
+		const unsubscribes = activeJobs.map((job) =>
+			EventsOn(`onJobProgress://${job.id}`, (event: GitProgressEvent) => {
+				setGitProgress((prev) => ({ ...prev, [job.id]: event }));
+			})
+		);
+
+		return () => {
+			unsubscribes.forEach((unsubscribe) => unsubscribe());
+		};
+	}, [activeJobIdsKey]);
+
+	if (activeJobs.length === 0) {
+		return null;
+	}
+
+	return (
+		<div className="fixed bottom-2 right-2 z-40">
+			<Popover>
+				<PopoverTrigger asChild>
+					<Button variant="outline" size="sm" className="gap-2 shadow-md">
+						<Loader2 className="h-4 w-4 animate-spin" />
+						{activeJobs.length === 1 ? activeJobs[0].title : `${activeJobs.length} jobs running`}
+					</Button>
+				</PopoverTrigger>
+				<PopoverContent align="end" className="w-96 space-y-3">
+					{activeJobs.map((job) => {
+						const progress = gitProgress[job.id];
+						const message = progress?.throughput
+							? `${job.progress.message} (${progress.throughput})`
+							: job.progress.message;
+
+						return (
+							<div key={job.id} className="space-y-1">
+								<div className="flex items-center justify-between gap-2">
+									<div className="min-w-0">
+										<div className="truncate text-sm font-medium">{job.title}</div>
+										<div className="truncate text-xs text-muted-foreground" title={job.repoPath}>
+											{job.state === 'queued' ? 'Waiting for another job in this repo' : message || 'Running...'}
+										</div>
+									</div>
+									<Button
+										variant="ghost"
+										size="icon"
+										className="h-6 w-6 shrink-0"
+										title="Cancel"
+										onClick={() => CancelJob(job.id).catch(() => {})}
+									>
+										<X className="h-4 w-4" />
+									</Button>
+								</div>
+								{job.progress.percent >= 0 && <Progress value={job.progress.percent} className="h-1" />}
+							</div>
+						);
+					})}
+				</PopoverContent>
+			</Popover>
+		</div>
+	);
+}
@@ -0,0 +1,163 @@
+import { Button } from '@/components/ui/button';
+import { useToast } from '@/hooks/use-toast';
+import { FileExtensionToLanguage } from '@/lib/monaco-utils';
+import Logger from '@/utils/logger';
+import * as monaco from 'monaco-editor';
+import { useEffect, useRef, useState } from 'react';
+import { useQuery } from 'react-query';
+import { FinishMergeTool, ReadFile } from '../../wailsjs/go/backend/App';
+import { backend } from '../../wailsjs/go/models';
+
+type MergeSide = 'local' | 'remote' | 'base';
+
+const mergeSideLabels: Record<MergeSide, string> = {
+	local: 'Local',
+	remote: 'Remote',
+	base: 'Base',
+};
+
+export type MergeToolViewProps = {
+	request: backend.MergeToolRequest;
+
+	// Called once the merge was resolved or aborted, e.g. to close its tab
+	onFinished?: (resolved: boolean) => void;
+};
+
+function getLanguage(filePath: string) {
+	const extension = filePath.split('.').pop() ?? '';
+	return FileExtensionToLanguage[extension] || extension;
+}
+
+// Resolves a conflict that git's mergetool handed to GitWhale. The merged file is edited on the right, next to the
+// side of the conflict that is picked to compare it with
+export default function MergeToolView({ request, onFinished }: MergeToolViewProps) {
+	const { args } = request;
+	const { toast } = useToast();
+
+	const editorDivRef = useRef<HTMLDivElement>(null);
+	const [editor, setEditor] = useState<monaco.editor.IStandaloneDiffEditor | undefined>(undefined);
+	const [comparedSide, setComparedSide] = useState<MergeSide>('local');
+	const [isFinishing, setIsFinishing] = useState(false);
+
+	// The files only change when git starts the next merge, which is a new request
+	const { data: files } = useQuery({
+		queryKey: ['MergeToolFiles', request.id],
+		queryFn: async () => {
+			const [local, remote, base, merged] = await Promise.all([
+				ReadFile(args.localPath),
+				ReadFile(args.remotePath),
+				ReadFile(args.basePath),
+				ReadFile(args.mergedPath),
+			]);
+			return { local, remote, base, merged } as Record<MergeSide | 'merged', string>;
+		},
+		staleTime: Infinity,
+	});
+
+	const [models, setModels] = useState<Record<MergeSide | 'merged', monaco.editor.ITextModel>>();
+
+	useEffect(() => {
+		if (!files) {
+			return;
+		}
+
+		const language = getLanguage(args.mergedPath);
+		const newModels = {
+			local: monaco.editor.createModel(files.local, language),
+			remote: monaco.editor.createModel(files.remote, language),
+			base: monaco.editor.createModel(files.base, language),
+			merged: monaco.editor.createModel(files.merged, language),
+		};
+		setModels(newModels);
+
+		return () => {
+			Object.values(newModels).forEach((model) => model.dispose());
+		};
+	}, [files]);
+
+	useEffect(() => {
+		if (!editorDivRef.current) {
+			return;
+		}
+
+		const diffEditor = monaco.editor.createDiffEditor(editorDivRef.current, {
+			renderSideBySide: true,
+			automaticLayout: true,
+			theme: 'vs-dark',
+			originalEditable: false,
+		});
+		setEditor(diffEditor);
+
+		return () => {
+			diffEditor.dispose();
+		};
+	}, []);
+
+	useEffect(() => {
+		if (!editor || !models) {
+			return;
+		}
+
+		editor.setModel({ original: models[comparedSide], modified: models.merged });
+	}, [editor, models, comparedSide]);
+
+	const takeSide = (side: MergeSide) => {
+		models?.merged.setValue(models[side].getValue());
+	};
+
+	const finish = async (resolved: boolean) => {
+		if (!models) {
+			return;
+		}
+
+		setIsFinishing(true);
+		try {
+			await FinishMergeTool(request.id, models.merged.getValue(), resolved);
+			onFinished?.(resolved);
+		} catch (error) {
+			Logger.error(`Failed to finish the merge of ${args.mergedPath}: ${error}`, 'MergeToolView');
+			toast({
+				variant: 'destructive',
+				title: 'Failed to finish the merge',
+				description: `${error}`,
+			});
+		} finally {
+			setIsFinishing(false);
+		}
+	};
+
+	return (
+		<div className="h-full w-full flex flex-col min-h-0">
+			<div className="flex flex-row items-center gap-2 border-b p-2">
+				<span className="truncate text-sm text-muted-foreground" title={args.mergedPath}>
+					{args.mergedPath}
+				</span>
+				<div className="grow" />
+				<span className="text-sm">Compare with:</span>
+				{(Object.keys(mergeSideLabels) as MergeSide[]).map((side) => (
+					<Button
+						key={side}
+						size="sm"
+						variant={comparedSide === side ? 'secondary' : 'ghost'}
+						onClick={() => setComparedSide(side)}
+					>
+						{mergeSideLabels[side]}
+					</Button>
+				))}
+				<Button size="sm" variant="outline" disabled={!models} onClick={() => takeSide('local')}>
+					Take Local
+				</Button>
+				<Button size="sm" variant="outline" disabled={!models} onClick={() => takeSide('remote')}>
+					Take Remote
+				</Button>
+				<Button size="sm" variant="outline" disabled={!models || isFinishing} onClick={() => finish(false)}>
+					Abort
+				</Button>
+				<Button size="sm" disabled={!models || isFinishing} onClick={() => finish(true)}>
+					Save and Mark Resolved
+				</Button>
+			</div>
+			<div ref={editorDivRef} className="grow min-h-0 w-full" />
+		</div>
+	);
+}
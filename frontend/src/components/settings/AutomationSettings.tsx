@@ -0,0 +1,35 @@
+import { Card, CardContent, CardHeader, CardTitle } from '@/components/ui/card';
+import { Checkbox } from '@/components/ui/checkbox';
+import { Label } from '@/components/ui/label';
+import { useSettings } from '@/hooks/app-settings/use-settings';
+import { Plug } from 'lucide-react';
+
+export function AutomationSettings() {
+	const { settings, updateSettings } = useSettings();
+
+	return (
+		<Card>
+			<CardHeader className="pb-3">
+				<CardTitle className="flex items-center gap-2 text-lg">
+					<Plug className="w-4 h-4" />
+					Automation
+				</CardTitle>
+			</CardHeader>
+			<CardContent className="space-y-3">
+				<div className="flex items-center justify-between">
+					<div className="space-y-0.5">
+						<Label className="text-sm font-medium">Enable Automation Server</Label>
+						<p className="text-xs text-muted-foreground">
+							Lets editor plugins and scripts open repos and diffs through a local socket. The socket
+							path and token are written to Automation.json in the GitWhale folder
+						</p>
+					</div>
+					<Checkbox
+						checked={settings.automation?.enabled ?? false}
+						onCheckedChange={(checked) => updateSettings({ automation: { enabled: !!checked } })}
+					/>
+				</div>
+			</CardContent>
+		</Card>
+	);
+}
@@ -2,8 +2,35 @@ import { Card, CardContent, CardHeader, CardTitle } from '@/components/ui/card';
 import { Checkbox } from '@/components/ui/checkbox';
 import { Input } from '@/components/ui/input';
 import { Label } from '@/components/ui/label';
+import {
+	Select,
+	SelectContent,
+	SelectGroup,
+	SelectItem,
+	SelectTrigger,
+	SelectValue,
+} from '@/components/ui/select';
 import { useSettings } from '@/hooks/app-settings/use-settings';
 import { GitBranch } from 'lucide-react';
+import { git_operations } from '../../../wailsjs/go/models';
+
+// Select items can't have an empty value, so git's defaults (stored as "") are shown under this one
+const GIT_DEFAULT_VALUE = 'default';
+
+const WHITESPACE_MODES = [
+	{ value: GIT_DEFAULT_VALUE, label: 'Show all whitespace changes' },
+	{ value: 'eol', label: 'Ignore whitespace at end of line' },
+	{ value: 'change', label: 'Ignore changes in amount of whitespace' },
+	{ value: 'all', label: 'Ignore all whitespace' },
+] as const;
+
+const DIFF_ALGORITHMS = [
+	{ value: GIT_DEFAULT_VALUE, label: "Git's default" },
+	{ value: 'myers', label: 'Myers' },
+	{ value: 'minimal', label: 'Minimal' },
+	{ value: 'patience', label: 'Patience' },
+	{ value: 'histogram', label: 'Histogram' },
+] as const;
 
 export function GitSettings() {
 	const { settings, updateSettings } = useSettings();
@@ -17,6 +44,23 @@ export function GitSettings() {
 		});
 	};
 
+	const handleDiffSettingsChange = <K extends keyof git_operations.DiffSettings>(
+		key: K,
+		value: git_operations.DiffSettings[K]
+	) => {
+		handleGitSettingsChange(
+			'diffSettings',
+			new git_operations.DiffSettings({
+				...settings.git.diffSettings,
+				[key]: value,
+			})
+		);
+	};
+
+	const parseThreshold = (value: string) => Math.min(100, Math.max(0, parseInt(value) || 0));
+
+	const diffSettings = settings.git.diffSettings ?? new git_operations.DiffSettings();
+
 	const handleUISettingsChange = <T extends typeof settings.ui, K extends keyof T>(key: K, value: T[K]) => {
 		updateSettings({
 			ui: {
@@ -89,6 +133,134 @@ export function GitSettings() {
 						className="mt-1"
 					/>
 				</div>
+				<div>
+					<Label htmlFor="git-backend" className="text-sm font-medium">
+						Git Backend
+					</Label>
+					<p className="text-xs text-muted-foreground">
+						How the log, refs and file contents are read. go-git reads them in-process, which avoids
+						starting a git process for every read
+					</p>
+					<Select
+						value={settings.git.backend || 'cli'}
+						onValueChange={(value) => handleGitSettingsChange('backend', value)}
+					>
+						<SelectTrigger id="git-backend" className="mt-1">
+							<SelectValue placeholder="Git CLI" />
+						</SelectTrigger>
+						<SelectContent>
+							<SelectGroup>
+								<SelectItem value="cli">Git CLI</SelectItem>
+								<SelectItem value="goGit">go-git (in-process)</SelectItem>
+							</SelectGroup>
+						</SelectContent>
+					</Select>
+				</div>
+				<div>
+					<Label htmlFor="diff-whitespace" className="text-sm font-medium">
+						Diff Whitespace
+					</Label>
+					<Select
+						value={diffSettings.ignoreWhitespace || GIT_DEFAULT_VALUE}
+						onValueChange={(value) =>
+							handleDiffSettingsChange(
+								'ignoreWhitespace',
+								value === GIT_DEFAULT_VALUE ? '' : value
+							)
+						}
+					>
+						<SelectTrigger id="diff-whitespace" className="mt-1">
+							<SelectValue placeholder="Show all whitespace changes" />
+						</SelectTrigger>
+						<SelectContent>
+							<SelectGroup>
+								{WHITESPACE_MODES.map((mode) => (
+									<SelectItem key={mode.value} value={mode.value}>
+										{mode.label}
+									</SelectItem>
+								))}
+							</SelectGroup>
+						</SelectContent>
+					</Select>
+				</div>
+				<div className="flex items-center justify-between">
+					<div className="space-y-0.5">
+						<Label className="text-sm font-medium">Ignore Blank Lines</Label>
+						<p className="text-xs text-muted-foreground">
+							Leave out changes that only add or remove blank lines
+						</p>
+					</div>
+					<Checkbox
+						checked={diffSettings.ignoreBlankLines}
+						onCheckedChange={(checked) => handleDiffSettingsChange('ignoreBlankLines', !!checked)}
+					/>
+				</div>
+				<div>
+					<Label htmlFor="diff-algorithm" className="text-sm font-medium">
+						Diff Algorithm
+					</Label>
+					<Select
+						value={diffSettings.algorithm || GIT_DEFAULT_VALUE}
+						onValueChange={(value) =>
+							handleDiffSettingsChange('algorithm', value === GIT_DEFAULT_VALUE ? '' : value)
+						}
+					>
+						<SelectTrigger id="diff-algorithm" className="mt-1">
+							<SelectValue placeholder="Git's default" />
+						</SelectTrigger>
+						<SelectContent>
+							<SelectGroup>
+								{DIFF_ALGORITHMS.map((algorithm) => (
+									<SelectItem key={algorithm.value} value={algorithm.value}>
+										{algorithm.label}
+									</SelectItem>
+								))}
+							</SelectGroup>
+						</SelectContent>
+					</Select>
+				</div>
+				<div>
+					<Label htmlFor="diff-rename-threshold" className="text-sm font-medium">
+						Rename Similarity (%)
+					</Label>
+					<p className="text-xs text-muted-foreground">
+						How similar a deleted and an added file must be to show as a rename. Empty uses git's
+						default of 50%
+					</p>
+					<Input
+						id="diff-rename-threshold"
+						type="number"
+						min={0}
+						max={100}
+						placeholder="50"
+						value={diffSettings.renameThreshold === 0 ? '' : diffSettings.renameThreshold}
+						onChange={(e) =>
+							handleDiffSettingsChange('renameThreshold', parseThreshold(e.target.value))
+						}
+						className="mt-1"
+					/>
+				</div>
+				<div>
+					<Label htmlFor="diff-copy-threshold" className="text-sm font-medium">
+						Copy Similarity (%)
+					</Label>
+					<p className="text-xs text-muted-foreground">
+						How similar a file must be to an existing one to show as a copy. Empty turns copy
+						detection off
+					</p>
+					<Input
+						id="diff-copy-threshold"
+						type="number"
+						min={0}
+						max={100}
+						placeholder="Off"
+						value={diffSettings.copyThreshold === 0 ? '' : diffSettings.copyThreshold}
+						onChange={(e) =>
+							handleDiffSettingsChange('copyThreshold', parseThreshold(e.target.value))
+						}
+						className="mt-1"
+					/>
+				</div>
 				<div className="flex items-center justify-between">
 					<div className="space-y-0.5">
 						<Label className="text-sm font-medium">Auto-show Commit Details</Label>
@@ -103,6 +275,20 @@ export function GitSettings() {
 						}
 					/>
 				</div>
+				<div className="flex items-center justify-between">
+					<div className="space-y-0.5">
+						<Label className="text-sm font-medium">Hide Unchanged Files in Directory Diffs</Label>
+						<p className="text-xs text-muted-foreground">
+							Leave out files that are identical on both sides when diffing two directories
+						</p>
+					</div>
+					<Checkbox
+						checked={settings.ui.hideUnchangedDirDiffFiles}
+						onCheckedChange={(checked) =>
+							handleUISettingsChange('hideUnchangedDirDiffFiles', !!checked)
+						}
+					/>
+				</div>
 			</CardContent>
 		</Card>
 	);
@@ -1,6 +1,7 @@
 export { GitSettings } from './GitSettings';
 export { TerminalSettings } from './TerminalSettings';
 export { AppearanceSettings } from './AppearanceSettings';
+export { AutomationSettings } from './AutomationSettings';
 export { ApplicationInfo } from './ApplicationInfo';
 export { UserScriptSettings } from './user-scripts/UserScriptSettings';
 export { SettingsHeader } from './SettingsHeader';
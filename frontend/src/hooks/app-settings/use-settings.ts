@@ -26,6 +26,9 @@ export function useSettings() {
 				? new command_utils.TerminalSettings({ ...settings.terminal, ...newSettings.terminal })
 				: settings.terminal,
 			ui: newSettings.ui ? new backend.UISettings({ ...settings.ui, ...newSettings.ui }) : settings.ui,
+			automation: newSettings.automation
+				? new backend.AutomationSettings({ ...settings.automation, ...newSettings.automation })
+				: settings.automation,
 			userScriptCommands: newSettings.userScriptCommands
 				? new backend.UserDefinedCommandDefinition({
 						...settings.userScriptCommands,
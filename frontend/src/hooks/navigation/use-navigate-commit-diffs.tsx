@@ -13,7 +13,7 @@ import { useRepoDiffState } from '../state/repo/use-git-diff-state';
 
 export function useNavigateToCommitDiffs(repoPath: string) {
 	const sidebar = useSidebarHandlers(SidebarSessionKeyGenerator.repoSidebar(repoPath));
-	const { createSession } = useRepoDiffState(repoPath);
+	const { createSession, addSession, closeSession } = useRepoDiffState(repoPath);
 	const [isLoadingNewDiff, setIsLoadingNewDiff] = useState(false);
 
 	const navigateToCommitDiff = async (firstCommitHash: string, secondCommitHash: string | undefined) => {
@@ -22,19 +22,30 @@ export function useNavigateToCommitDiffs(repoPath: string) {
 			fromRef: firstCommitHash,
 			toRef: secondCommitHash ?? '',
 			isSingleCommitDiff: secondCommitHash === '' || secondCommitHash === undefined,
+
+			// Only the list of changed files is read up front, the files are loaded as they're opened
+			lazyLoad: true,
 		});
 	};
 
-	const navigateToCommitDiffWithOptions = async (options: git_operations.DiffOptions) => {
-		setIsLoadingNewDiff(true);
-		const pageKey = `commit-${options.fromRef}-${options.toRef}`;
+	const getPageKey = (options: git_operations.DiffOptions) => `commit-${options.fromRef}-${options.toRef}`;
 
-		// Check if this commit is already open in the sidebar
-		// If it already exists, just switch to it
+	// Check if this commit is already open in the sidebar
+	// If it already exists, just switch to it
+	const switchToOpenDiff = (options: git_operations.DiffOptions) => {
+		const pageKey = getPageKey(options);
 		const existingItems = sidebar.dynamicItems ?? [];
 		const existingCommit = existingItems.find((item) => item.id === pageKey);
 		if (existingCommit) {
 			sidebar.setActiveItem(pageKey);
+			return true;
+		}
+		return false;
+	};
+
+	const navigateToCommitDiffWithOptions = async (options: git_operations.DiffOptions) => {
+		setIsLoadingNewDiff(true);
+		if (switchToOpenDiff(options)) {
 			setIsLoadingNewDiff(false);
 			return;
 		}
@@ -52,6 +63,18 @@ export function useNavigateToCommitDiffs(repoPath: string) {
 			return;
 		}
 
+		openDiffSessionInSidebar(options, diffSessionID, getPageKey(options));
+		setIsLoadingNewDiff(false);
+	};
+
+	// Shows a session that was started elsewhere, e.g. by the automation server. It gets its own page even if the
+	// same refs are already open, since the caller refers to it by its session ID
+	const navigateToExistingDiffSession = (options: git_operations.DiffOptions, session: git_operations.DiffSession) => {
+		addSession(session);
+		openDiffSessionInSidebar(options, session.sessionId, `diff-session-${session.sessionId}`);
+	};
+
+	const openDiffSessionInSidebar = (options: git_operations.DiffOptions, diffSessionID: string, pageKey: string) => {
 		const firstCommitHashShort = convertToShortHash(options.fromRef);
 		const secondCommitHashShort = convertToShortHash(options.toRef);
 		const pageTitle = options.isSingleCommitDiff || !secondCommitHashShort
@@ -70,13 +93,15 @@ export function useNavigateToCommitDiffs(repoPath: string) {
 					`Closing RepoCommitDiffView for session ${diffSessionID}`,
 					'useNavigateToCommitDiffs'
 				);
+
+				// Otherwise the backend would bring the tab back after a restart
+				closeSession(diffSessionID).catch(() => {});
 			},
 		};
 
 		// Add the item to the sidebar and set it as active
 		sidebar.addDynamicItem(commitItem);
-		setIsLoadingNewDiff(false);
 	};
 
-	return { navigateToCommitDiffWithOptions, navigateToCommitDiff, isLoadingNewDiff };
+	return { navigateToCommitDiffWithOptions, navigateToCommitDiff, navigateToExistingDiffSession, isLoadingNewDiff };
 }
@@ -0,0 +1,39 @@
+import {
+	SidebarItemProps,
+	SidebarSessionKeyGenerator,
+	useSidebarHandlers,
+} from '@/hooks/state/useSidebarHandlers';
+import RepoBlameView from '@/pages/repo/RepoBlameView';
+import { FileClock } from 'lucide-react';
+import { Logger } from '../../utils/logger';
+
+// Opens the blame of a file (relative to the repo's root) in the repo's sidebar
+export function useNavigateToBlame(repoPath: string) {
+	const sidebar = useSidebarHandlers(SidebarSessionKeyGenerator.repoSidebar(repoPath));
+
+	const navigateToBlame = (filePath: string) => {
+		const pageKey = `blame-${filePath}`;
+
+		// If it's already open, just switch to it
+		const existingItems = sidebar.dynamicItems ?? [];
+		if (existingItems.some((item) => item.id === pageKey)) {
+			sidebar.setActiveItem(pageKey);
+			return;
+		}
+
+		const blameItem: SidebarItemProps = {
+			id: pageKey,
+			title: filePath.split('/').pop() || filePath,
+			icon: <FileClock className="h-4 w-4" />,
+			component: <RepoBlameView repoPath={repoPath} filePath={filePath} />,
+			isDynamic: true,
+			onClose: () => {
+				Logger.debug(`Closing the blame of ${filePath}`, 'use-navigate-to-blame');
+			},
+		};
+
+		sidebar.addDynamicItem(blameItem);
+	};
+
+	return navigateToBlame;
+}
@@ -1,7 +1,7 @@
 import { useToast } from '@/hooks/use-toast';
 import Logger from '@/utils/logger';
 import { atom } from 'jotai';
-import { EndDiffSession, StartDiffSession } from '../../../../wailsjs/go/backend/App';
+import { EndDiffSession, GetDiffSession, StartDiffSession } from '../../../../wailsjs/go/backend/App';
 import { git_operations } from '../../../../wailsjs/go/models';
 import { useMapPrimitive } from '../primitives/use-map-primitive';
 import { FileTabsSessionKeyGenerator } from '../useFileTabsHandlers';
@@ -63,18 +63,44 @@ export function useRepoDiffState(repoPath: string) {
 		[_isLoadingPrim.set, toast, _diffSessionsPrim.set]
 	);
 
+	// Tracks a session that was started elsewhere, e.g. by the automation server
+	const addSession = useCallback(
+		(session: git_operations.DiffSession) => {
+			_diffSessionsPrim.set((prev) => [...(prev ?? []), session]);
+		},
+		[_diffSessionsPrim.set]
+	);
+
+	// Sessions restored after a restart only have their manifest, so their files are written again on first use
+	const restoreSession = useCallback(
+		async (sessionId: string) => {
+			const session = await GetDiffSession(sessionId);
+			if (!session) {
+				toast({
+					variant: 'destructive',
+					title: 'Failed to restore diff session',
+					description: 'The diff might no longer exist, check the logs for details',
+				});
+				return undefined;
+			}
+
+			_diffSessionsPrim.set((prev) => (prev ?? []).map((s) => (s.sessionId === sessionId ? session : s)));
+			return session;
+		},
+		[_diffSessionsPrim.set, toast]
+	);
+
 	const closeSession = useCallback(
 		async (sessionId: string) => {
 			try {
 				await EndDiffSession(sessionId);
-				const newSessions = _diffSessionsPrim.value?.filter((s) => s.sessionId !== sessionId) ?? [];
-				_diffSessionsPrim.set(newSessions);
+				_diffSessionsPrim.set((prev) => (prev ?? []).filter((s) => s.sessionId !== sessionId));
 			} catch (error) {
 				Logger.error(`Failed to close diff session: ${error}`, 'RepoDiffView');
 				throw error;
 			}
 		},
-		[_diffSessionsPrim.value, _diffSessionsPrim.set]
+		[_diffSessionsPrim.set]
 	);
 
 	return useMemo(() => {
@@ -82,6 +108,8 @@ export function useRepoDiffState(repoPath: string) {
 			isLoading: _isLoadingPrim.value || false,
 
 			createSession,
+			addSession,
+			restoreSession,
 			closeSession,
 
 			// Get current sessions for this repo
@@ -97,6 +125,8 @@ export function useRepoDiffState(repoPath: string) {
 	}, [
 		_isLoadingPrim.value,
 		createSession,
+		addSession,
+		restoreSession,
 		closeSession,
 		_diffSessionsPrim.value,
 		_diffSessionsPrim.kill,
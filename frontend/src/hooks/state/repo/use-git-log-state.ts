@@ -45,7 +45,8 @@ export function useRepoLogState(repoPath: string) {
 	const _isLoadingMorePrim = useMapPrimitive(isLoadingMoreCommitsAtom, repoPath);
 	const _hasMoreCommitsPrim = useMapPrimitive(hasMoreCommitsAtom, repoPath);
 
-	const [needsToReload, setNeedsToReload] = useState(false);
+	// Options are passed along with the request, since the ones in the atom only update on the next render
+	const [reloadRequest, setReloadRequest] = useState<{ options?: git_operations.GitLogOptions }>();
 
 	const currentSelectedCommits = _selectedCommitsPrim.value ?? [];
 
@@ -180,23 +181,26 @@ export function useRepoLogState(repoPath: string) {
 		_gitRefsPrim.set(newRefs);
 	}, [_gitRefsPrim.set]);
 
-	const refreshLogAndRefs = useCallback(async () => {
-		if (_isLoadingPrim.value) {
-			return;
-		}
+	const refreshLogAndRefs = useCallback(
+		async (logOptions: git_operations.GitLogOptions = currentLogOptions) => {
+			if (_isLoadingPrim.value) {
+				return;
+			}
 
-		try {
-			_isLoadingPrim.set(true);
-			_hasMoreCommitsPrim.set(true);
+			try {
+				_isLoadingPrim.set(true);
+				_hasMoreCommitsPrim.set(true);
 
-			await Promise.all([loadAllRefsInner(), refreshLogsInner(currentLogOptions, false)]);
-		} catch (error) {
-			Logger.error(`Failed to reload refs: ${error}`, 'RepoLogView');
-		} finally {
-			_isLoadingPrim.set(false);
-			setNeedsToReload(false);
-		}
-	}, [_isLoadingPrim.value, _isLoadingPrim.set, _hasMoreCommitsPrim.set, setNeedsToReload]);
+				await Promise.all([loadAllRefsInner(), refreshLogsInner(logOptions, false)]);
+			} catch (error) {
+				Logger.error(`Failed to reload refs: ${error}`, 'RepoLogView');
+			} finally {
+				_isLoadingPrim.set(false);
+				setReloadRequest(undefined);
+			}
+		},
+		[_isLoadingPrim.value, _isLoadingPrim.set, _hasMoreCommitsPrim.set, setReloadRequest, currentLogOptions]
+	);
 
 	const loadMoreCommits = useCallback(async () => {
 		// Prevent multiple simultaneous requests
@@ -249,11 +253,12 @@ export function useRepoLogState(repoPath: string) {
 		}
 	}, [_isLoadingPrim.set, loadAllRefsInner, refreshLogsInner, currentLogOptions]);
 
+	// A request made while the log is loading waits for it, so it isn't dropped
 	useEffect(() => {
-		if (needsToReload) {
-			refreshLogAndRefs();
+		if (reloadRequest && !_isLoadingPrim.value) {
+			refreshLogAndRefs(reloadRequest.options);
 		}
-	}, [needsToReload]);
+	}, [reloadRequest, _isLoadingPrim.value]);
 
 	return useMemo(() => {
 		return {
@@ -267,8 +272,12 @@ export function useRepoLogState(repoPath: string) {
 			// All the refs that git is tracking for this repo
 			refs: _gitRefsPrim.value,
 
-			refreshLogAndRefs: () => {
-				setNeedsToReload(true);
+			// Pass the new options when they were just changed, so the log isn't loaded with the previous ones
+			refreshLogAndRefs: (newOptions?: git_operations.GitLogOptions) => {
+				if (newOptions) {
+					_gitLogOptionsPrim.set(newOptions);
+				}
+				setReloadRequest({ options: newOptions });
 			},
 
 			refreshRefs: loadAllRefsInner,
@@ -312,7 +321,7 @@ export function useRepoLogState(repoPath: string) {
 			},
 		};
 	}, [
-		setNeedsToReload,
+		setReloadRequest,
 		loadAllRefsInner,
 		loadMoreCommits,
 		refetchRepo,
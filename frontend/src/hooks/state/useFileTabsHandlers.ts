@@ -10,6 +10,9 @@ export const FileTabsSessionKeyGenerator = {
 	startupDiffViewer: () => {
 		return 'startup-diff-viewer';
 	},
+	dirDiffViewer: (leftPath: string, rightPath: string) => {
+		return `dir-diff-viewer-${leftPath}-${rightPath}`;
+	},
 	diffSession: (diffSessionID: string) => {
 		return `diff-session-${diffSessionID}`;
 	},
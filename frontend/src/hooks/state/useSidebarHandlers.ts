@@ -22,14 +22,13 @@ export function useSidebarHandlers(
 	const state = useSidebarState(sessionKey, initialValues);
 
 	const addDynamicItem = (item: SidebarItemProps): void => {
-		// Check if item already exists
-		const existingItems = state.dynamicItems;
-		const itemExists = existingItems?.some((existing) => existing.id === item.id);
-
-		if (!itemExists) {
-			const newItems = [...(existingItems ?? []), { ...item, isDynamic: true }];
-			state.setDynamicItems(newItems);
-		}
+		// Based on the latest items, so several items can be added in a row
+		state.setDynamicItems((existingItems) => {
+			if (existingItems?.some((existing) => existing.id === item.id)) {
+				return existingItems;
+			}
+			return [...(existingItems ?? []), { ...item, isDynamic: true }];
+		});
 
 		// Set as active item
 		state.setActiveItemId(item.id);
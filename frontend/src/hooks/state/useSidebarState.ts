@@ -1,6 +1,6 @@
 import { atom } from 'jotai';
 import { ReactNode, useEffect } from 'react';
-import { useMapPrimitive } from './primitives/use-map-primitive';
+import { SetIntent, useMapPrimitive } from './primitives/use-map-primitive';
 
 type SidebarSessionKey = string;
 type SidebarItemId = string;
@@ -49,7 +49,7 @@ export function useSidebarState(
 		}
 	};
 
-	const setDynamicItems = (items: SidebarItemProps[]) => {
+	const setDynamicItems = (items: SetIntent<SidebarItemProps[]>) => {
 		_dynamicItemsPrim.set(items);
 	};
 
@@ -0,0 +1,86 @@
+import { useNavigateRootFilTabs } from '@/hooks/navigation/use-navigate-root-file-tabs';
+import { useNavigateToCommitDiffs } from '@/hooks/navigation/use-navigate-commit-diffs';
+import { atom, useAtom, useSetAtom } from 'jotai';
+import { useEffect, useRef } from 'react';
+import { EventsOn } from '../../../wailsjs/runtime/runtime';
+import { git_operations } from '../../../wailsjs/go/models';
+
+// Mirrors backend.AutomationOpenDiffEvent, which the automation server sends after starting a diff session
+export type AutomationOpenDiffEvent = {
+	repoPath: string;
+	options: git_operations.DiffOptions;
+	session: git_operations.DiffSession;
+};
+
+// Mirrors backend.AutomationFocusFileEvent
+export type AutomationFocusFileEvent = {
+	sessionId: string;
+	path: string;
+};
+
+// Diff sessions waiting for their repo's page, which might not be mounted yet when the event arrives
+const pendingAutomationDiffsAtom = atom<AutomationOpenDiffEvent[]>([]);
+
+// Opens the repos and diffs requested through the automation server. Mounted once, at the root of the app
+export function useAutomationEvents() {
+	const rootNavigation = useNavigateRootFilTabs();
+	const rootNavigationRef = useRef(rootNavigation);
+	rootNavigationRef.current = rootNavigation;
+
+	const setPendingDiffs = useSetAtom(pendingAutomationDiffsAtom);
+
+	useEffect(() => {
+		const unsubscribeOpenRepo = EventsOn('onAutomationOpenRepo', (repoPath: string) => {
+			rootNavigationRef.current.onOpenRepoWithPath(repoPath);
+		});
+
+		const unsubscribeOpenDiff = EventsOn('onAutomationOpenDiff', (event: AutomationOpenDiffEvent) => {
+			setPendingDiffs((prev) => [...prev, event]);
+			rootNavigationRef.current.onOpenRepoWithPath(event.repoPath);
+		});
+
+		return () => {
+			unsubscribeOpenRepo();
+			unsubscribeOpenDiff();
+		};
+	}, []);
+}
+
+// Shows the diff sessions the automation server opened for this repo. Mounted by the repo's page
+export function useAutomationDiffsForRepo(repoPath: string) {
+	const [pendingDiffs, setPendingDiffs] = useAtom(pendingAutomationDiffsAtom);
+	const { navigateToExistingDiffSession } = useNavigateToCommitDiffs(repoPath);
+
+	const diffsForRepo = pendingDiffs.filter((event) => event.repoPath === repoPath);
+
+	useEffect(() => {
+		if (diffsForRepo.length === 0) {
+			return;
+		}
+
+		setPendingDiffs((prev) => prev.filter((event) => event.repoPath !== repoPath));
+		diffsForRepo.forEach((event) => navigateToExistingDiffSession(event.options, event.session));
+	}, [diffsForRepo.length]);
+}
+
+// Runs the callback when the automation server asks to open a file of the given diff session
+export function useAutomationFocusFile(diffSessionID: string | undefined, callback: (path: string) => void) {
+	const callbackRef = useRef(callback);
+	callbackRef.current = callback;
+
+	useEffect(() => {
+		if (!diffSessionID) {
+			return;
+		}
+
+		const unsubscribe = EventsOn('onAutomationFocusFile', (event: AutomationFocusFileEvent) => {
+			if (event.sessionId === diffSessionID) {
+				callbackRef.current(event.path);
+			}
+		});
+
+		return () => {
+			unsubscribe();
+		};
+	}, [diffSessionID]);
+}
@@ -0,0 +1,85 @@
+import DirDiffView from '@/components/dir-diff-view';
+import FileDiffView from '@/components/file-diff-view';
+import MergeToolView from '@/components/merge-tool-view';
+import { FileTabsSessionKeyGenerator, useFileTabsHandlers } from '@/hooks/state/useFileTabsHandlers';
+import { useEffect, useRef } from 'react';
+import { CloseForwardedDirDiff, FinishMergeTool } from '../../../wailsjs/go/backend/App';
+import { backend, git_operations } from '../../../wailsjs/go/models';
+import { EventsOn } from '../../../wailsjs/runtime/runtime';
+
+// Mirrors backend.ForwardedDirDiff
+type ForwardedDirDiff = {
+	id: string;
+	leftFolderPath: string;
+	rightFolderPath: string;
+};
+
+const getFileName = (filePath: string) => filePath.split(/[\\/]/).pop() ?? filePath;
+
+// Opens the diffs and merges that other GitWhale processes (e.g. started by `git difftool` or `git mergetool`)
+// forwarded to this window
+export function useForwardedRequests() {
+	const fileTabs = useFileTabsHandlers(FileTabsSessionKeyGenerator.appWorkspace());
+
+	// Keep the latest handlers, so re-renders don't re-subscribe
+	const openTabRef = useRef(fileTabs.openTab);
+	openTabRef.current = fileTabs.openTab;
+	const closeTabRef = useRef(fileTabs.closeTab);
+	closeTabRef.current = fileTabs.closeTab;
+
+	useEffect(() => {
+		const unsubscribeFileDiffs = EventsOn('onOpenNewFileDiff', (file: git_operations.FileInfo) => {
+			openTabRef.current({
+				tabKey: `$$file-diff-${file.LeftDirAbsPath}-${file.RightDirAbsPath}$$`,
+				titleRender: () => <>{file.Name}</>,
+				tooltipContent: () => (
+					<span>
+						{file.LeftDirAbsPath} ↔ {file.RightDirAbsPath}
+					</span>
+				),
+				component: <FileDiffView file={file} />,
+				isPermanentlyOpen: true,
+			});
+		});
+
+		// git deletes the folders once the process that forwarded them exits, which waits until the tab is closed
+		const unsubscribeDirDiffs = EventsOn('onOpenDirDiff', (dirDiff: ForwardedDirDiff) => {
+			openTabRef.current({
+				tabKey: `$$dir-diff-${dirDiff.id}$$`,
+				titleRender: () => <>Dir Diff</>,
+				tooltipContent: () => (
+					<span>
+						{dirDiff.leftFolderPath} ↔ {dirDiff.rightFolderPath}
+					</span>
+				),
+				component: <DirDiffView leftPath={dirDiff.leftFolderPath} rightPath={dirDiff.rightFolderPath} />,
+				isPermanentlyOpen: true,
+				onTabClose: () => {
+					CloseForwardedDirDiff(dirDiff.id);
+				},
+			});
+		});
+
+		// Closing the tab without finishing the merge aborts it, so git's mergetool doesn't wait forever
+		const unsubscribeMerges = EventsOn('onOpenMergeTool', (request: backend.MergeToolRequest) => {
+			const tabKey = `$$merge-tool-${request.id}$$`;
+			openTabRef.current({
+				tabKey,
+				titleRender: () => <>Merge {getFileName(request.args.mergedPath)}</>,
+				tooltipContent: () => <span>{request.args.mergedPath}</span>,
+				component: <MergeToolView request={request} onFinished={() => closeTabRef.current(tabKey)} />,
+				isPermanentlyOpen: true,
+				onTabClose: () => {
+					// Fails when the merge was already finished, which is what closed the tab
+					FinishMergeTool(request.id, '', false).catch(() => {});
+				},
+			});
+		});
+
+		return () => {
+			unsubscribeFileDiffs();
+			unsubscribeDirDiffs();
+			unsubscribeMerges();
+		};
+	}, []);
+}
@@ -0,0 +1,36 @@
+import { useEffect, useRef } from 'react';
+import { EventsOn } from '../../../wailsjs/runtime/runtime';
+
+export type RepoWatchEventType = 'statusChanged' | 'headMoved' | 'refsChanged';
+
+// Mirrors git_operations.RepoWatchEvent, which the backend pushes when the repo changes outside of GitWhale
+export type RepoWatchEvent = {
+	repoPath: string;
+	type: RepoWatchEventType;
+	paths: string[];
+};
+
+// Runs the callback whenever the backend's file system watcher reports one of the given changes for the repo
+export function useRepoWatcherEvents(
+	repoPath: string,
+	eventTypes: RepoWatchEventType[],
+	callback: (event: RepoWatchEvent) => void
+) {
+	// Keep the latest callback, so re-renders don't re-subscribe
+	const callbackRef = useRef(callback);
+	callbackRef.current = callback;
+
+	const eventTypesKey = eventTypes.join(',');
+
+	useEffect(() => {
+		const unsubscribe = EventsOn(`onRepoChanged://${repoPath}`, (event: RepoWatchEvent) => {
+			if (eventTypes.includes(event.type)) {
+				callbackRef.current(event);
+			}
+		});
+
+		return () => {
+			unsubscribe();
+		};
+	}, [repoPath, eventTypesKey]);
+}
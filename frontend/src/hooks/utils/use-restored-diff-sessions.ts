@@ -0,0 +1,38 @@
+import { useNavigateToCommitDiffs } from '@/hooks/navigation/use-navigate-commit-diffs';
+import { useRepoDiffState } from '@/hooks/state/repo/use-git-diff-state';
+import { SidebarSessionKeyGenerator, useSidebarHandlers } from '@/hooks/state/useSidebarHandlers';
+import Logger from '@/utils/logger';
+import { useEffect, useRef } from 'react';
+import { ListDiffSessions } from '../../../wailsjs/go/backend/App';
+
+// Reopens the diff tabs this repo had open, including the ones saved before GitWhale was restarted.
+// Mounted by the repo's page
+export function useRestoredDiffSessions(repoPath: string) {
+	const { navigateToExistingDiffSession } = useNavigateToCommitDiffs(repoPath);
+	const { sessionsData } = useRepoDiffState(repoPath);
+	const sidebar = useSidebarHandlers(SidebarSessionKeyGenerator.repoSidebar(repoPath));
+	const hasRestoredSessions = useRef(false);
+
+	// Waits for the sidebar to pick its default item, so it doesn't replace the restored tab that's shown
+	const isSidebarReady = !!sidebar.staticItems;
+
+	useEffect(() => {
+		if (hasRestoredSessions.current || !isSidebarReady) {
+			return;
+		}
+		hasRestoredSessions.current = true;
+
+		const openSessionIDs = new Set(sessionsData.map((session) => session.sessionId));
+		ListDiffSessions()
+			.then((sessions) => {
+				// Most recently used first, so the last one opened (and shown) is the one used last
+				sessions
+					.filter((session) => session.repoPath === repoPath && !openSessionIDs.has(session.sessionId))
+					.reverse()
+					.forEach((session) => navigateToExistingDiffSession(session.options, session));
+			})
+			.catch((error) => {
+				Logger.error(`Failed to list the saved diff sessions: ${error}`, 'useRestoredDiffSessions');
+			});
+	}, [isSidebarReady]);
+}
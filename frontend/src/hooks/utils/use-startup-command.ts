@@ -0,0 +1,107 @@
+import { useNavigateRootFilTabs } from '@/hooks/navigation/use-navigate-root-file-tabs';
+import { useNavigateToBlame } from '@/hooks/navigation/use-navigate-to-blame';
+import { useNavigateToCommitDiffs } from '@/hooks/navigation/use-navigate-commit-diffs';
+import { useRepoLogState } from '@/hooks/state/repo/use-git-log-state';
+import { SidebarSessionKeyGenerator, useSidebarHandlers } from '@/hooks/state/useSidebarHandlers';
+import { atom, useAtom, useSetAtom } from 'jotai';
+import { useEffect, useRef } from 'react';
+import { NormalizeFolderPath } from '../../../wailsjs/go/backend/App';
+import { backend, git_operations } from '../../../wailsjs/go/models';
+import { EventsOn } from '../../../wailsjs/runtime/runtime';
+import { UseAppState } from '../state/use-app-state';
+
+type PendingStartupCommand = {
+	repoPath: string; // Normalized, like the repo page's path
+	state: backend.StartupState;
+};
+
+// Commands waiting for their repo's page, which isn't mounted yet when the repo was just opened
+const pendingStartupCommandsAtom = atom<PendingStartupCommand[]>([]);
+
+// Routes the command GitWhale was started with, and the ones other GitWhale processes forward to this window.
+// Mounted once, at the root of the app
+export function useStartupCommand() {
+	const { appState } = UseAppState();
+	const rootNavigation = useNavigateRootFilTabs();
+	const rootNavigationRef = useRef(rootNavigation);
+	rootNavigationRef.current = rootNavigation;
+
+	const setPendingCommands = useSetAtom(pendingStartupCommandsAtom);
+	const hasRoutedStartupState = useRef(false);
+
+	const routeCommand = async (state: backend.StartupState) => {
+		// difftool has its own page, and no command just opens the app
+		if (!state.repoPath) {
+			return;
+		}
+
+		const repoPath = await NormalizeFolderPath(state.repoPath);
+		if (state.command !== 'open') {
+			setPendingCommands((prev) => [...prev, { repoPath, state }]);
+		}
+		rootNavigationRef.current.onOpenRepoWithPath(repoPath);
+	};
+
+	useEffect(() => {
+		const startupState = appState?.startupState;
+		if (!startupState || hasRoutedStartupState.current) {
+			return;
+		}
+
+		hasRoutedStartupState.current = true;
+		routeCommand(startupState);
+	}, [appState?.startupState]);
+
+	useEffect(() => {
+		const unsubscribe = EventsOn('onStartupCommand', routeCommand);
+
+		return () => {
+			unsubscribe();
+		};
+	}, []);
+}
+
+// Shows the diff, log or blame that a command asked for in this repo. Mounted by the repo's page
+export function useStartupCommandsForRepo(repoPath: string) {
+	const [pendingCommands, setPendingCommands] = useAtom(pendingStartupCommandsAtom);
+	const { navigateToCommitDiffWithOptions } = useNavigateToCommitDiffs(repoPath);
+	const navigateToBlame = useNavigateToBlame(repoPath);
+	const logState = useRepoLogState(repoPath);
+	const sidebar = useSidebarHandlers(SidebarSessionKeyGenerator.repoSidebar(repoPath));
+
+	const commandsForRepo = pendingCommands.filter((command) => command.repoPath === repoPath);
+
+	// The log can only be switched to once the sidebar has registered its static items
+	const isSidebarReady = !!sidebar.staticItems;
+
+	useEffect(() => {
+		if (commandsForRepo.length === 0 || !isSidebarReady) {
+			return;
+		}
+
+		setPendingCommands((prev) => prev.filter((command) => command.repoPath !== repoPath));
+		commandsForRepo.forEach(({ state }) => {
+			switch (state.command) {
+				case 'diff':
+					if (state.diffOptions) {
+						navigateToCommitDiffWithOptions(
+							git_operations.DiffOptions.createFrom({ ...state.diffOptions, repoPath })
+						);
+					}
+					break;
+				case 'log':
+					logState.refreshLogAndRefs({
+						...logState.options.get(),
+						paths: state.filePath ? [state.filePath] : undefined,
+					});
+					sidebar.setActiveItem('log');
+					break;
+				case 'blame':
+					if (state.filePath) {
+						navigateToBlame(state.filePath);
+					}
+					break;
+			}
+		});
+	}, [commandsForRepo.length, isSidebarReady]);
+}
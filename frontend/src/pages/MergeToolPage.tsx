@@ -0,0 +1,19 @@
+import LoadingSpinner from '@/components/loading-spinner';
+import MergeToolView from '@/components/merge-tool-view';
+import { useQuery } from 'react-query';
+import { GetStartupMergeTool } from '../../wailsjs/go/backend/App';
+
+// The window that git's mergetool started. Finishing the merge closes it
+export default function MergeToolPage() {
+	const { data: request } = useQuery({
+		queryKey: ['GetStartupMergeTool'],
+		queryFn: GetStartupMergeTool,
+		staleTime: Infinity,
+	});
+
+	if (!request) {
+		return <LoadingSpinner />;
+	}
+
+	return <MergeToolView request={request} />;
+}
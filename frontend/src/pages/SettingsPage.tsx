@@ -3,6 +3,7 @@ import {
 	GitSettings,
 	TerminalSettings,
 	AppearanceSettings,
+	AutomationSettings,
 	ApplicationInfo,
 	SettingsHeader,
 	SettingsLoading,
@@ -24,6 +25,7 @@ export default function SettingsPage() {
 				<GitSettings />
 				<TerminalSettings />
 				<AppearanceSettings />
+				<AutomationSettings />
 				<div className="lg:col-span-2">
 					<UserScriptSettings />
 				</div>
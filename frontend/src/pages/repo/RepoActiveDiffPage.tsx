@@ -13,6 +13,7 @@ import {
 } from '@/hooks/state/useFileTabsHandlers';
 import { usePersistentPanelSizes } from '@/hooks/use-persistent-panel-sizes';
 import { useRefreshOnFocus } from '@/hooks/utils/use-refresh-on-focus';
+import { useRepoWatcherEvents } from '@/hooks/utils/use-repo-watcher-events';
 import { cn } from '@/lib/utils';
 import Logger from '@/utils/logger';
 import {
@@ -26,7 +27,7 @@ import {
 	Plus,
 	RefreshCw,
 } from 'lucide-react';
-import { CleanupStagingDiffSession, CreateStagingDiffSession } from '../../../wailsjs/go/backend/App';
+import { CreateStagingDiffSession } from '../../../wailsjs/go/backend/App';
 import { git_operations } from '../../../wailsjs/go/models';
 import { useEffect } from 'react';
 import { DiffFileListItem, DiffStatusBadge } from '@/components/git-diff/diff-file-list-item';
@@ -46,7 +47,9 @@ export default function RepoActiveDiffPage({ repoPath }: RepoActiveDiffPageProps
 	// 2. If the user requested one
 	// 3. If the user performed a few operations, and we need make sure the staged file list is in sync with git
 	// 		(this is because we prematurely update all the files in the view)
+	// 4. If the repo's files, index or HEAD changed outside of GitWhale
 	useRefreshOnFocus(actions.refresh);
+	useRepoWatcherEvents(repoPath, ['statusChanged', 'headMoved'], actions.refresh);
 	useKeyboardShortcut('r', actions.refresh);
 	const shouldTriggerAutoRefresh = useDebounce(stateFlags.shouldTriggerAutoRefresh, 600);
 	useEffect(() => {
@@ -350,18 +353,19 @@ function FileListSection({
 
 	const openFileInDiff = async (file: git_operations.GitStatusFile, fileType: string) => {
 		try {
-			Logger.info(`Creating staging diff session for: ${file.path} (${fileType})`, 'StagingPage');
+			// Conflicted files show up as both staged and unstaged, so they're always compared ours vs theirs
+			const diffType = file.isConflicted ? 'conflicted' : fileType;
+			Logger.info(`Creating staging diff session for: ${file.path} (${diffType})`, 'StagingPage');
 
-			// Create staging diff session
-			const diffInfo = await CreateStagingDiffSession(repoPath, file.path, fileType);
+			const diffInfo = await CreateStagingDiffSession(repoPath, file, diffType);
 
-			// Create FileInfo for the diff viewer with the temporary file paths
+			// The contents are passed to the diff viewer directly, so the paths are only used for display
 			const fileInfo: git_operations.FileInfo = {
 				Name: file.path.split('/').pop() || file.path,
 				Path: file.path,
 				Extension: file.path.split('.').pop() || '',
-				LeftDirAbsPath: diffInfo.leftPath,
-				RightDirAbsPath: diffInfo.rightPath,
+				LeftDirAbsPath: diffInfo.oldPath,
+				RightDirAbsPath: diffInfo.filePath,
 			};
 
 			const tabKey = `staging-${fileType}-${file.path}`;
@@ -377,15 +381,16 @@ function FileListSection({
 						</span>
 					</span>
 				),
-				tooltipContent: () => <>{file.path}</>,
-				component: <FileDiffView file={fileInfo} />,
+				tooltipContent: () => (
+					<>{diffInfo.oldPath !== diffInfo.filePath ? `${diffInfo.oldPath} → ${file.path}` : file.path}</>
+				),
+				component: (
+					<FileDiffView
+						file={fileInfo}
+						contents={{ original: diffInfo.left.content, modified: diffInfo.right.content }}
+					/>
+				),
 				isPermanentlyOpen: false,
-				onTabClose: () => {
-					// Cleanup staging diff session when tab is closed
-					CleanupStagingDiffSession(diffInfo.sessionId).catch((error) => {
-						Logger.error(`Failed to cleanup staging diff session: ${error}`, 'StagingPage');
-					});
-				},
 			};
 
 			fileTabsHandlers.openTab(tab);
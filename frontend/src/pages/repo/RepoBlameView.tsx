@@ -0,0 +1,85 @@
+import { CommitHash } from '@/components/commit-hash';
+import { EmptyState } from '@/components/empty-state';
+import LoadingSpinner from '@/components/loading-spinner';
+import { convertUnixTimeToDate } from '@/hooks/utils/use-unix-time';
+import Logger from '@/utils/logger';
+import { FileX } from 'lucide-react';
+import { useEffect, useState } from 'react';
+import { GetBlame } from '../../../wailsjs/go/backend/App';
+import { git_operations } from '../../../wailsjs/go/models';
+
+// git blame reports lines that aren't committed yet with an all-zero hash
+const isUncommitted = (commitHash: string) => /^0+$/.test(commitHash);
+
+export default function RepoBlameView({ repoPath, filePath }: { repoPath: string; filePath: string }) {
+	const [blameLines, setBlameLines] = useState<git_operations.BlameLine[] | undefined>(undefined);
+	const [error, setError] = useState<string | undefined>(undefined);
+
+	useEffect(() => {
+		GetBlame(repoPath, filePath)
+			.then(setBlameLines)
+			.catch((err) => {
+				Logger.error(`Failed to blame ${filePath}: ${err}`, 'RepoBlameView');
+				setError(`${err}`);
+			});
+	}, [repoPath, filePath]);
+
+	if (error) {
+		return (
+			<EmptyState
+				title={() => (
+					<>
+						<FileX className="h-5 w-5" /> Failed to blame {filePath}
+					</>
+				)}
+				message={error}
+			/>
+		);
+	}
+
+	if (!blameLines) {
+		return <LoadingSpinner />;
+	}
+
+	return (
+		<div className="h-full w-full overflow-auto font-mono text-xs">
+			<table className="w-full border-collapse">
+				<tbody>
+					{blameLines.map((line, index) => {
+						// Only the first line of each block of lines from the same commit shows who changed it
+						const startsBlock = index === 0 || blameLines[index - 1].commitHash !== line.commitHash;
+
+						return (
+							<tr
+								key={line.lineNumber}
+								className={startsBlock && index !== 0 ? 'border-t border-border/50' : undefined}
+							>
+								<td className="w-72 max-w-72 px-2 whitespace-nowrap overflow-hidden text-ellipsis text-muted-foreground align-top">
+									{startsBlock &&
+										(isUncommitted(line.commitHash) ? (
+											<span>Not committed yet</span>
+										) : (
+											<span className="flex items-center gap-2" title={line.summary}>
+												<CommitHash
+													commitHash={line.commitHash}
+													repoPath={repoPath}
+													shortHash
+													showIcon={false}
+												/>
+												<span className="truncate">{line.author}</span>
+												<span>{convertUnixTimeToDate(`${line.authorTime}`).toLocaleDateString()}</span>
+											</span>
+										))}
+								</td>
+								<td className="w-12 px-2 text-right text-muted-foreground select-none align-top">
+									{line.lineNumber}
+								</td>
+								<td className="px-2 whitespace-pre">{line.content}</td>
+							</tr>
+						);
+					})}
+				</tbody>
+			</table>
+		</div>
+	);
+}
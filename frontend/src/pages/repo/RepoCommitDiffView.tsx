@@ -7,7 +7,8 @@ import { Separator } from '@/components/ui/separator';
 import { useRepoDiffState } from '@/hooks/state/repo/use-git-diff-state';
 import { FileTabsSessionKeyGenerator } from '@/hooks/state/useFileTabsHandlers';
 import { usePersistentPanelSizes } from '@/hooks/use-persistent-panel-sizes';
-import { GitCompare } from 'lucide-react';
+import { GitCompare, Loader2 } from 'lucide-react';
+import { useEffect, useState } from 'react';
 
 interface RepoCommitDiffViewProps {
 	repoPath: string;
@@ -16,7 +17,8 @@ interface RepoCommitDiffViewProps {
 
 export default function RepoCommitDiffView(props: RepoCommitDiffViewProps) {
 	const { repoPath, diffSessionID } = props;
-	const { sessionsData } = useRepoDiffState(repoPath);
+	const { sessionsData, restoreSession } = useRepoDiffState(repoPath);
+	const [isRestoring, setIsRestoring] = useState(false);
 
 	// Persistent panel sizes for file tree (left) and diff content (right)
 	const [panelSizes, setPanelSizes] = usePersistentPanelSizes(
@@ -25,6 +27,26 @@ export default function RepoCommitDiffView(props: RepoCommitDiffViewProps) {
 	);
 
 	const diffSession = sessionsData.find((s) => s.sessionId === diffSessionID);
+	const needsRestore = !!diffSession && !diffSession.directoryData;
+
+	useEffect(() => {
+		if (!needsRestore) {
+			return;
+		}
+
+		setIsRestoring(true);
+		restoreSession(diffSessionID).finally(() => setIsRestoring(false));
+	}, [needsRestore, diffSessionID]);
+
+	if (isRestoring) {
+		return (
+			<div className="w-full h-full flex items-center justify-center gap-2 text-muted-foreground">
+				<Loader2 className="w-4 h-4 animate-spin" />
+				Restoring diff...
+			</div>
+		);
+	}
+
 	if (!diffSession || !diffSession.directoryData) {
 		return (
 			<EmptyState
@@ -68,6 +90,8 @@ export default function RepoCommitDiffView(props: RepoCommitDiffViewProps) {
 							className="grow"
 							directoryData={diffSession.directoryData}
 							fileTabsSessionKey={FileTabsSessionKeyGenerator.diffSession(diffSessionID)}
+							diffSessionID={diffSessionID}
+							isLazySession={diffSession.isLazy}
 						/>
 
 						{diffSession.commitInformation && (
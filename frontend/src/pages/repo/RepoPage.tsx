@@ -5,6 +5,9 @@ import RepoActiveDiffPage from '@/pages/repo/RepoActiveDiffPage';
 import RepoHomeView from '@/pages/repo/RepoHomeView';
 import RepoLogView from '@/pages/repo/RepoLogView';
 import RepoTerminalView from '@/pages/repo/RepoTerminalView';
+import { useAutomationDiffsForRepo } from '@/hooks/utils/use-automation-events';
+import { useRestoredDiffSessions } from '@/hooks/utils/use-restored-diff-sessions';
+import { useStartupCommandsForRepo } from '@/hooks/utils/use-startup-command';
 import { CommandPaletteContextKey } from '@/types/command-palette';
 import { FolderGit, GitGraph, House, Terminal } from 'lucide-react';
 import { useEffect } from 'react';
@@ -18,6 +21,9 @@ interface RepoViewTabsProps {
 
 export default function RepoPage({ repoPath, className }: RepoViewTabsProps) {
 	const commandPaletteState = useCommandPaletteState();
+	useAutomationDiffsForRepo(repoPath);
+	useStartupCommandsForRepo(repoPath);
+	useRestoredDiffSessions(repoPath);
 
 	// Static sidebar items that are always available
 	const staticItems: SidebarItemProps[] = [
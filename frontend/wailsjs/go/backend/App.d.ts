@@ -6,7 +6,16 @@ import {logger} from '../models';
 import {command_utils} from '../models';
 import {context} from '../models';
 
-export function CleanupStagingDiffSession(arg1:string):Promise<void>;
+
+export function AbortPatchApply(arg1:string):Promise<void>;
+
+export function AnswerCredentialPrompt(arg1:string,arg2:string,arg3:boolean):Promise<Promise<void>>;
+
+export function ApplyPatch(arg1:git_operations.PatchApplyOptions):Promise<git_operations.PatchApplyResult>;
+
+export function CancelCredentialPrompt(arg1:string):Promise<Promise<void>>;
+
+export function CancelJob(arg1:string):Promise<void>;
 
 export function CleanupTerminalSession(arg1:string):Promise<void>;
 
@@ -14,11 +23,19 @@ export function ClearApplicationLogHistory():Promise<void>;
 
 export function ClearCommandLogs():Promise<void>;
 
+export function ClearCredentialCache():Promise<Promise<void>>;
+
+export function CloseForwardedDirDiff(arg1:string):Promise<void>;
+
 export function CloseRepo(arg1:string):Promise<backend.App>;
 
 export function CommitChanges(arg1:string,arg2:string):Promise<void>;
 
-export function CreateStagingDiffSession(arg1:string,arg2:string,arg3:string):Promise<git_operations.StagingDiffInfo>;
+export function CompareRefs(arg1:string,arg2:string,arg3:string):Promise<git_operations.RefComparison>;
+
+export function ContinuePatchApply(arg1:string):Promise<git_operations.PatchApplyResult>;
+
+export function CreateStagingDiffSession(arg1:string,arg2:git_operations.GitStatusFile,arg3:string):Promise<git_operations.StagingDiffInfo>;
 
 export function DeleteUserScriptCommand(arg1:string):Promise<void>;
 
@@ -26,28 +43,58 @@ export function EndDiffSession(arg1:string):Promise<void>;
 
 export function ExecuteShellCommand(arg1:string,arg2:string,arg3:string):Promise<void>;
 
+export function ExitCode():Promise<number>;
+
+export function ExportPatches(arg1:git_operations.PatchExportOptions):Promise<git_operations.PatchExportResult>;
+
 export function ExportUserScripts(arg1:Array<string>):Promise<void>;
 
+export function FinishMergeTool(arg1:string,arg2:string,arg3:boolean):Promise<void>;
+
 export function GetAllRefs(arg1:string):Promise<Array<git_operations.GitRef>>;
 
 export function GetAppState():Promise<backend.App>;
 
 export function GetApplicationLogHistory():Promise<Array<logger.LogEntry>>;
 
+export function GetBinaryDiffInfo(arg1:git_operations.BinaryDiffOptions):Promise<git_operations.BinaryDiffInfo>;
+
+export function GetBisectLog(arg1:string):Promise<string>;
+
+export function GetBisectState(arg1:string):Promise<git_operations.BisectState>;
+
+export function GetBlame(arg1:string,arg2:string):Promise<Array<git_operations.BlameLine>>;
+
 export function GetCommandById(arg1:string):Promise<command_utils.CommandEntry>;
 
 export function GetCommandLogs():Promise<Array<command_utils.CommandEntry>>;
 
+export function GetCommitBinaryDiffInfo(arg1:string,arg2:string,arg3:git_operations.FileChange,arg4:number,arg5:number):Promise<git_operations.BinaryDiffInfo>;
+
 export function GetDetailedCommitInfo(arg1:string,arg2:string):Promise<git_operations.DetailedCommitInfo>;
 
 export function GetDiffSession(arg1:string):Promise<git_operations.DiffSession>;
 
+export function GetDiffSessionFileContent(arg1:string,arg2:string,arg3:string):Promise<git_operations.DiffFileContent>;
+
+export function GetDirDiffDirectory(arg1:string,arg2:string):Promise<git_operations.Directory>;
+
+export function GetFileContentFromRef(arg1:string,arg2:string,arg3:string):Promise<string>;
+
 export function GetGitStatus(arg1:string):Promise<git_operations.GitStatus>;
 
+export function GetGrepFileContent(arg1:string,arg2:string,arg3:string,arg4:string):Promise<string>;
+
+export function GetRangeDiff(arg1:git_operations.RangeDiffOptions):Promise<git_operations.RangeDiffResult>;
+
 export function GetStartupDirDiffDirectory():Promise<git_operations.Directory>;
 
+export function GetStartupMergeTool():Promise<backend.MergeToolRequest>;
+
 export function GetTerminalDefaults():Promise<backend.TerminalDefaults>;
 
+export function GetUnifiedDiff(arg1:git_operations.DiffOptions):Promise<git_operations.UnifiedDiff>;
+
 export function GetWorktrees(arg1:string):Promise<Array<git_operations.WorktreeInfo>>;
 
 export function GitFetch(arg1:string):Promise<void>;
@@ -58,6 +105,10 @@ export function InitNewTerminalSession(arg1:string):Promise<void>;
 
 export function ListDiffSessions():Promise<Array<git_operations.DiffSession>>;
 
+export function ListJobs():Promise<Array<command_utils.JobInfo>>;
+
+export function MarkBisectCommit(arg1:string,arg2:string,arg3:string):Promise<git_operations.BisectState>;
+
 export function NormalizeFolderPath(arg1:string):Promise<string>;
 
 export function OnTerminalSessionWasResized(arg1:string,arg2:command_utils.TTYSize):Promise<void>;
@@ -68,16 +119,30 @@ export function OpenRepoWithPath(arg1:string):Promise<void>;
 
 export function ReadFile(arg1:string):Promise<string>;
 
+export function ResetBisect(arg1:string):Promise<void>;
+
 export function RunGitLog(arg1:string,arg2:git_operations.GitLogOptions):Promise<Array<git_operations.GitLogCommitInfo>>;
 
 export function SaveUserScriptCommand(arg1:backend.UserDefinedCommandDefinition):Promise<void>;
 
+export function SelectPatchFileForImport():Promise<string>;
+
 export function SelectUserScriptFileForImport():Promise<string>;
 
+export function SkipPatch(arg1:string):Promise<git_operations.PatchApplyResult>;
+
 export function StageFile(arg1:string,arg2:Array<string>):Promise<void>;
 
+export function StartBisect(arg1:string,arg2:string,arg3:Array<string>):Promise<git_operations.BisectState>;
+
+export function StartBisectRun(arg1:string,arg2:string,arg3:Array<string>,arg4:string):Promise<void>;
+
 export function StartDiffSession(arg1:git_operations.DiffOptions):Promise<git_operations.DiffSession>;
 
+export function StartGrepSearch(arg1:git_operations.GrepOptions,arg2:string):Promise<void>;
+
+export function StartMergeCommitDiffSessions(arg1:git_operations.DiffOptions):Promise<Array<git_operations.DiffSession>>;
+
 export function Startup(arg1:context.Context,arg2:backend.StartupState):Promise<void>;
 
 export function ToggleStarRepo(arg1:string):Promise<boolean>;
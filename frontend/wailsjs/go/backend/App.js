@@ -2,8 +2,24 @@
 // Cynhyrchwyd y ffeil hon yn awtomatig. PEIDIWCH Â MODIWL
 // This file is automatically generated. DO NOT EDIT
 
-export function CleanupStagingDiffSession(arg1) {
-  return window['go']['backend']['App']['CleanupStagingDiffSession'](arg1);
+export function AbortPatchApply(arg1) {
+  return window['go']['backend']['App']['AbortPatchApply'](arg1);
+}
+
+export function AnswerCredentialPrompt(arg1, arg2, arg3) {
+  return window['go']['backend']['App']['AnswerCredentialPrompt'](arg1, arg2, arg3);
+}
+
+export function ApplyPatch(arg1) {
+  return window['go']['backend']['App']['ApplyPatch'](arg1);
+}
+
+export function CancelCredentialPrompt(arg1) {
+  return window['go']['backend']['App']['CancelCredentialPrompt'](arg1);
+}
+
+export function CancelJob(arg1) {
+  return window['go']['backend']['App']['CancelJob'](arg1);
 }
 
 export function CleanupTerminalSession(arg1) {
@@ -18,6 +34,14 @@ export function ClearCommandLogs() {
   return window['go']['backend']['App']['ClearCommandLogs']();
 }
 
+export function ClearCredentialCache() {
+  return window['go']['backend']['App']['ClearCredentialCache']();
+}
+
+export function CloseForwardedDirDiff(arg1) {
+  return window['go']['backend']['App']['CloseForwardedDirDiff'](arg1);
+}
+
 export function CloseRepo(arg1) {
   return window['go']['backend']['App']['CloseRepo'](arg1);
 }
@@ -26,6 +50,14 @@ export function CommitChanges(arg1, arg2) {
   return window['go']['backend']['App']['CommitChanges'](arg1, arg2);
 }
 
+export function CompareRefs(arg1, arg2, arg3) {
+  return window['go']['backend']['App']['CompareRefs'](arg1, arg2, arg3);
+}
+
+export function ContinuePatchApply(arg1) {
+  return window['go']['backend']['App']['ContinuePatchApply'](arg1);
+}
+
 export function CreateStagingDiffSession(arg1, arg2, arg3) {
   return window['go']['backend']['App']['CreateStagingDiffSession'](arg1, arg2, arg3);
 }
@@ -42,10 +74,22 @@ export function ExecuteShellCommand(arg1, arg2, arg3) {
   return window['go']['backend']['App']['ExecuteShellCommand'](arg1, arg2, arg3);
 }
 
+export function ExitCode() {
+  return window['go']['backend']['App']['ExitCode']();
+}
+
+export function ExportPatches(arg1) {
+  return window['go']['backend']['App']['ExportPatches'](arg1);
+}
+
 export function ExportUserScripts(arg1) {
   return window['go']['backend']['App']['ExportUserScripts'](arg1);
 }
 
+export function FinishMergeTool(arg1, arg2, arg3) {
+  return window['go']['backend']['App']['FinishMergeTool'](arg1, arg2, arg3);
+}
+
 export function GetAllRefs(arg1) {
   return window['go']['backend']['App']['GetAllRefs'](arg1);
 }
@@ -58,6 +102,22 @@ export function GetApplicationLogHistory() {
   return window['go']['backend']['App']['GetApplicationLogHistory']();
 }
 
+export function GetBinaryDiffInfo(arg1) {
+  return window['go']['backend']['App']['GetBinaryDiffInfo'](arg1);
+}
+
+export function GetBisectLog(arg1) {
+  return window['go']['backend']['App']['GetBisectLog'](arg1);
+}
+
+export function GetBisectState(arg1) {
+  return window['go']['backend']['App']['GetBisectState'](arg1);
+}
+
+export function GetBlame(arg1, arg2) {
+  return window['go']['backend']['App']['GetBlame'](arg1, arg2);
+}
+
 export function GetCommandById(arg1) {
   return window['go']['backend']['App']['GetCommandById'](arg1);
 }
@@ -66,6 +126,10 @@ export function GetCommandLogs() {
   return window['go']['backend']['App']['GetCommandLogs']();
 }
 
+export function GetCommitBinaryDiffInfo(arg1, arg2, arg3, arg4, arg5) {
+  return window['go']['backend']['App']['GetCommitBinaryDiffInfo'](arg1, arg2, arg3, arg4, arg5);
+}
+
 export function GetDetailedCommitInfo(arg1, arg2) {
   return window['go']['backend']['App']['GetDetailedCommitInfo'](arg1, arg2);
 }
@@ -74,18 +138,46 @@ export function GetDiffSession(arg1) {
   return window['go']['backend']['App']['GetDiffSession'](arg1);
 }
 
+export function GetDiffSessionFileContent(arg1, arg2, arg3) {
+  return window['go']['backend']['App']['GetDiffSessionFileContent'](arg1, arg2, arg3);
+}
+
+export function GetDirDiffDirectory(arg1, arg2) {
+  return window['go']['backend']['App']['GetDirDiffDirectory'](arg1, arg2);
+}
+
+export function GetFileContentFromRef(arg1, arg2, arg3) {
+  return window['go']['backend']['App']['GetFileContentFromRef'](arg1, arg2, arg3);
+}
+
 export function GetGitStatus(arg1) {
   return window['go']['backend']['App']['GetGitStatus'](arg1);
 }
 
+export function GetGrepFileContent(arg1, arg2, arg3, arg4) {
+  return window['go']['backend']['App']['GetGrepFileContent'](arg1, arg2, arg3, arg4);
+}
+
+export function GetRangeDiff(arg1) {
+  return window['go']['backend']['App']['GetRangeDiff'](arg1);
+}
+
 export function GetStartupDirDiffDirectory() {
   return window['go']['backend']['App']['GetStartupDirDiffDirectory']();
 }
 
+export function GetStartupMergeTool() {
+  return window['go']['backend']['App']['GetStartupMergeTool']();
+}
+
 export function GetTerminalDefaults() {
   return window['go']['backend']['App']['GetTerminalDefaults']();
 }
 
+export function GetUnifiedDiff(arg1) {
+  return window['go']['backend']['App']['GetUnifiedDiff'](arg1);
+}
+
 export function GetWorktrees(arg1) {
   return window['go']['backend']['App']['GetWorktrees'](arg1);
 }
@@ -106,6 +198,14 @@ export function ListDiffSessions() {
   return window['go']['backend']['App']['ListDiffSessions']();
 }
 
+export function ListJobs() {
+  return window['go']['backend']['App']['ListJobs']();
+}
+
+export function MarkBisectCommit(arg1, arg2, arg3) {
+  return window['go']['backend']['App']['MarkBisectCommit'](arg1, arg2, arg3);
+}
+
 export function NormalizeFolderPath(arg1) {
   return window['go']['backend']['App']['NormalizeFolderPath'](arg1);
 }
@@ -126,6 +226,10 @@ export function ReadFile(arg1) {
   return window['go']['backend']['App']['ReadFile'](arg1);
 }
 
+export function ResetBisect(arg1) {
+  return window['go']['backend']['App']['ResetBisect'](arg1);
+}
+
 export function RunGitLog(arg1, arg2) {
   return window['go']['backend']['App']['RunGitLog'](arg1, arg2);
 }
@@ -134,18 +238,42 @@ export function SaveUserScriptCommand(arg1) {
   return window['go']['backend']['App']['SaveUserScriptCommand'](arg1);
 }
 
+export function SelectPatchFileForImport() {
+  return window['go']['backend']['App']['SelectPatchFileForImport']();
+}
+
 export function SelectUserScriptFileForImport() {
   return window['go']['backend']['App']['SelectUserScriptFileForImport']();
 }
 
+export function SkipPatch(arg1) {
+  return window['go']['backend']['App']['SkipPatch'](arg1);
+}
+
 export function StageFile(arg1, arg2) {
   return window['go']['backend']['App']['StageFile'](arg1, arg2);
 }
 
+export function StartBisect(arg1, arg2, arg3) {
+  return window['go']['backend']['App']['StartBisect'](arg1, arg2, arg3);
+}
+
+export function StartBisectRun(arg1, arg2, arg3, arg4) {
+  return window['go']['backend']['App']['StartBisectRun'](arg1, arg2, arg3, arg4);
+}
+
 export function StartDiffSession(arg1) {
   return window['go']['backend']['App']['StartDiffSession'](arg1);
 }
 
+export function StartGrepSearch(arg1, arg2) {
+  return window['go']['backend']['App']['StartGrepSearch'](arg1, arg2);
+}
+
+export function StartMergeCommitDiffSessions(arg1) {
+  return window['go']['backend']['App']['StartMergeCommitDiffSessions'](arg1);
+}
+
 export function Startup(arg1, arg2) {
   return window['go']['backend']['App']['Startup'](arg1, arg2);
 }
@@ -94,6 +94,7 @@ export namespace backend {
 	}
 	export class UISettings {
 	    autoShowCommitDetails: boolean;
+	    hideUnchangedDirDiffFiles: boolean;
 	
 	    static createFrom(source: any = {}) {
 	        return new UISettings(source);
@@ -102,12 +103,16 @@ export namespace backend {
 	    constructor(source: any = {}) {
 	        if ('string' === typeof source) source = JSON.parse(source);
 	        this.autoShowCommitDetails = source["autoShowCommitDetails"];
+	        this.hideUnchangedDirDiffFiles = source["hideUnchangedDirDiffFiles"];
 	    }
 	}
 	export class GitSettings {
 	    commitsToLoad: number;
 	    commitMessageTabWidth: number;
 	    commitMessageWrapLimitCol: number;
+	    diffSettings: git_operations.DiffSettings;
+	    diffSessions: git_operations.DiffSessionSettings;
+	    backend: string;
 	
 	    static createFrom(source: any = {}) {
 	        return new GitSettings(source);
@@ -118,12 +123,46 @@ export namespace backend {
 	        this.commitsToLoad = source["commitsToLoad"];
 	        this.commitMessageTabWidth = source["commitMessageTabWidth"];
 	        this.commitMessageWrapLimitCol = source["commitMessageWrapLimitCol"];
+	        this.diffSettings = this.convertValues(source["diffSettings"], git_operations.DiffSettings);
+	        this.diffSessions = this.convertValues(source["diffSessions"], git_operations.DiffSessionSettings);
+	        this.backend = source["backend"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class AutomationSettings {
+	    enabled: boolean;
+	
+	    static createFrom(source: any = {}) {
+	        return new AutomationSettings(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.enabled = source["enabled"];
 	    }
 	}
 	export class AppSettings {
 	    git: GitSettings;
 	    terminal: command_utils.TerminalSettings;
 	    ui: UISettings;
+	    automation: AutomationSettings;
 	    userScriptCommands: UserDefinedCommandDefinition[];
 	
 	    static createFrom(source: any = {}) {
@@ -135,6 +174,7 @@ export namespace backend {
 	        this.git = this.convertValues(source["git"], GitSettings);
 	        this.terminal = this.convertValues(source["terminal"], command_utils.TerminalSettings);
 	        this.ui = this.convertValues(source["ui"], UISettings);
+	        this.automation = this.convertValues(source["automation"], AutomationSettings);
 	        this.userScriptCommands = this.convertValues(source["userScriptCommands"], UserDefinedCommandDefinition);
 	    }
 	
@@ -196,12 +236,60 @@ export namespace backend {
 		    return a;
 		}
 	}
+	export class MergeToolArgs {
+	    localPath: string;
+	    remotePath: string;
+	    basePath: string;
+	    mergedPath: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new MergeToolArgs(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.localPath = source["localPath"];
+	        this.remotePath = source["remotePath"];
+	        this.basePath = source["basePath"];
+	        this.mergedPath = source["mergedPath"];
+	    }
+	}
+	export class MergeToolRequest {
+	    id: string;
+	    args: MergeToolArgs;
+	
+	    static createFrom(source: any = {}) {
+	        return new MergeToolRequest(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.id = source["id"];
+	        this.args = this.convertValues(source["args"], MergeToolArgs);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
 	export class StartupDirectoryDiffArgs {
 	    leftFolderPath: string;
 	    rightFolderPath: string;
 	    IsFileDiff: boolean;
-	    ShouldSendNotification: boolean;
-	    ShouldStartFileWatcher: boolean;
 	
 	    static createFrom(source: any = {}) {
 	        return new StartupDirectoryDiffArgs(source);
@@ -212,12 +300,15 @@ export namespace backend {
 	        this.leftFolderPath = source["leftFolderPath"];
 	        this.rightFolderPath = source["rightFolderPath"];
 	        this.IsFileDiff = source["IsFileDiff"];
-	        this.ShouldSendNotification = source["ShouldSendNotification"];
-	        this.ShouldStartFileWatcher = source["ShouldStartFileWatcher"];
 	    }
 	}
 	export class StartupState {
+	    command: string;
+	    repoPath?: string;
+	    filePath?: string;
+	    diffOptions?: git_operations.DiffOptions;
 	    directoryDiffArgs?: StartupDirectoryDiffArgs;
+	    mergeToolArgs?: MergeToolArgs;
 	
 	    static createFrom(source: any = {}) {
 	        return new StartupState(source);
@@ -225,7 +316,12 @@ export namespace backend {
 	
 	    constructor(source: any = {}) {
 	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.command = source["command"];
+	        this.repoPath = source["repoPath"];
+	        this.filePath = source["filePath"];
+	        this.diffOptions = this.convertValues(source["diffOptions"], git_operations.DiffOptions);
 	        this.directoryDiffArgs = this.convertValues(source["directoryDiffArgs"], StartupDirectoryDiffArgs);
+	        this.mergeToolArgs = this.convertValues(source["mergeToolArgs"], MergeToolArgs);
 	    }
 	
 		convertValues(a: any, classs: any, asMap: boolean = false): any {
@@ -431,87 +527,152 @@ export namespace command_utils {
 	        this.cursorStyle = source["cursorStyle"];
 	    }
 	}
+	export class JobProgress {
+	    message: string;
+	    percent: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new JobProgress(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.message = source["message"];
+	        this.percent = source["percent"];
+	    }
+	}
+	export class JobInfo {
+	    id: string;
+	    title: string;
+	    repoPath: string;
+	    writesToRepo: boolean;
+	    state: string;
+	    progress: JobProgress;
+	    error?: string;
+	    // Go type: time
+	    createdAt: any;
+	    // Go type: time
+	    startedAt?: any;
+	    // Go type: time
+	    finishedAt?: any;
+	
+	    static createFrom(source: any = {}) {
+	        return new JobInfo(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.id = source["id"];
+	        this.title = source["title"];
+	        this.repoPath = source["repoPath"];
+	        this.writesToRepo = source["writesToRepo"];
+	        this.state = source["state"];
+	        this.progress = this.convertValues(source["progress"], JobProgress);
+	        this.error = source["error"];
+	        this.createdAt = this.convertValues(source["createdAt"], null);
+	        this.startedAt = this.convertValues(source["startedAt"], null);
+	        this.finishedAt = this.convertValues(source["finishedAt"], null);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
 
 }
 
 export namespace git_operations {
 	
-	export class CommitStats {
-	    filesChanged: number;
-	    linesAdded: number;
-	    linesDeleted: number;
-	    linesModified: number;
-	    totalLines: number;
+	export class HexDumpLine {
+	    offset: number;
+	    hex: string;
+	    ascii: string;
 	
 	    static createFrom(source: any = {}) {
-	        return new CommitStats(source);
+	        return new HexDumpLine(source);
 	    }
 	
 	    constructor(source: any = {}) {
 	        if ('string' === typeof source) source = JSON.parse(source);
-	        this.filesChanged = source["filesChanged"];
-	        this.linesAdded = source["linesAdded"];
-	        this.linesDeleted = source["linesDeleted"];
-	        this.linesModified = source["linesModified"];
-	        this.totalLines = source["totalLines"];
+	        this.offset = source["offset"];
+	        this.hex = source["hex"];
+	        this.ascii = source["ascii"];
 	    }
 	}
-	export class FileChange {
+	export class BinarySideInfo {
+	    exists: boolean;
 	    path: string;
-	    oldPath: string;
-	    status: string;
-	    linesAdded: number;
-	    linesDeleted: number;
-	    binaryFile: boolean;
+	    mimeType: string;
+	    size: number;
+	    isImage: boolean;
+	    width: number;
+	    height: number;
+	    thumbnail: string;
+	    hexDump: HexDumpLine[];
 	
 	    static createFrom(source: any = {}) {
-	        return new FileChange(source);
+	        return new BinarySideInfo(source);
 	    }
 	
 	    constructor(source: any = {}) {
 	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.exists = source["exists"];
 	        this.path = source["path"];
-	        this.oldPath = source["oldPath"];
-	        this.status = source["status"];
-	        this.linesAdded = source["linesAdded"];
-	        this.linesDeleted = source["linesDeleted"];
-	        this.binaryFile = source["binaryFile"];
+	        this.mimeType = source["mimeType"];
+	        this.size = source["size"];
+	        this.isImage = source["isImage"];
+	        this.width = source["width"];
+	        this.height = source["height"];
+	        this.thumbnail = source["thumbnail"];
+	        this.hexDump = this.convertValues(source["hexDump"], HexDumpLine);
 	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
 	}
-	export class DetailedCommitInfo {
-	    commitHash: string;
-	    username: string;
-	    userEmail: string;
-	    commitTimeStamp: string;
-	    authoredTimeStamp: string;
-	    parentCommitHashes: string[];
-	    refs: string;
-	    commitMessage: string[];
-	    shortStat: string;
-	    changedFiles: FileChange[];
-	    commitStats: CommitStats;
-	    committerName: string;
-	    committerEmail: string;
+	export class BinaryDiffInfo {
+	    left: BinarySideInfo;
+	    right: BinarySideInfo;
+	    sizeDelta: number;
 	
 	    static createFrom(source: any = {}) {
-	        return new DetailedCommitInfo(source);
+	        return new BinaryDiffInfo(source);
 	    }
 	
 	    constructor(source: any = {}) {
 	        if ('string' === typeof source) source = JSON.parse(source);
-	        this.commitHash = source["commitHash"];
-	        this.username = source["username"];
-	        this.userEmail = source["userEmail"];
-	        this.commitTimeStamp = source["commitTimeStamp"];
-	        this.authoredTimeStamp = source["authoredTimeStamp"];
-	        this.parentCommitHashes = source["parentCommitHashes"];
-	        this.refs = source["refs"];
-	        this.commitMessage = source["commitMessage"];
-	        this.shortStat = source["shortStat"];
-	        this.changedFiles = this.convertValues(source["changedFiles"], FileChange);
-	        this.commitStats = this.convertValues(source["commitStats"], CommitStats);
-	        this.committerName = source["committerName"];
-	        this.committerEmail = source["committerEmail"];
+	        this.left = this.convertValues(source["left"], BinarySideInfo);
+	        this.right = this.convertValues(source["right"], BinarySideInfo);
+	        this.sizeDelta = source["sizeDelta"];
 	    }
 	
 		convertValues(a: any, classs: any, asMap: boolean = false): any {
@@ -532,24 +693,31 @@ export namespace git_operations {
 		    return a;
 		}
 	}
-	export class DiffOptions {
+	export class BinaryDiffOptions {
 	    repoPath: string;
-	    fromRef: string;
-	    toRef: string;
-	    isSingleCommitDiff: boolean;
+	    leftRef: string;
+	    leftPath: string;
+	    rightRef: string;
+	    rightPath: string;
+	    hexDumpOffset: number;
+	    hexDumpLength: number;
 	
 	    static createFrom(source: any = {}) {
-	        return new DiffOptions(source);
+	        return new BinaryDiffOptions(source);
 	    }
 	
 	    constructor(source: any = {}) {
 	        if ('string' === typeof source) source = JSON.parse(source);
 	        this.repoPath = source["repoPath"];
-	        this.fromRef = source["fromRef"];
-	        this.toRef = source["toRef"];
-	        this.isSingleCommitDiff = source["isSingleCommitDiff"];
+	        this.leftRef = source["leftRef"];
+	        this.leftPath = source["leftPath"];
+	        this.rightRef = source["rightRef"];
+	        this.rightPath = source["rightPath"];
+	        this.hexDumpOffset = source["hexDumpOffset"];
+	        this.hexDumpLength = source["hexDumpLength"];
 	    }
 	}
+	
 	export class GitLogCommitInfo {
 	    commitHash: string;
 	    username: string;
@@ -578,42 +746,30 @@ export namespace git_operations {
 	        this.shortStat = source["shortStat"];
 	    }
 	}
-	export class FileInfo {
-	    Path: string;
-	    Name: string;
-	    Extension: string;
-	    LeftDirAbsPath: string;
-	    RightDirAbsPath: string;
-	
-	    static createFrom(source: any = {}) {
-	        return new FileInfo(source);
-	    }
-	
-	    constructor(source: any = {}) {
-	        if ('string' === typeof source) source = JSON.parse(source);
-	        this.Path = source["Path"];
-	        this.Name = source["Name"];
-	        this.Extension = source["Extension"];
-	        this.LeftDirAbsPath = source["LeftDirAbsPath"];
-	        this.RightDirAbsPath = source["RightDirAbsPath"];
-	    }
-	}
-	export class Directory {
-	    Path: string;
-	    Name: string;
-	    Files: FileInfo[];
-	    SubDirs: Directory[];
+	export class BisectState {
+	    isActive: boolean;
+	    currentCommit?: GitLogCommitInfo;
+	    remainingRevisions: number;
+	    estimatedSteps: number;
+	    badRef: string;
+	    goodRefs: string[];
+	    skipRefs: string[];
+	    firstBadCommit?: GitLogCommitInfo;
 	
 	    static createFrom(source: any = {}) {
-	        return new Directory(source);
+	        return new BisectState(source);
 	    }
 	
 	    constructor(source: any = {}) {
 	        if ('string' === typeof source) source = JSON.parse(source);
-	        this.Path = source["Path"];
-	        this.Name = source["Name"];
-	        this.Files = this.convertValues(source["Files"], FileInfo);
-	        this.SubDirs = this.convertValues(source["SubDirs"], Directory);
+	        this.isActive = source["isActive"];
+	        this.currentCommit = this.convertValues(source["currentCommit"], GitLogCommitInfo);
+	        this.remainingRevisions = source["remainingRevisions"];
+	        this.estimatedSteps = source["estimatedSteps"];
+	        this.badRef = source["badRef"];
+	        this.goodRefs = source["goodRefs"];
+	        this.skipRefs = source["skipRefs"];
+	        this.firstBadCommit = this.convertValues(source["firstBadCommit"], GitLogCommitInfo);
 	    }
 	
 		convertValues(a: any, classs: any, asMap: boolean = false): any {
@@ -634,40 +790,528 @@ export namespace git_operations {
 		    return a;
 		}
 	}
-	export class DiffSession {
-	    sessionId: string;
-	    repoPath: string;
-	    fromRef: string;
-	    toRef: string;
-	    leftPath: string;
-	    rightPath: string;
-	    // Go type: time
-	    createdAt: any;
-	    // Go type: time
-	    lastAccessed: any;
-	    title: string;
-	    directoryData?: Directory;
-	    hasDiffData: boolean;
-	    commitInformation?: GitLogCommitInfo;
+	export class BlameLine {
+	    lineNumber: number;
+	    commitHash: string;
+	    author: string;
+	    authorEmail: string;
+	    authorTime: number;
+	    summary: string;
+	    content: string;
 	
 	    static createFrom(source: any = {}) {
-	        return new DiffSession(source);
+	        return new BlameLine(source);
 	    }
 	
 	    constructor(source: any = {}) {
 	        if ('string' === typeof source) source = JSON.parse(source);
-	        this.sessionId = source["sessionId"];
-	        this.repoPath = source["repoPath"];
-	        this.fromRef = source["fromRef"];
-	        this.toRef = source["toRef"];
-	        this.leftPath = source["leftPath"];
-	        this.rightPath = source["rightPath"];
-	        this.createdAt = this.convertValues(source["createdAt"], null);
-	        this.lastAccessed = this.convertValues(source["lastAccessed"], null);
-	        this.title = source["title"];
-	        this.directoryData = this.convertValues(source["directoryData"], Directory);
-	        this.hasDiffData = source["hasDiffData"];
-	        this.commitInformation = this.convertValues(source["commitInformation"], GitLogCommitInfo);
+	        this.lineNumber = source["lineNumber"];
+	        this.commitHash = source["commitHash"];
+	        this.author = source["author"];
+	        this.authorEmail = source["authorEmail"];
+	        this.authorTime = source["authorTime"];
+	        this.summary = source["summary"];
+	        this.content = source["content"];
+	    }
+	}
+	export class CommitStats {
+	    filesChanged: number;
+	    linesAdded: number;
+	    linesDeleted: number;
+	    linesModified: number;
+	    totalLines: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new CommitStats(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.filesChanged = source["filesChanged"];
+	        this.linesAdded = source["linesAdded"];
+	        this.linesDeleted = source["linesDeleted"];
+	        this.linesModified = source["linesModified"];
+	        this.totalLines = source["totalLines"];
+	    }
+	}
+	export class FileChange {
+	    path: string;
+	    oldPath: string;
+	    status: string;
+	    linesAdded: number;
+	    linesDeleted: number;
+	    binaryFile: boolean;
+	
+	    static createFrom(source: any = {}) {
+	        return new FileChange(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.path = source["path"];
+	        this.oldPath = source["oldPath"];
+	        this.status = source["status"];
+	        this.linesAdded = source["linesAdded"];
+	        this.linesDeleted = source["linesDeleted"];
+	        this.binaryFile = source["binaryFile"];
+	    }
+	}
+	export class DetailedCommitInfo {
+	    commitHash: string;
+	    username: string;
+	    userEmail: string;
+	    commitTimeStamp: string;
+	    authoredTimeStamp: string;
+	    parentCommitHashes: string[];
+	    refs: string;
+	    commitMessage: string[];
+	    shortStat: string;
+	    changedFiles: FileChange[];
+	    commitStats: CommitStats;
+	    committerName: string;
+	    committerEmail: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new DetailedCommitInfo(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.commitHash = source["commitHash"];
+	        this.username = source["username"];
+	        this.userEmail = source["userEmail"];
+	        this.commitTimeStamp = source["commitTimeStamp"];
+	        this.authoredTimeStamp = source["authoredTimeStamp"];
+	        this.parentCommitHashes = source["parentCommitHashes"];
+	        this.refs = source["refs"];
+	        this.commitMessage = source["commitMessage"];
+	        this.shortStat = source["shortStat"];
+	        this.changedFiles = this.convertValues(source["changedFiles"], FileChange);
+	        this.commitStats = this.convertValues(source["commitStats"], CommitStats);
+	        this.committerName = source["committerName"];
+	        this.committerEmail = source["committerEmail"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class IntralineRange {
+	    start: number;
+	    end: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new IntralineRange(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.start = source["start"];
+	        this.end = source["end"];
+	    }
+	}
+	export class DiffLine {
+	    type: string;
+	    content: string;
+	    oldLineNumber: number;
+	    newLineNumber: number;
+	    noNewlineAtEndOfFile: boolean;
+	    intralineChanges: IntralineRange[];
+	
+	    static createFrom(source: any = {}) {
+	        return new DiffLine(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.type = source["type"];
+	        this.content = source["content"];
+	        this.oldLineNumber = source["oldLineNumber"];
+	        this.newLineNumber = source["newLineNumber"];
+	        this.noNewlineAtEndOfFile = source["noNewlineAtEndOfFile"];
+	        this.intralineChanges = this.convertValues(source["intralineChanges"], IntralineRange);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class DiffHunk {
+	    oldStart: number;
+	    oldLines: number;
+	    newStart: number;
+	    newLines: number;
+	    section: string;
+	    lines: DiffLine[];
+	
+	    static createFrom(source: any = {}) {
+	        return new DiffHunk(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.oldStart = source["oldStart"];
+	        this.oldLines = source["oldLines"];
+	        this.newStart = source["newStart"];
+	        this.newLines = source["newLines"];
+	        this.section = source["section"];
+	        this.lines = this.convertValues(source["lines"], DiffLine);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class DiffFile {
+	    oldPath: string;
+	    newPath: string;
+	    status: string;
+	    oldMode: string;
+	    newMode: string;
+	    similarity: number;
+	    isBinary: boolean;
+	    hunks: DiffHunk[];
+	
+	    static createFrom(source: any = {}) {
+	        return new DiffFile(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.oldPath = source["oldPath"];
+	        this.newPath = source["newPath"];
+	        this.status = source["status"];
+	        this.oldMode = source["oldMode"];
+	        this.newMode = source["newMode"];
+	        this.similarity = source["similarity"];
+	        this.isBinary = source["isBinary"];
+	        this.hunks = this.convertValues(source["hunks"], DiffHunk);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class DiffFileContent {
+	    path: string;
+	    side: string;
+	    exists: boolean;
+	    size: number;
+	    isBinary: boolean;
+	    content: string;
+	    encoding: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new DiffFileContent(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.path = source["path"];
+	        this.side = source["side"];
+	        this.exists = source["exists"];
+	        this.size = source["size"];
+	        this.isBinary = source["isBinary"];
+	        this.content = source["content"];
+	        this.encoding = source["encoding"];
+	    }
+	}
+	
+	
+	export class DiffManifestEntry {
+	    status: string;
+	    path: string;
+	    oldPath: string;
+	    oldMode: string;
+	    newMode: string;
+	    oldBlob: string;
+	    newBlob: string;
+	    oldSize: number;
+	    newSize: number;
+	    newFromWorkingTree: boolean;
+	
+	    static createFrom(source: any = {}) {
+	        return new DiffManifestEntry(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.status = source["status"];
+	        this.path = source["path"];
+	        this.oldPath = source["oldPath"];
+	        this.oldMode = source["oldMode"];
+	        this.newMode = source["newMode"];
+	        this.oldBlob = source["oldBlob"];
+	        this.newBlob = source["newBlob"];
+	        this.oldSize = source["oldSize"];
+	        this.newSize = source["newSize"];
+	        this.newFromWorkingTree = source["newFromWorkingTree"];
+	    }
+	}
+	export class DiffSettings {
+	    ignoreWhitespace: string;
+	    ignoreBlankLines: boolean;
+	    algorithm: string;
+	    renameThreshold: number;
+	    copyThreshold: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new DiffSettings(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.ignoreWhitespace = source["ignoreWhitespace"];
+	        this.ignoreBlankLines = source["ignoreBlankLines"];
+	        this.algorithm = source["algorithm"];
+	        this.renameThreshold = source["renameThreshold"];
+	        this.copyThreshold = source["copyThreshold"];
+	    }
+	}
+	export class DiffOptions {
+	    repoPath: string;
+	    fromRef: string;
+	    toRef: string;
+	    isSingleCommitDiff: boolean;
+	    parentIndex: number;
+	    rangeMode: string;
+	    contextLines?: number;
+	    pathspecs: string[];
+	    diffSettings?: DiffSettings;
+	    lazyLoad: boolean;
+	
+	    static createFrom(source: any = {}) {
+	        return new DiffOptions(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.repoPath = source["repoPath"];
+	        this.fromRef = source["fromRef"];
+	        this.toRef = source["toRef"];
+	        this.isSingleCommitDiff = source["isSingleCommitDiff"];
+	        this.parentIndex = source["parentIndex"];
+	        this.rangeMode = source["rangeMode"];
+	        this.contextLines = source["contextLines"];
+	        this.pathspecs = source["pathspecs"];
+	        this.diffSettings = this.convertValues(source["diffSettings"], DiffSettings);
+	        this.lazyLoad = source["lazyLoad"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class DirectoryStats {
+	    Added: number;
+	    Deleted: number;
+	    Modified: number;
+	    Renamed: number;
+	    Unchanged: number;
+	    Binary: number;
+	    LinesAdded: number;
+	    LinesDeleted: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new DirectoryStats(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.Added = source["Added"];
+	        this.Deleted = source["Deleted"];
+	        this.Modified = source["Modified"];
+	        this.Renamed = source["Renamed"];
+	        this.Unchanged = source["Unchanged"];
+	        this.Binary = source["Binary"];
+	        this.LinesAdded = source["LinesAdded"];
+	        this.LinesDeleted = source["LinesDeleted"];
+	    }
+	}
+	export class FileInfo {
+	    Path: string;
+	    Name: string;
+	    Extension: string;
+	    LeftDirAbsPath: string;
+	    RightDirAbsPath: string;
+	    Status: string;
+	    OldPath: string;
+	    IsBinary: boolean;
+	    LeftSize: number;
+	    RightSize: number;
+	    LinesAdded: number;
+	    LinesDeleted: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new FileInfo(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.Path = source["Path"];
+	        this.Name = source["Name"];
+	        this.Extension = source["Extension"];
+	        this.LeftDirAbsPath = source["LeftDirAbsPath"];
+	        this.RightDirAbsPath = source["RightDirAbsPath"];
+	        this.Status = source["Status"];
+	        this.OldPath = source["OldPath"];
+	        this.IsBinary = source["IsBinary"];
+	        this.LeftSize = source["LeftSize"];
+	        this.RightSize = source["RightSize"];
+	        this.LinesAdded = source["LinesAdded"];
+	        this.LinesDeleted = source["LinesDeleted"];
+	    }
+	}
+	export class Directory {
+	    Path: string;
+	    Name: string;
+	    Files: FileInfo[];
+	    SubDirs: Directory[];
+	    Stats: DirectoryStats;
+	
+	    static createFrom(source: any = {}) {
+	        return new Directory(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.Path = source["Path"];
+	        this.Name = source["Name"];
+	        this.Files = this.convertValues(source["Files"], FileInfo);
+	        this.SubDirs = this.convertValues(source["SubDirs"], Directory);
+	        this.Stats = this.convertValues(source["Stats"], DirectoryStats);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class DiffSession {
+	    sessionId: string;
+	    repoPath: string;
+	    fromRef: string;
+	    toRef: string;
+	    leftPath: string;
+	    rightPath: string;
+	    // Go type: time
+	    createdAt: any;
+	    // Go type: time
+	    lastAccessed: any;
+	    title: string;
+	    directoryData?: Directory;
+	    hasDiffData: boolean;
+	    commitInformation?: GitLogCommitInfo;
+	    isLazy: boolean;
+	    manifest: DiffManifestEntry[];
+	    options: DiffOptions;
+	    diskSize: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new DiffSession(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.sessionId = source["sessionId"];
+	        this.repoPath = source["repoPath"];
+	        this.fromRef = source["fromRef"];
+	        this.toRef = source["toRef"];
+	        this.leftPath = source["leftPath"];
+	        this.rightPath = source["rightPath"];
+	        this.createdAt = this.convertValues(source["createdAt"], null);
+	        this.lastAccessed = this.convertValues(source["lastAccessed"], null);
+	        this.title = source["title"];
+	        this.directoryData = this.convertValues(source["directoryData"], Directory);
+	        this.hasDiffData = source["hasDiffData"];
+	        this.commitInformation = this.convertValues(source["commitInformation"], GitLogCommitInfo);
+	        this.isLazy = source["isLazy"];
+	        this.manifest = this.convertValues(source["manifest"], DiffManifestEntry);
+	        this.options = this.convertValues(source["options"], DiffOptions);
+	        this.diskSize = source["diskSize"];
 	    }
 	
 		convertValues(a: any, classs: any, asMap: boolean = false): any {
@@ -688,6 +1332,22 @@ export namespace git_operations {
 		    return a;
 		}
 	}
+	export class DiffSessionSettings {
+	    maxDiskUsageMB: number;
+	    maxIdleHours: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new DiffSessionSettings(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.maxDiskUsageMB = source["maxDiskUsageMB"];
+	        this.maxIdleHours = source["maxIdleHours"];
+	    }
+	}
+	
+	
 	
 	
 	
@@ -697,6 +1357,7 @@ export namespace git_operations {
 	    fromRef?: string;
 	    searchQuery?: string;
 	    commitsToSkip?: number;
+	    paths?: string[];
 	
 	    static createFrom(source: any = {}) {
 	        return new GitLogOptions(source);
@@ -708,6 +1369,7 @@ export namespace git_operations {
 	        this.fromRef = source["fromRef"];
 	        this.searchQuery = source["searchQuery"];
 	        this.commitsToSkip = source["commitsToSkip"];
+	        this.paths = source["paths"];
 	    }
 	}
 	export class GitRef {
@@ -732,6 +1394,7 @@ export namespace git_operations {
 	    stagedStatus: string;
 	    workingStatus: string;
 	    oldPath: string;
+	    isConflicted: boolean;
 	
 	    static createFrom(source: any = {}) {
 	        return new GitStatusFile(source);
@@ -744,6 +1407,7 @@ export namespace git_operations {
 	        this.stagedStatus = source["stagedStatus"];
 	        this.workingStatus = source["workingStatus"];
 	        this.oldPath = source["oldPath"];
+	        this.isConflicted = source["isConflicted"];
 	    }
 	}
 	export class GitStatus {
@@ -783,16 +1447,211 @@ export namespace git_operations {
 		}
 	}
 	
+	export class GrepOptions {
+	    repoPath: string;
+	    query: string;
+	    target: string;
+	    ref: string;
+	    isRegex: boolean;
+	    caseSensitive: boolean;
+	    pathspecs: string[];
+	    contextLines: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new GrepOptions(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.repoPath = source["repoPath"];
+	        this.query = source["query"];
+	        this.target = source["target"];
+	        this.ref = source["ref"];
+	        this.isRegex = source["isRegex"];
+	        this.caseSensitive = source["caseSensitive"];
+	        this.pathspecs = source["pathspecs"];
+	        this.contextLines = source["contextLines"];
+	    }
+	}
+	
+	
+	export class RangeDiffOptions {
+	    repoPath: string;
+	    oldRange: string;
+	    newRange: string;
+	    branch: string;
+	    reflogEntry: number;
+	    base: string;
+	    creationFactor: number;
+	    diffSettings?: DiffSettings;
+	
+	    static createFrom(source: any = {}) {
+	        return new RangeDiffOptions(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.repoPath = source["repoPath"];
+	        this.oldRange = source["oldRange"];
+	        this.newRange = source["newRange"];
+	        this.branch = source["branch"];
+	        this.reflogEntry = source["reflogEntry"];
+	        this.base = source["base"];
+	        this.creationFactor = source["creationFactor"];
+	        this.diffSettings = this.convertValues(source["diffSettings"], DiffSettings);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class RangeDiffPair {
+	    status: string;
+	    oldIndex: number;
+	    newIndex: number;
+	    oldCommit?: GitLogCommitInfo;
+	    newCommit?: GitLogCommitInfo;
+	    interdiff: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new RangeDiffPair(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.status = source["status"];
+	        this.oldIndex = source["oldIndex"];
+	        this.newIndex = source["newIndex"];
+	        this.oldCommit = this.convertValues(source["oldCommit"], GitLogCommitInfo);
+	        this.newCommit = this.convertValues(source["newCommit"], GitLogCommitInfo);
+	        this.interdiff = source["interdiff"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class RangeDiffResult {
+	    oldRange: string;
+	    newRange: string;
+	    pairs: RangeDiffPair[];
+	
+	    static createFrom(source: any = {}) {
+	        return new RangeDiffResult(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.oldRange = source["oldRange"];
+	        this.newRange = source["newRange"];
+	        this.pairs = this.convertValues(source["pairs"], RangeDiffPair);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class RefComparison {
+	    repoPath: string;
+	    refA: string;
+	    refB: string;
+	    mergeBase: string;
+	    commitsOnlyInA: GitLogCommitInfo[];
+	    commitsOnlyInB: GitLogCommitInfo[];
+	    aheadCount: number;
+	    behindCount: number;
+	    canFastForward: boolean;
+	    diffSession?: DiffSession;
+	
+	    static createFrom(source: any = {}) {
+	        return new RefComparison(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.repoPath = source["repoPath"];
+	        this.refA = source["refA"];
+	        this.refB = source["refB"];
+	        this.mergeBase = source["mergeBase"];
+	        this.commitsOnlyInA = this.convertValues(source["commitsOnlyInA"], GitLogCommitInfo);
+	        this.commitsOnlyInB = this.convertValues(source["commitsOnlyInB"], GitLogCommitInfo);
+	        this.aheadCount = source["aheadCount"];
+	        this.behindCount = source["behindCount"];
+	        this.canFastForward = source["canFastForward"];
+	        this.diffSession = this.convertValues(source["diffSession"], DiffSession);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
 	export class StagingDiffInfo {
-	    sessionId: string;
 	    filePath: string;
+	    oldPath: string;
 	    fileType: string;
-	    leftPath: string;
-	    rightPath: string;
 	    leftLabel: string;
 	    rightLabel: string;
 	    // Go type: time
 	    createdAt: any;
+	    left: DiffFileContent;
+	    right: DiffFileContent;
+	    binaryInfo?: BinaryDiffInfo;
 	
 	    static createFrom(source: any = {}) {
 	        return new StagingDiffInfo(source);
@@ -800,14 +1659,49 @@ export namespace git_operations {
 	
 	    constructor(source: any = {}) {
 	        if ('string' === typeof source) source = JSON.parse(source);
-	        this.sessionId = source["sessionId"];
 	        this.filePath = source["filePath"];
+	        this.oldPath = source["oldPath"];
 	        this.fileType = source["fileType"];
-	        this.leftPath = source["leftPath"];
-	        this.rightPath = source["rightPath"];
 	        this.leftLabel = source["leftLabel"];
 	        this.rightLabel = source["rightLabel"];
 	        this.createdAt = this.convertValues(source["createdAt"], null);
+	        this.left = this.convertValues(source["left"], DiffFileContent);
+	        this.right = this.convertValues(source["right"], DiffFileContent);
+	        this.binaryInfo = this.convertValues(source["binaryInfo"], BinaryDiffInfo);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class UnifiedDiff {
+	    fromRef: string;
+	    toRef: string;
+	    files: DiffFile[];
+	
+	    static createFrom(source: any = {}) {
+	        return new UnifiedDiff(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.fromRef = source["fromRef"];
+	        this.toRef = source["toRef"];
+	        this.files = this.convertValues(source["files"], DiffFile);
 	    }
 	
 		convertValues(a: any, classs: any, asMap: boolean = false): any {
@@ -847,6 +1741,127 @@ export namespace git_operations {
 	    }
 	}
 
+	export class PatchApplyOptions {
+	    repoPath: string;
+	    patchPath: string;
+	    dryRun: boolean;
+	
+	    static createFrom(source: any = {}) {
+	        return new PatchApplyOptions(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.repoPath = source["repoPath"];
+	        this.patchPath = source["patchPath"];
+	        this.dryRun = source["dryRun"];
+	    }
+	}
+	export class PatchHunkCheck {
+	    path: string;
+	    hunkIndex: number;
+	    oldStart: number;
+	    oldLines: number;
+	    newStart: number;
+	    newLines: number;
+	    applies: boolean;
+	    error: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new PatchHunkCheck(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.path = source["path"];
+	        this.hunkIndex = source["hunkIndex"];
+	        this.oldStart = source["oldStart"];
+	        this.oldLines = source["oldLines"];
+	        this.newStart = source["newStart"];
+	        this.newLines = source["newLines"];
+	        this.applies = source["applies"];
+	        this.error = source["error"];
+	    }
+	}
+	export class PatchApplyResult {
+	    isMailbox: boolean;
+	    dryRun: boolean;
+	    applied: boolean;
+	    usedThreeWay: boolean;
+	    hunks: PatchHunkCheck[];
+	    conflictedFiles: string[];
+	    amInProgress: boolean;
+	    output: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new PatchApplyResult(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.isMailbox = source["isMailbox"];
+	        this.dryRun = source["dryRun"];
+	        this.applied = source["applied"];
+	        this.usedThreeWay = source["usedThreeWay"];
+	        this.hunks = this.convertValues(source["hunks"], PatchHunkCheck);
+	        this.conflictedFiles = source["conflictedFiles"];
+	        this.amInProgress = source["amInProgress"];
+	        this.output = source["output"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class PatchExportOptions {
+	    repoPath: string;
+	    fromRef: string;
+	    toRef: string;
+	    format: string;
+	    outputPath: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new PatchExportOptions(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.repoPath = source["repoPath"];
+	        this.fromRef = source["fromRef"];
+	        this.toRef = source["toRef"];
+	        this.format = source["format"];
+	        this.outputPath = source["outputPath"];
+	    }
+	}
+	export class PatchExportResult {
+	    files: string[];
+	    commitCount: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new PatchExportResult(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.files = source["files"];
+	        this.commitCount = source["commitCount"];
+	    }
+	}
+
 }
 
 export namespace logger {
@@ -3,10 +3,12 @@ package main
 import (
 	"context"
 	"embed"
+	"errors"
 	"fmt"
 	"os"
 
 	"gitwhale/backend"
+	"gitwhale/backend/command_utils"
 	"gitwhale/backend/lib"
 	"gitwhale/backend/logger"
 
@@ -22,6 +24,34 @@ import (
 var assets embed.FS
 
 func main() {
+	// git runs this executable through the askpass script when it needs credentials
+	if len(os.Args) > 1 && os.Args[1] == command_utils.AskPassFlag {
+		os.Exit(command_utils.RunAskPassHelper(os.Args[2:]))
+	}
+
+	// Reports write to stdout, so the logs of parsing the command line (which runs git) can't go there
+	if len(os.Args) > 1 && os.Args[1] == "report" {
+		logger.Log.SetHeadless(logger.Warning)
+	}
+
+	startupState, err := backend.ParseCommandLine(os.Args[1:])
+	switch {
+	case errors.Is(err, backend.ErrHelpRequested):
+		fmt.Print(backend.CommandLineUsage)
+		return
+	case errors.Is(err, backend.ErrVersionRequested):
+		fmt.Printf("%s %s\n", lib.APP_NAME, lib.APP_VERSION)
+		return
+	case err != nil:
+		fmt.Fprintf(os.Stderr, "Error: %v\n\n%s", err, backend.CommandLineUsage)
+		os.Exit(2)
+	}
+
+	// Reports are written without creating a window
+	if startupState.Command == backend.StartupCommandReport {
+		os.Exit(backend.RunHeadlessReport(startupState.Report))
+	}
+
 	// Create an instance of the app structure
 	app := backend.NewApp()
 
@@ -29,20 +59,21 @@ func main() {
 
 	pid := os.Getpid()
 
-	startupState := backend.GetStartupState()
-	if startupState != nil && startupState.DirectoryDiffArgs != nil && startupState.DirectoryDiffArgs.IsFileDiff {
-		if startupState.DirectoryDiffArgs.ShouldSendNotification {
-			// Make the other GitWhale process show the diff instead
-			if err := backend.SendFileDiffNotification(startupState.DirectoryDiffArgs.LeftPath, startupState.DirectoryDiffArgs.RightPath); err != nil {
-				fmt.Printf("Error sending file diff notification: %v\n", err)
-				os.Exit(1)
-			}
-			return
+	// Make the running GitWhale process show it instead, if there is one. Otherwise this process opens its own window
+	if instanceRequest := startupState.InstanceRequest(); instanceRequest != nil {
+		forwarded, exitCode, err := backend.ForwardToRunningInstance(*instanceRequest)
+		if err != nil && forwarded {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		} else if err != nil {
+			fmt.Printf("Error forwarding to the running instance, opening a new window: %v\n", err)
+		}
+		if forwarded {
+			os.Exit(exitCode)
 		}
 	}
 
 	// Create application with options
-	err := wails.Run(&options.App{
+	err = wails.Run(&options.App{
 		Title:  fmt.Sprint("[", pid, "] ", lib.APP_NAME),
 		Width:  1300,
 		Height: 768,
@@ -71,4 +102,9 @@ func main() {
 	if err != nil {
 		println("Error:", err.Error())
 	}
+
+	// git's mergetool reads whether the merge was resolved from the exit code
+	if exitCode := app.ExitCode(); exitCode != 0 {
+		os.Exit(exitCode)
+	}
 }